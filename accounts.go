@@ -12,7 +12,7 @@ type Account struct {
 
 func (a *Account) GetAccountFunds() (AccountFundsResponse, error) {
 	// create url
-	url := createURL(Endpoints.Account, getAccountFunds)
+	url := createURL(a.Client.Endpoints.Account, getAccountFunds)
 
 	// build request
 	params := struct {
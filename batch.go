@@ -0,0 +1,174 @@
+package gofair
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BatchCall is a call added to a Batch. Result and Err are set by Batch.Send; Err holds the error of this call alone.
+type BatchCall[T any] struct {
+	Result T
+	Err    error
+
+	operation string
+	params    interface{}
+}
+
+func (call *BatchCall[T]) request() (string, interface{}) {
+	return call.operation, call.params
+}
+
+func (call *BatchCall[T]) resolve(result json.RawMessage, err error) {
+	if err != nil {
+		call.Err = err
+		return
+	}
+	call.Err = json.Unmarshal(result, &call.Result)
+}
+
+type batchCall interface {
+	request() (operation string, params interface{})
+	resolve(result json.RawMessage, err error)
+}
+
+// Batch sends several read-only betting calls to the Exchange in a single JSON-RPC request, whatever the Client's
+// Transport. Add calls with its methods, then Send it once.
+type Batch struct {
+	betting *Betting
+	calls   []batchCall
+}
+
+// NewBatch creates an empty Batch.
+func (b *Betting) NewBatch() *Batch {
+	return &Batch{betting: b}
+}
+
+// Len returns the number of calls in the batch
+func (batch *Batch) Len() int {
+	return len(batch.calls)
+}
+
+func addCall[T any](batch *Batch, operation string, params interface{}) *BatchCall[T] {
+	call := &BatchCall[T]{operation: strings.TrimSuffix(operation, "/"), params: params}
+	batch.calls = append(batch.calls, call)
+	return call
+}
+
+// ListEventTypes adds a listEventTypes call, see Betting.ListEventTypes
+func (batch *Batch) ListEventTypes(filter MarketFilter) *BatchCall[[]EventTypeResult] {
+	return addCall[[]EventTypeResult](batch, listEventTypes, filterParams(filter))
+}
+
+// ListCompetitions adds a listCompetitions call, see Betting.ListCompetitions
+func (batch *Batch) ListCompetitions(filter MarketFilter) *BatchCall[[]CompetitionResult] {
+	return addCall[[]CompetitionResult](batch, listCompetitions, filterParams(filter))
+}
+
+// ListEvents adds a listEvents call, see Betting.ListEvents
+func (batch *Batch) ListEvents(filter MarketFilter) *BatchCall[[]EventResult] {
+	return addCall[[]EventResult](batch, listEvents, filterParams(filter))
+}
+
+// ListMarketTypes adds a listMarketTypes call, see Betting.ListMarketTypes
+func (batch *Batch) ListMarketTypes(filter MarketFilter) *BatchCall[[]MarketTypeResult] {
+	return addCall[[]MarketTypeResult](batch, listMarketTypes, filterParams(filter))
+}
+
+// ListCountries adds a listCountries call, see Betting.ListCountries
+func (batch *Batch) ListCountries(filter MarketFilter) *BatchCall[[]CountryResult] {
+	return addCall[[]CountryResult](batch, listCountries, filterParams(filter))
+}
+
+// ListVenues adds a listVenues call, see Betting.ListVenues
+func (batch *Batch) ListVenues(filter MarketFilter) *BatchCall[[]VenueResult] {
+	return addCall[[]VenueResult](batch, listVenues, filterParams(filter))
+}
+
+// ListMarketCatalogue adds a listMarketCatalogue call, see Betting.ListMarketCatalogue
+func (batch *Batch) ListMarketCatalogue(filter MarketFilter, marketProjection []MarketProjection, sort MarketSort, maxResults int) *BatchCall[[]MarketCatalogue] {
+	return addCall[[]MarketCatalogue](batch, listMarketCatalogue, listMarketCatalogueParams(filter, marketProjection, sort, maxResults))
+}
+
+// ListMarketBook adds a listMarketBook call, see Betting.ListMarketBook
+func (batch *Batch) ListMarketBook(marketIDs []string, displayOrders bool) *BatchCall[[]MarketBook] {
+	return addCall[[]MarketBook](batch, listMarketBook, listMarketBookParams(marketIDs, displayOrders))
+}
+
+// ListMarketProfitAndLoss adds a listMarketProfitAndLoss call, see Betting.ListMarketProfitAndLoss
+func (batch *Batch) ListMarketProfitAndLoss(marketIDs []string) *BatchCall[[]MarketProfitAndLoss] {
+	return addCall[[]MarketProfitAndLoss](batch, listMarketProfitAndLoss, marketIDsParams(marketIDs))
+}
+
+// ListCurrentOrders adds a listCurrentOrders call, see Betting.ListCurrentOrders
+func (batch *Batch) ListCurrentOrders(betIDs []string, marketIDs []string, orderProjection OrderProjection) *BatchCall[CurrentOrderSummaryReport] {
+	return addCall[CurrentOrderSummaryReport](batch, listCurrentOrders, listCurrentOrdersParams(betIDs, marketIDs, orderProjection))
+}
+
+// Send makes every call in the batch in one request, waiting on the Limiter for each and retrying the whole request
+// if it fails with a transient error. The returned error is only set if the request as a whole failed, in which case it
+// is also set on every call; otherwise the error of each call is on its BatchCall.
+func (batch *Batch) Send() error {
+
+	if len(batch.calls) == 0 {
+		return nil
+	}
+	c := batch.betting.Client
+
+	requests := make([]rpcRequest, len(batch.calls))
+	for i, call := range batch.calls {
+		operation, params := call.request()
+		params = c.localise(operation+"/", params)
+		requests[i] = rpcRequest{JSONRPC: "2.0", Method: bettingRPCPrefix + operation, Params: params, ID: i + 1}
+	}
+
+	bytes, err := json.Marshal(requests)
+	if err != nil {
+		return err
+	}
+
+	var responses []rpcResponse
+	err = c.withRetry("batch", true, func() (err error) {
+		for _, call := range batch.calls {
+			operation, _ := call.request()
+			c.wait(createURL(c.Endpoints.Betting, operation))
+		}
+
+		start := time.Now()
+		defer func() {
+			c.observe("batch", start, err)
+		}()
+
+		data, err := c.post(c.Endpoints.BettingRPC, "batch", bytes)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, &responses)
+	})
+	if err != nil {
+		for _, call := range batch.calls {
+			call.resolve(nil, err)
+		}
+		return err
+	}
+
+	byID := make(map[int]rpcResponse, len(responses))
+	for _, response := range responses {
+		byID[response.ID] = response
+	}
+
+	for i, call := range batch.calls {
+		response, ok := byID[i+1]
+		switch {
+		case !ok:
+			operation, _ := call.request()
+			call.resolve(nil, fmt.Errorf("No response to batched %s call", operation))
+		case response.Error != nil:
+			call.resolve(nil, response.Error.apiError())
+		default:
+			call.resolve(response.Result, nil)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Package betfairtest provides local stand-ins for the Betfair Exchange REST and Stream APIs, so that the gofair Client
+// and Stream can be exercised end to end without network access or a Betfair account.
+package betfairtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Certificate is a self-signed key pair, usable both as a server certificate and as a client certificate.
+type Certificate struct {
+	CertPEM []byte
+	KeyPEM  []byte
+	TLS     tls.Certificate
+	x509    *x509.Certificate
+}
+
+// NewCertificate generates a self-signed certificate valid for localhost.
+func NewCertificate() (*Certificate, error) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{Organization: []string{"gofair test"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	cert := new(Certificate)
+	cert.CertPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	cert.KeyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	cert.TLS, err = tls.X509KeyPair(cert.CertPEM, cert.KeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	cert.x509, err = x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return cert, nil
+}
+
+// CertPool returns a pool which trusts the certificate.
+func (cert *Certificate) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.x509)
+	return pool
+}
+
+// WriteFiles writes the certificate and key to dir as PEM files, returning their paths for use in a config.Config.
+func (cert *Certificate) WriteFiles(dir string) (certFile string, keyFile string, err error) {
+
+	certFile = filepath.Join(dir, "client.crt")
+	keyFile = filepath.Join(dir, "client.key")
+
+	if err = os.WriteFile(certFile, cert.CertPEM, 0600); err != nil {
+		return "", "", err
+	}
+	if err = os.WriteFile(keyFile, cert.KeyPEM, 0600); err != nil {
+		return "", "", err
+	}
+
+	return certFile, keyFile, nil
+}
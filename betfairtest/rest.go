@@ -0,0 +1,243 @@
+package betfairtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+const rpcPath = "/json-rpc/"
+
+const (
+	// AppKey is the application key accepted by the test servers
+	AppKey = "test-app-key"
+	// SessionToken is the session token issued by RESTServer and accepted by StreamServer
+	SessionToken = "test-session-token"
+)
+
+// Request is a REST request received by RESTServer
+type Request struct {
+	Path         string
+	AppKey       string
+	SessionToken string
+	Body         []byte
+}
+
+type restResponse struct {
+	status int
+	body   []byte
+}
+
+// RESTServer is an httptest server standing in for the identity, betting and account endpoints. Login, keepAlive and
+// logout are answered by default unless overridden; every other operation returns 404 until a response is registered with Handle.
+type RESTServer struct {
+	*httptest.Server
+
+	// Username and Password are the credentials accepted by certlogin, any credentials are accepted if Username is empty
+	Username string
+	Password string
+
+	mu        sync.Mutex
+	responses map[string]restResponse
+	failures  map[string][]restResponse
+	requests  []Request
+}
+
+// NewRESTServer starts a RESTServer, which must be closed by the caller.
+func NewRESTServer() *RESTServer {
+
+	server := new(RESTServer)
+	server.responses = make(map[string]restResponse)
+	server.failures = make(map[string][]restResponse)
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+
+	server.Handle("/identity/keepAlive", map[string]string{"token": SessionToken, "product": AppKey, "status": "SUCCESS", "error": ""})
+	server.Handle("/identity/logout", map[string]string{"token": "", "product": AppKey, "status": "SUCCESS", "error": ""})
+
+	return server
+}
+
+// LoginURL is the base URL of the certificate login endpoint
+func (server *RESTServer) LoginURL() string {
+	return server.URL + "/login/"
+}
+
+// IdentityURL is the base URL of the keepAlive and logout endpoints
+func (server *RESTServer) IdentityURL() string {
+	return server.URL + "/identity/"
+}
+
+// BettingURL is the base URL of the betting endpoints
+func (server *RESTServer) BettingURL() string {
+	return server.URL + "/betting/"
+}
+
+// AccountURL is the base URL of the account endpoints
+func (server *RESTServer) AccountURL() string {
+	return server.URL + "/account/"
+}
+
+// BettingRPCURL is the JSON-RPC endpoint of the betting API. Calls are answered with the responses registered for the
+// same operation with HandleBetting.
+func (server *RESTServer) BettingRPCURL() string {
+	return server.URL + rpcPath + "betting"
+}
+
+// AccountRPCURL is the JSON-RPC endpoint of the account API. Calls are answered with the responses registered for the
+// same operation with HandleAccount.
+func (server *RESTServer) AccountRPCURL() string {
+	return server.URL + rpcPath + "account"
+}
+
+// Handle registers the response returned, as JSON, for requests to path.
+func (server *RESTServer) Handle(path string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.responses[routeKey(path)] = restResponse{status: http.StatusOK, body: b}
+
+	return nil
+}
+
+// HandleBetting registers the response returned for a betting operation such as "listEventTypes".
+func (server *RESTServer) HandleBetting(operation string, v interface{}) error {
+	return server.Handle("/betting/"+operation, v)
+}
+
+// HandleAccount registers the response returned for an account operation such as "getAccountFunds".
+func (server *RESTServer) HandleAccount(operation string, v interface{}) error {
+	return server.Handle("/account/"+operation, v)
+}
+
+// HandleStatus makes requests to path fail with the given HTTP status code.
+func (server *RESTServer) HandleStatus(path string, status int) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.responses[routeKey(path)] = restResponse{status: status}
+}
+
+// FailNext makes the next times requests to path fail with the given HTTP status code, and an APINGException body if
+// errorCode is set, before the registered response is returned again.
+func (server *RESTServer) FailNext(path string, status int, errorCode string, times int) {
+	response := restResponse{status: status}
+	if errorCode != "" {
+		response.body, _ = json.Marshal(map[string]interface{}{
+			"faultcode":   "Client",
+			"faultstring": errorCode,
+			"detail":      map[string]interface{}{"APINGException": map[string]string{"errorCode": errorCode}},
+		})
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for i := 0; i < times; i++ {
+		server.failures[routeKey(path)] = append(server.failures[routeKey(path)], response)
+	}
+}
+
+// Requests returns every request received so far, in order
+func (server *RESTServer) Requests() []Request {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]Request(nil), server.requests...)
+}
+
+func (server *RESTServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	request := Request{
+		Path:         r.URL.Path,
+		AppKey:       r.Header.Get("X-Application"),
+		SessionToken: r.Header.Get("X-Authentication"),
+		Body:         body,
+	}
+
+	server.mu.Lock()
+	server.requests = append(server.requests, request)
+	server.mu.Unlock()
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, rpcPath) {
+		server.serveRPC(w, request)
+		return
+	}
+
+	response, ok := server.next(r.URL.Path)
+
+	login := routeKey(r.URL.Path) == "/login/certlogin"
+
+	if !ok && login {
+		server.login(w, r, body)
+		return
+	}
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if !login && (request.AppKey != AppKey || request.SessionToken != SessionToken) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if response.status != http.StatusOK {
+		w.WriteHeader(response.status)
+		w.Write(response.body)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response.body)
+}
+
+// next returns the response to a request to path, taking the first pending failure if there is one
+func (server *RESTServer) next(path string) (restResponse, bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	response, ok := server.responses[routeKey(path)]
+	if failures := server.failures[routeKey(path)]; len(failures) > 0 {
+		response, ok = failures[0], true
+		server.failures[routeKey(path)] = failures[1:]
+	}
+	return response, ok
+}
+
+func (server *RESTServer) login(w http.ResponseWriter, r *http.Request, body []byte) {
+
+	result := map[string]string{"loginStatus": "SUCCESS", "sessionToken": SessionToken}
+
+	r.Body = io.NopCloser(strings.NewReader(string(body)))
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if server.Username != "" && (r.PostForm.Get("username") != server.Username || r.PostForm.Get("password") != server.Password) {
+		result = map[string]string{"loginStatus": "INVALID_USERNAME_OR_PASSWORD", "sessionToken": ""}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func routeKey(path string) string {
+	return "/" + strings.Trim(path, "/")
+}
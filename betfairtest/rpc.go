@@ -0,0 +1,108 @@
+package betfairtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// JSON-RPC error codes returned by RPC endpoints of RESTServer
+const (
+	RPCMethodNotFound = -32601
+	RPCAPINGException = -32099
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   interface{}     `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcRoutes maps the JSON-RPC method prefixes onto the paths their responses are registered under
+var rpcRoutes = map[string]string{
+	"SportsAPING/v1.0/":  "/betting/",
+	"AccountAPING/v1.0/": "/account/",
+}
+
+// serveRPC answers a single or batched JSON-RPC request. Failures registered with FailNext are returned as a JSON-RPC
+// APINGException if they have an error code, otherwise the whole request fails with their HTTP status.
+func (server *RESTServer) serveRPC(w http.ResponseWriter, request Request) {
+
+	if request.AppKey != AppKey || request.SessionToken != SessionToken {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	body := bytes.TrimSpace(request.Body)
+	batch := len(body) > 0 && body[0] == '['
+
+	var requests []rpcRequest
+	if !batch {
+		body = append(append([]byte("["), body...), ']')
+	}
+	if err := json.Unmarshal(body, &requests); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	responses := make([]rpcResponse, len(requests))
+	for i, call := range requests {
+		response, status := server.call(call)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		responses[i] = response
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if batch {
+		json.NewEncoder(w).Encode(responses)
+	} else {
+		json.NewEncoder(w).Encode(responses[0])
+	}
+}
+
+// call answers one JSON-RPC call, returning an HTTP status other than 200 OK if the whole request should fail
+func (server *RESTServer) call(call rpcRequest) (rpcResponse, int) {
+
+	response := rpcResponse{JSONRPC: "2.0", ID: call.ID}
+	notFound := map[string]interface{}{"code": RPCMethodNotFound, "message": "Method not found"}
+
+	path := ""
+	for prefix, route := range rpcRoutes {
+		if strings.HasPrefix(call.Method, prefix) {
+			path = route + strings.TrimPrefix(call.Method, prefix)
+		}
+	}
+	if path == "" {
+		response.Error = notFound
+		return response, http.StatusOK
+	}
+
+	result, ok := server.next(path)
+	switch {
+	case !ok:
+		response.Error = notFound
+	case result.status == http.StatusOK:
+		response.Result = result.body
+	case result.body != nil:
+		var fault struct {
+			Detail json.RawMessage `json:"detail"`
+		}
+		json.Unmarshal(result.body, &fault)
+		response.Error = map[string]interface{}{"code": RPCAPINGException, "message": "ANGX-0001", "data": fault.Detail}
+	default:
+		return response, result.status
+	}
+	return response, http.StatusOK
+}
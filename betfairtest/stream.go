@@ -0,0 +1,369 @@
+package betfairtest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultMaxConnections is the number of concurrent connections a StreamServer accepts before reporting that none
+// are available
+const DefaultMaxConnections = 10
+
+// Message is a request received by StreamServer
+type Message struct {
+	Op  string
+	ID  int32
+	Raw []byte
+}
+
+type streamFailure struct {
+	errorCode    string
+	errorMessage string
+}
+
+type streamConn struct {
+	conn          net.Conn
+	mu            sync.Mutex
+	authenticated bool
+}
+
+func (conn *streamConn) write(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	_, err = conn.conn.Write(append(b, '\r', '\n'))
+	return err
+}
+
+// StreamServer is a TLS server standing in for the Exchange Stream API. It speaks the CRLF delimited JSON protocol:
+// it sends a ConnectionMessage when a client connects, authenticates against AppKey and SessionToken, acknowledges
+// subscriptions and heartbeats with a StatusMessage and follows each subscription with its scripted change messages.
+type StreamServer struct {
+	// Addr is the host:port the server is listening on, to be passed to Stream.Start
+	Addr        string
+	Certificate *Certificate
+
+	// MaxConnections is the number of concurrent connections allowed before connectionsAvailable reaches zero
+	MaxConnections int
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	conns    map[*streamConn]struct{}
+	scripts  map[string][]json.RawMessage
+	failures map[string]streamFailure
+	received []Message
+	nextConn int
+}
+
+// NewStreamServer starts a StreamServer on a local port, which must be closed by the caller.
+func NewStreamServer() (*StreamServer, error) {
+
+	cert, err := NewCertificate()
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert.TLS},
+		ClientAuth:   tls.RequestClientCert,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	server := new(StreamServer)
+	server.Addr = listener.Addr().String()
+	server.Certificate = cert
+	server.MaxConnections = DefaultMaxConnections
+	server.listener = listener
+	server.conns = make(map[*streamConn]struct{})
+	server.scripts = make(map[string][]json.RawMessage)
+	server.failures = make(map[string]streamFailure)
+
+	server.wg.Add(1)
+	go server.accept()
+
+	return server, nil
+}
+
+// ClientTLSConfig returns a TLS configuration which trusts the server, for use as Stream.TLSConfig
+func (server *StreamServer) ClientTLSConfig() *tls.Config {
+	return &tls.Config{RootCAs: server.Certificate.CertPool()}
+}
+
+// Script sets the change messages sent after a subscription request with the given op ("marketSubscription",
+// "orderSubscription" or "raceSubscription") has been acknowledged. The id of each message is set to the id of the
+// request. Without a script an empty SUB_IMAGE is sent.
+func (server *StreamServer) Script(op string, messages ...string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	script := make([]json.RawMessage, len(messages))
+	for i, message := range messages {
+		script[i] = json.RawMessage(message)
+	}
+	server.scripts[op] = script
+}
+
+// FailSubscription makes subscription requests with the given op fail with the errorCode and errorMessage.
+func (server *StreamServer) FailSubscription(op string, errorCode string, errorMessage string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.failures[op] = streamFailure{errorCode: errorCode, errorMessage: errorMessage}
+}
+
+// Send writes a message to every authenticated connection.
+func (server *StreamServer) Send(message string) error {
+	var v json.RawMessage = []byte(message)
+
+	server.mu.Lock()
+	conns := make([]*streamConn, 0, len(server.conns))
+	for conn := range server.conns {
+		if conn.authenticated {
+			conns = append(conns, conn)
+		}
+	}
+	server.mu.Unlock()
+
+	for _, conn := range conns {
+		if err := conn.write(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Received returns every request received so far, in order
+func (server *StreamServer) Received() []Message {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]Message(nil), server.received...)
+}
+
+// Connections returns the number of open connections
+func (server *StreamServer) Connections() int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return len(server.conns)
+}
+
+// Close stops the listener and closes every open connection.
+func (server *StreamServer) Close() {
+	server.listener.Close()
+
+	server.mu.Lock()
+	for conn := range server.conns {
+		conn.conn.Close()
+	}
+	server.mu.Unlock()
+
+	server.wg.Wait()
+}
+
+func (server *StreamServer) accept() {
+	defer server.wg.Done()
+
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		server.wg.Add(1)
+		go server.serve(&streamConn{conn: conn})
+	}
+}
+
+func (server *StreamServer) serve(conn *streamConn) {
+	defer server.wg.Done()
+	defer conn.conn.Close()
+
+	server.mu.Lock()
+	server.conns[conn] = struct{}{}
+	server.nextConn++
+	connectionID := "001-" + strconv.Itoa(server.nextConn)
+	server.mu.Unlock()
+
+	defer func() {
+		server.mu.Lock()
+		delete(server.conns, conn)
+		server.mu.Unlock()
+	}()
+
+	if err := conn.write(map[string]string{"op": "connection", "connectionId": connectionID}); err != nil {
+		return
+	}
+
+	reader := bufio.NewReader(conn.conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			continue
+		}
+
+		if !server.handle(conn, line) {
+			return
+		}
+	}
+}
+
+// handle replies to a single request, returning false if the connection should be closed
+func (server *StreamServer) handle(conn *streamConn, line []byte) bool {
+
+	var request struct {
+		Op      string `json:"op"`
+		ID      int32  `json:"id"`
+		AppKey  string `json:"appKey"`
+		Session string `json:"session"`
+	}
+
+	if err := json.Unmarshal(line, &request); err != nil {
+		conn.write(failureStatus(0, "INVALID_INPUT", err.Error(), true))
+		return false
+	}
+
+	server.mu.Lock()
+	server.received = append(server.received, Message{Op: request.Op, ID: request.ID, Raw: append([]byte(nil), line...)})
+	server.mu.Unlock()
+
+	if request.Op == "authentication" {
+		return server.authenticate(conn, request.ID, request.AppKey, request.Session)
+	}
+
+	if !conn.authenticated {
+		conn.write(failureStatus(request.ID, "NOT_AUTHORIZED", "Connection is not authenticated", true))
+		return false
+	}
+
+	switch request.Op {
+
+	case "heartbeat":
+		conn.write(successStatus(request.ID))
+		return true
+
+	case "marketSubscription", "orderSubscription", "raceSubscription":
+		server.subscribe(conn, request.Op, request.ID)
+		return true
+	}
+
+	conn.write(failureStatus(request.ID, "INVALID_REQUEST", "Unknown op "+request.Op, true))
+	return false
+}
+
+func (server *StreamServer) authenticate(conn *streamConn, id int32, appKey string, session string) bool {
+
+	if appKey != AppKey {
+		conn.write(failureStatus(id, "INVALID_APP_KEY", "Invalid application key", true))
+		return false
+	}
+
+	if session != SessionToken {
+		conn.write(failureStatus(id, "INVALID_SESSION_INFORMATION", "Invalid session token", true))
+		return false
+	}
+
+	server.mu.Lock()
+	available := server.MaxConnections - len(server.conns)
+	conn.authenticated = available >= 0
+	server.mu.Unlock()
+
+	if available < 0 {
+		conn.write(failureStatus(id, "MAX_CONNECTION_LIMIT_EXCEEDED", "No connections available", true))
+		return false
+	}
+
+	status := successStatus(id)
+	status["connectionsAvailable"] = available
+	conn.write(status)
+
+	return true
+}
+
+func (server *StreamServer) subscribe(conn *streamConn, op string, id int32) {
+
+	server.mu.Lock()
+	failure, failed := server.failures[op]
+	script, scripted := server.scripts[op]
+	server.mu.Unlock()
+
+	if failed {
+		conn.write(failureStatus(id, failure.errorCode, failure.errorMessage, false))
+		return
+	}
+
+	conn.write(successStatus(id))
+
+	if !scripted {
+		conn.write(emptyImage(op, id))
+		return
+	}
+
+	for _, raw := range script {
+		message := make(map[string]interface{})
+		if err := json.Unmarshal(raw, &message); err != nil {
+			continue
+		}
+		message["id"] = id
+		conn.write(message)
+	}
+}
+
+func successStatus(id int32) map[string]interface{} {
+	return map[string]interface{}{"op": "status", "id": id, "statusCode": "SUCCESS", "connectionClosed": false}
+}
+
+func failureStatus(id int32, errorCode string, errorMessage string, connectionClosed bool) map[string]interface{} {
+	return map[string]interface{}{
+		"op":               "status",
+		"id":               id,
+		"statusCode":       "FAILURE",
+		"errorCode":        errorCode,
+		"errorMessage":     errorMessage,
+		"connectionClosed": connectionClosed,
+	}
+}
+
+func emptyImage(op string, id int32) map[string]interface{} {
+
+	image := map[string]interface{}{
+		"id":          id,
+		"ct":          "SUB_IMAGE",
+		"clk":         "AAAAAAAA",
+		"initialClk":  "AAAAAAAA",
+		"heartbeatMs": 5000,
+		"conflateMs":  0,
+		"pt":          time.Now().UnixMilli(),
+	}
+
+	switch op {
+	case "marketSubscription":
+		image["op"] = "mcm"
+		image["mc"] = []interface{}{}
+	case "orderSubscription":
+		image["op"] = "ocm"
+		image["oc"] = []interface{}{}
+	case "raceSubscription":
+		image["op"] = "rcm"
+		image["rc"] = []interface{}{}
+	}
+
+	return image
+}
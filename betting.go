@@ -1,5 +1,13 @@
 package gofair
 
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jonachehilton/gofair/decimal"
+	"github.com/jonachehilton/gofair/price"
+)
+
 // Betting API Operations
 const (
 	listEventTypes          = "listEventTypes/"
@@ -14,17 +22,61 @@ const (
 	listMarketProfitAndLoss = "listMarketProfitAndLoss/"
 	placeOrders             = "placeOrders/"
 	cancelOrders            = "cancelOrders/"
+	replaceOrders           = "replaceOrders/"
 	listCurrentOrders       = "listCurrentOrders/"
+	listClearedOrders       = "listClearedOrders/"
 )
 
 // Betting object
 type Betting struct {
 	Client *Client
+	// Validation, when set, checks the instructions passed to PlaceOrders before they are sent
+	Validation *ValidationOptions
+}
+
+// filterParams builds the request of the operations which take only a MarketFilter
+func filterParams(filter MarketFilter) interface{} {
+	return struct {
+		Filter MarketFilter `json:"filter,omitempty"`
+	}{
+		Filter: filter,
+	}
+}
+
+// localised lists the betting operations which return names in the Config's Locale
+var localised = map[string]bool{
+	listEventTypes:      true,
+	listCompetitions:    true,
+	listEvents:          true,
+	listMarketTypes:     true,
+	listCountries:       true,
+	listVenues:          true,
+	listMarketCatalogue: true,
+	listMarketBook:      true,
+}
+
+// localise adds the Config's Locale to the params of operations which accept one
+func (c *Client) localise(endpoint string, params interface{}) interface{} {
+	if c.Config == nil || c.Config.Locale == "" || !localised[endpoint] {
+		return params
+	}
+
+	bytes, err := json.Marshal(params)
+	if err != nil {
+		return params
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(bytes, &fields); err != nil {
+		return params
+	}
+	fields["locale"], _ = json.Marshal(c.Config.Locale)
+	return fields
 }
 
 func (b *Betting) bettingRequest(endpoint string, params interface{}, response interface{}) error {
 
-	url := createURL(Endpoints.Betting, endpoint)
+	url := createURL(b.Client.Endpoints.Betting, endpoint)
+	params = b.Client.localise(endpoint, params)
 
 	// make request
 	err := b.Client.request(url, params, &response)
@@ -38,11 +90,7 @@ func (b *Betting) bettingRequest(endpoint string, params interface{}, response i
 // ListEventTypes returns a list of Event Types (i.e. Sports) associated with the markets selected by the MarketFilter.
 func (b *Betting) ListEventTypes(filter MarketFilter) ([]EventTypeResult, error) {
 	// build request
-	params := struct {
-		Filter MarketFilter `json:"filter,omitempty"`
-	}{
-		Filter: filter,
-	}
+	params := filterParams(filter)
 
 	var response []EventTypeResult
 
@@ -54,11 +102,7 @@ func (b *Betting) ListEventTypes(filter MarketFilter) ([]EventTypeResult, error)
 // ListCompetitions returns a list of Competitions (i.e., World Cup 2013) associated with the markets selected by the MarketFilter. Currently only Football markets have an associated competition.
 func (b *Betting) ListCompetitions(filter MarketFilter) ([]CompetitionResult, error) {
 	// build request
-	params := struct {
-		Filter MarketFilter `json:"filter,omitempty"`
-	}{
-		Filter: filter,
-	}
+	params := filterParams(filter)
 
 	var response []CompetitionResult
 
@@ -68,11 +112,11 @@ func (b *Betting) ListCompetitions(filter MarketFilter) ([]CompetitionResult, er
 }
 
 // ListTimeRanges returns a list of time ranges in the granularity specified in the request (i.e. 3PM to 4PM, Aug 14th to Aug 15th) associated with the markets selected by the MarketFilter.
-func (b *Betting) ListTimeRanges(filter MarketFilter, granularity string) ([]TimeRangeResult, error) {
+func (b *Betting) ListTimeRanges(filter MarketFilter, granularity TimeGranularity) ([]TimeRangeResult, error) {
 	// build request
 	params := struct {
-		Filter      MarketFilter `json:"filter,omitempty"`
-		Granularity string       `json:"granularity,omitempty"`
+		Filter      MarketFilter    `json:"filter,omitempty"`
+		Granularity TimeGranularity `json:"granularity,omitempty"`
 	}{
 		Filter:      filter,
 		Granularity: granularity,
@@ -88,11 +132,7 @@ func (b *Betting) ListTimeRanges(filter MarketFilter, granularity string) ([]Tim
 // ListEvents returns a list of Events (i.e, Reading vs. Man United) associated with the markets selected by the MarketFilter.
 func (b *Betting) ListEvents(filter MarketFilter) ([]EventResult, error) {
 	// build request
-	params := struct {
-		Filter MarketFilter `json:"filter,omitempty"`
-	}{
-		Filter: filter,
-	}
+	params := filterParams(filter)
 
 	var response []EventResult
 
@@ -104,11 +144,7 @@ func (b *Betting) ListEvents(filter MarketFilter) ([]EventResult, error) {
 // ListMarketTypes returns a list of market types (i.e. MATCH_ODDS, NEXT_GOAL) associated with the markets selected by the MarketFilter. The market types are always the same, regardless of locale.
 func (b *Betting) ListMarketTypes(filter MarketFilter) ([]MarketTypeResult, error) {
 	// build request
-	params := struct {
-		Filter MarketFilter `json:"filter,omitempty"`
-	}{
-		Filter: filter,
-	}
+	params := filterParams(filter)
 
 	var response []MarketTypeResult
 
@@ -120,11 +156,7 @@ func (b *Betting) ListMarketTypes(filter MarketFilter) ([]MarketTypeResult, erro
 // ListCountries returns a list of Countries associated with the markets selected by the MarketFilter.
 func (b *Betting) ListCountries(filter MarketFilter) ([]CountryResult, error) {
 	// build request
-	params := struct {
-		Filter MarketFilter `json:"filter,omitempty"`
-	}{
-		Filter: filter,
-	}
+	params := filterParams(filter)
 
 	var response []CountryResult
 
@@ -136,11 +168,7 @@ func (b *Betting) ListCountries(filter MarketFilter) ([]CountryResult, error) {
 // ListVenues returns a list of Venues (i.e. Cheltenham, Ascot) associated with the markets selected by the MarketFilter. Currently, only Horse Racing markets are associated with a Venue.
 func (b *Betting) ListVenues(filter MarketFilter) ([]VenueResult, error) {
 	// build request
-	params := struct {
-		Filter MarketFilter `json:"filter,omitempty"`
-	}{
-		Filter: filter,
-	}
+	params := filterParams(filter)
 
 	var response []VenueResult
 
@@ -150,30 +178,44 @@ func (b *Betting) ListVenues(filter MarketFilter) ([]VenueResult, error) {
 }
 
 // ListMarketCatalogue returns a list of information about published (ACTIVE/SUSPENDED) markets that does not change (or changes very rarely). You use listMarketCatalogue to retrieve the name of the market, the names of selections and other information about markets.  Market Data request Limits apply to requests made to listMarketCatalogue.
-func (b *Betting) ListMarketCatalogue(filter MarketFilter, marketProjection []string, sort string, maxResults int) ([]MarketCatalogue, error) {
+func (b *Betting) ListMarketCatalogue(filter MarketFilter, marketProjection []MarketProjection, sort MarketSort, maxResults int) ([]MarketCatalogue, error) {
 	// build request
-	params := struct {
-		Filter           MarketFilter `json:"filter,omitempty"`
-		MarketProjection []string     `json:"marketProjection,omitempty"`
-		Sort             string       `json:"sort,omitempty"`
-		MaxResults       int          `json:"maxResults,omitempty"`
+	params := listMarketCatalogueParams(filter, marketProjection, sort, maxResults)
+
+	var response []MarketCatalogue
+
+	err := b.bettingRequest(listMarketCatalogue, params, &response)
+
+	return response, err
+}
+
+func listMarketCatalogueParams(filter MarketFilter, marketProjection []MarketProjection, sort MarketSort, maxResults int) interface{} {
+	return struct {
+		Filter           MarketFilter       `json:"filter,omitempty"`
+		MarketProjection []MarketProjection `json:"marketProjection,omitempty"`
+		Sort             MarketSort         `json:"sort,omitempty"`
+		MaxResults       int                `json:"maxResults,omitempty"`
 	}{
 		Filter:           filter,
 		MarketProjection: marketProjection,
 		Sort:             sort,
 		MaxResults:       maxResults,
 	}
+}
 
-	var response []MarketCatalogue
+// ListMarketBook returns a list of dynamic data about markets. Dynamic data includes prices, the status of the market, the status of selections, the traded volume, and the status of any orders you have placed in the market.
+func (b *Betting) ListMarketBook(marketIDs []string, displayOrders bool) ([]MarketBook, error) {
+	// build request
+	params := listMarketBookParams(marketIDs, displayOrders)
 
-	err := b.bettingRequest(listMarketCatalogue, params, &response)
+	var response []MarketBook
+
+	err := b.bettingRequest(listMarketBook, params, &response)
 
 	return response, err
 }
 
-// ListMarketBook returns a list of dynamic data about markets. Dynamic data includes prices, the status of the market, the status of selections, the traded volume, and the status of any orders you have placed in the market.
-func (b *Betting) ListMarketBook(marketIDs []string, displayOrders bool) ([]MarketBook, error) {
-	// build request
+func listMarketBookParams(marketIDs []string, displayOrders bool) interface{} {
 	priceProjection := new(PriceProjection)
 
 	params := struct {
@@ -197,21 +239,13 @@ func (b *Betting) ListMarketBook(marketIDs []string, displayOrders bool) ([]Mark
 		priceProjection.ExBestOffersOverrides.BestPricesDepth = 3
 	}
 
-	var response []MarketBook
-
-	err := b.bettingRequest(listMarketBook, params, &response)
-
-	return response, err
+	return params
 }
 
 // ListMarketProfitAndLoss retrieves profit and loss for a given list of OPEN markets. The values are calculated using matched bets and optionally settled bets. Only odds (MarketBettingType = ODDS) markets  are implemented, markets of other types are silently ignored.
 func (b *Betting) ListMarketProfitAndLoss(marketIDs []string) ([]MarketProfitAndLoss, error) {
 	// build request
-	params := struct {
-		MarketIDs []string `json:"marketIds,omitempty"`
-	}{
-		MarketIDs: marketIDs,
-	}
+	params := marketIDsParams(marketIDs)
 
 	var response []MarketProfitAndLoss
 
@@ -220,26 +254,120 @@ func (b *Betting) ListMarketProfitAndLoss(marketIDs []string) ([]MarketProfitAnd
 	return response, err
 }
 
+func marketIDsParams(marketIDs []string) interface{} {
+	return struct {
+		MarketIDs []string `json:"marketIds,omitempty"`
+	}{
+		MarketIDs: marketIDs,
+	}
+}
+
+// PlaceOrdersOptions holds the optional parameters of placeOrders.
+type PlaceOrdersOptions struct {
+	// CustomerRef de-duplicates requests, the same ref is rejected if resubmitted within 60 seconds
+	CustomerRef string `json:"customerRef,omitempty"`
+	// MarketVersion rejects the orders if the market has been updated since this version
+	MarketVersion *MarketVersion `json:"marketVersion,omitempty"`
+	// CustomerStrategyRef is returned in the order stream and on cleared orders
+	CustomerStrategyRef string `json:"customerStrategyRef,omitempty"`
+	// Async returns as soon as the orders are accepted, the bet ids then arrive on the order stream
+	Async bool `json:"async,omitempty"`
+}
+
+// MarketVersion is the version of a market at which orders should be placed.
+type MarketVersion struct {
+	Version int64 `json:"version"`
+}
+
 // PlaceOrders allows new orders to be submitted into a market. Please note that additional bet sizing rules apply to bets placed into the Italian Exchange.
 func (b *Betting) PlaceOrders(marketID string, placeInstructions []PlaceInstruction) (PlaceExecutionReport, error) {
+	return b.PlaceOrdersWithOptions(marketID, placeInstructions, PlaceOrdersOptions{})
+}
+
+// PlaceOrdersWithOptions is PlaceOrders with the optional customerRef, marketVersion, customerStrategyRef and async parameters.
+// Transient failures are only retried when a customerRef is set. If the outcome is unknown, the orders are looked up by
+// their CustomerOrderRef with ListCurrentOrders, returning an OrderOutcomeUnknownError if that is not possible.
+func (b *Betting) PlaceOrdersWithOptions(marketID string, placeInstructions []PlaceInstruction, options PlaceOrdersOptions) (PlaceExecutionReport, error) {
+	if b.Validation != nil {
+		if err := ValidateInstructions(placeInstructions, *b.Validation); err != nil {
+			return PlaceExecutionReport{}, err
+		}
+	}
+
+	if err := b.Client.spend(len(placeInstructions), false); err != nil {
+		return PlaceExecutionReport{}, err
+	}
+
 	// build request
 	params := struct {
 		MarketID     string             `json:"marketId,omitempty"`
 		Instructions []PlaceInstruction `json:"instructions,omitempty"`
+		PlaceOrdersOptions
 	}{
-		MarketID:     marketID,
-		Instructions: placeInstructions,
+		MarketID:           marketID,
+		Instructions:       placeInstructions,
+		PlaceOrdersOptions: options,
 	}
 
 	var response PlaceExecutionReport
 
-	err := b.bettingRequest(placeOrders, params, &response)
+	// A customerRef makes a repeated request safe, as the Exchange rejects it as a duplicate if the first succeeded
+	err := b.Client.requestWithRetry(createURL(b.Client.Endpoints.Betting, placeOrders), params, &response, options.CustomerRef != "")
+	if placementUnknown(response, err) {
+		return b.reconcile(marketID, placeInstructions, options, err)
+	}
 
 	return response, err
 }
 
+// PlaceOrdersOnLadder checks that the price of every LIMIT and LIMIT_ON_CLOSE instruction is valid on the market's
+// price ladder before submitting the orders with PlaceOrders. No request is made if any price is invalid.
+func (b *Betting) PlaceOrdersOnLadder(marketID string, ladder *price.Ladder, placeInstructions []PlaceInstruction) (PlaceExecutionReport, error) {
+	if err := ValidatePrices(ladder, placeInstructions); err != nil {
+		return PlaceExecutionReport{}, err
+	}
+	return b.PlaceOrders(marketID, placeInstructions)
+}
+
+// ValidatePrices returns an error describing the first instruction whose price is not on the ladder.
+func ValidatePrices(ladder *price.Ladder, placeInstructions []PlaceInstruction) error {
+	for i, instruction := range placeInstructions {
+		var p decimal.Price
+		switch instruction.OrderType {
+		case OrderTypeEnum.Limit:
+			p = instruction.LimitOrder.Price
+		case OrderTypeEnum.LimitOnClose:
+			if instruction.LimitOnCloseOrder == nil {
+				continue
+			}
+			p = instruction.LimitOnCloseOrder.Price
+		default:
+			continue
+		}
+
+		if _, err := ladder.Index(p.Float64()); err != nil {
+			return fmt.Errorf("instruction %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Ladder returns the price ladder of the market.
+func (description MarketCatalogueDescription) Ladder() (*price.Ladder, error) {
+	if description.PriceLadderDescription.Type == price.LadderTypeEnum.LineRange && description.LineRangeInfo != nil {
+		info := description.LineRangeInfo
+		return price.NewLineRangeLadder(info.MinUnitValue, info.MaxUnitValue, info.Interval)
+	}
+	return price.ForType(description.PriceLadderDescription.Type)
+}
+
 // CancelOrders allows the user to cancel all bets OR cancel all bets on a market OR fully or partially cancel particular orders on a market. Only LIMIT orders can be cancelled or partially cancelled once placed.
 func (b *Betting) CancelOrders(marketID string, cancelInstructions []CancelInstruction) (CancelExecutionReport, error) {
+	// Cancelling every order on a market or account is counted as a single transaction
+	if err := b.Client.spend(max(len(cancelInstructions), 1), true); err != nil {
+		return CancelExecutionReport{}, err
+	}
+
 	// build request
 	params := struct {
 		MarketID     string              `json:"marketId,omitempty"`
@@ -256,9 +384,42 @@ func (b *Betting) CancelOrders(marketID string, cancelInstructions []CancelInstr
 	return response, err
 }
 
-func (b *Betting) ListCurrentOrders(betIDs []string, marketIDs []string, orderProjection OrderProjection) (CurrentOrderSummaryReport, error) {
+// ReplaceOrders cancels the unmatched part of each order and places a new order at the new price. The new order keeps
+// the size, side and persistence of the one it replaces.
+func (b *Betting) ReplaceOrders(marketID string, replaceInstructions []ReplaceInstruction) (ReplaceExecutionReport, error) {
+	if err := b.Client.spend(len(replaceInstructions), false); err != nil {
+		return ReplaceExecutionReport{}, err
+	}
+
 	// build request
 	params := struct {
+		MarketID     string               `json:"marketId,omitempty"`
+		Instructions []ReplaceInstruction `json:"instructions,omitempty"`
+	}{
+		MarketID:     marketID,
+		Instructions: replaceInstructions,
+	}
+
+	var response ReplaceExecutionReport
+
+	err := b.bettingRequest(replaceOrders, params, &response)
+
+	return response, err
+}
+
+func (b *Betting) ListCurrentOrders(betIDs []string, marketIDs []string, orderProjection OrderProjection) (CurrentOrderSummaryReport, error) {
+	// build request
+	params := listCurrentOrdersParams(betIDs, marketIDs, orderProjection)
+
+	var response CurrentOrderSummaryReport
+
+	err := b.bettingRequest(listCurrentOrders, params, &response)
+
+	return response, err
+}
+
+func listCurrentOrdersParams(betIDs []string, marketIDs []string, orderProjection OrderProjection) interface{} {
+	return struct {
 		BetIDs          []string        `json:"betIds,omitempty"`
 		MarketIDs       []string        `json:"marketIds,omitempty"`
 		OrderProjection OrderProjection `json:"orderProjection,omitempty"`
@@ -267,10 +428,29 @@ func (b *Betting) ListCurrentOrders(betIDs []string, marketIDs []string, orderPr
 		MarketIDs:       marketIDs,
 		OrderProjection: orderProjection,
 	}
+}
 
-	var response CurrentOrderSummaryReport
+// ListClearedOrders returns a list of settled bets based on the bet status, ordered by settled date. Up to recordCount
+// bets, or 1000 if it is zero, are returned from the fromRecord'th; settledDateRange may be nil.
+func (b *Betting) ListClearedOrders(betStatus BetStatus, marketIDs []string, settledDateRange *TimeRangeFilter, fromRecord int, recordCount int) (ClearedOrderSummaryReport, error) {
+	// build request
+	params := struct {
+		BetStatus        BetStatus        `json:"betStatus"`
+		MarketIDs        []string         `json:"marketIds,omitempty"`
+		SettledDateRange *TimeRangeFilter `json:"settledDateRange,omitempty"`
+		FromRecord       int              `json:"fromRecord,omitempty"`
+		RecordCount      int              `json:"recordCount,omitempty"`
+	}{
+		BetStatus:        betStatus,
+		MarketIDs:        marketIDs,
+		SettledDateRange: settledDateRange,
+		FromRecord:       fromRecord,
+		RecordCount:      recordCount,
+	}
 
-	err := b.bettingRequest(listCurrentOrders, params, &response)
+	var response ClearedOrderSummaryReport
+
+	err := b.bettingRequest(listClearedOrders, params, &response)
 
 	return response, err
 }
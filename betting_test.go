@@ -0,0 +1,84 @@
+package gofair
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/price"
+)
+
+func TestPlaceOrdersOnLadder(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("placeOrders", PlaceExecutionReport{MarketID: "1.23", Status: "SUCCESS"})
+	instructions := []PlaceInstruction{
+		{OrderType: OrderTypeEnum.Limit, SelectionID: 1, Side: SideEnum.Back, LimitOrder: LimitOrder{Price: 1.01, Size: 2}},
+		{OrderType: OrderTypeEnum.LimitOnClose, SelectionID: 1, Side: SideEnum.Lay, LimitOnCloseOrder: &LimitOnCloseOrder{Price: 990, Liability: 10}},
+		{OrderType: OrderTypeEnum.MarketOnClose, SelectionID: 1, Side: SideEnum.Back, MarketOnCloseOrder: &MarketOnCloseOrder{Liability: 10}},
+	}
+
+	// Act
+	report, err := client.Betting.PlaceOrdersOnLadder("1.23", price.Classic, instructions)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "1.23", report.MarketID)
+}
+
+func TestPlaceOrdersOnLadderInvalidPrice(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	requests := len(server.Requests())
+	instructions := []PlaceInstruction{
+		{OrderType: OrderTypeEnum.Limit, SelectionID: 1, Side: SideEnum.Back, LimitOrder: LimitOrder{Price: 2.5, Size: 2}},
+		{OrderType: OrderTypeEnum.Limit, SelectionID: 1, Side: SideEnum.Back, LimitOrder: LimitOrder{Price: 2.51, Size: 2}},
+	}
+
+	// Act
+	_, err := client.Betting.PlaceOrdersOnLadder("1.23", price.Classic, instructions)
+
+	// Assert
+	assert.EqualError(t, err, "instruction 1: Price 2.51 is not a valid CLASSIC price")
+	var invalid *price.InvalidPriceError
+	assert.ErrorAs(t, err, &invalid)
+	assert.Len(t, server.Requests(), requests)
+}
+
+func TestMarketCatalogueDescriptionLadder(t *testing.T) {
+	// Arrange
+	line := MarketCatalogueDescription{
+		PriceLadderDescription: PriceLadderDescription{Type: price.LadderTypeEnum.LineRange},
+		LineRangeInfo:          &MarketLineRangeInfo{MinUnitValue: 0.5, MaxUnitValue: 10.5, Interval: 1},
+	}
+
+	// Act
+	classic, classicErr := MarketCatalogueDescription{}.Ladder()
+	finest, finestErr := MarketCatalogueDescription{PriceLadderDescription: PriceLadderDescription{Type: price.LadderTypeEnum.Finest}}.Ladder()
+	lineLadder, lineErr := line.Ladder()
+
+	// Assert
+	assert.NoError(t, classicErr)
+	assert.Equal(t, price.Classic, classic)
+	assert.NoError(t, finestErr)
+	assert.Equal(t, price.Finest, finest)
+	assert.NoError(t, lineErr)
+	assert.Equal(t, 11, lineLadder.Len())
+}
+
+func TestListClearedOrders(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("listClearedOrders", ClearedOrderSummaryReport{ClearedOrders: []ClearedOrderSummary{{BetID: "1", BetOutcome: "WON", Profit: 2.5}}})
+
+	// Act
+	report, err := client.Betting.ListClearedOrders(BetStatusEnum.Settled, []string{"1.23"}, nil, 0, 0)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "WON", report.ClearedOrders[0].BetOutcome)
+	assert.JSONEq(t, `{"betStatus": "SETTLED", "marketIds": ["1.23"]}`, string(server.Requests()[1].Body))
+}
@@ -3,13 +3,15 @@ package gofair
 import (
 	"crypto/tls"
 	"encoding/json"
-	"errors"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/jonachehilton/gofair/config"
+	"github.com/jonachehilton/gofair/logging"
+	"github.com/jonachehilton/gofair/metrics"
 	"github.com/jonachehilton/gofair/streaming"
 )
 
@@ -26,26 +28,135 @@ type Client struct {
 	Betting      *Betting
 	Account      *Account
 	Streaming    *streaming.Stream
+	Orders       *OrderManager
+
+	// Metrics, if set, records every REST request, see SetMetrics
+	Metrics metrics.Recorder
+
+	// Endpoints are the API endpoints of the Client's jurisdiction, see Config.Endpoints
+	Endpoints EndpointSet
+
+	// Timeout limits each REST request, see Config.Timeout
+	Timeout time.Duration
+
+	// Transport selects REST, the default, or JSON-RPC for the betting and account APIs
+	Transport Transport
+
+	// Retry is applied to requests which fail with a transient error, see RetryPolicy
+	Retry RetryPolicy
+
+	// Limiter throttles the REST requests and counts transactions, it is created with DefaultRateLimits, which only
+	// limit logins, and may be set to nil to disable limiting
+	Limiter *RateLimiter
+
+	// Logger receives the login, keep alive and REST request logs with credentials redacted, see SetLogger
+	Logger *slog.Logger
 }
 
 func createURL(endpoint string, method string) string {
 	return endpoint + method
 }
 
-// Request issues a HTTP POST to the Betfair Exchange API Endpoint specified.
+// SetMetrics records the REST requests and the Streaming connection with recorder. Call it before Streaming.Start.
+func (c *Client) SetMetrics(recorder metrics.Recorder) {
+	c.Metrics = recorder
+	c.Streaming.Metrics = recorder
+}
+
+// SetLogger logs the session, REST requests and the Streaming connection with logger. Credentials are always
+// redacted and payloads are only logged at debug level. Call it before Streaming.Start.
+func (c *Client) SetLogger(logger *slog.Logger) {
+	c.Logger = logging.OrDiscard(logger)
+	c.Streaming.Logger = c.Logger
+}
+
+// log returns the Logger, redacted, or a logger which drops every record if none is set
+func (c *Client) log() *slog.Logger {
+	return logging.OrDiscard(c.Logger)
+}
+
+// wait blocks until the Limiter allows a request to url
+func (c *Client) wait(url string) {
+	if c.Limiter != nil {
+		c.Limiter.Wait(classify(c.Endpoints, url))
+	}
+}
+
+// spend records count transactions with the Limiter, returning a TransactionBudgetError if orders would exceed the
+// budget
+func (c *Client) spend(count int, cancellation bool) error {
+	if c.Limiter != nil {
+		return c.Limiter.Spend(count, cancellation)
+	}
+	return nil
+}
+
+// operation returns the name of the API operation from its URL, such as placeOrders or certlogin
+func operation(url string) string {
+	url = strings.TrimSuffix(url, "/")
+	return url[strings.LastIndex(url, "/")+1:]
+}
+
+// request issues a HTTP POST to the Betfair Exchange API Endpoint specified, retrying read-only operations which fail
+// with a transient error.
 func (c *Client) request(url string, params interface{}, v interface{}) error {
+	return c.requestWithRetry(url, params, v, classify(c.Endpoints, url) != OperationClassEnum.Transaction)
+}
+
+// send makes a single attempt at a request, over JSON-RPC if that is the Client's Transport.
+func (c *Client) send(url string, params interface{}, v interface{}) (err error) {
+
+	c.wait(url)
+
+	start := time.Now()
+	defer func() {
+		c.observe(operation(url), start, err)
+	}()
+
+	if c.Transport == TransportEnum.JSONRPC {
+		if endpoint, method, ok := rpcMethod(c.Endpoints, url); ok {
+			return c.call(endpoint, method, params, v)
+		}
+	}
 
 	bytes, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
 
+	data, err := c.post(url, operation(url), bytes)
 	if err != nil {
 		return err
 	}
 
+	return json.Unmarshal(data, v)
+}
+
+// observe records a finished request with the metrics Recorder and the Logger.
+func (c *Client) observe(operation string, start time.Time, err error) {
+	duration := time.Since(start)
+	if c.Metrics != nil {
+		c.Metrics.RESTRequest(operation, duration, err)
+	}
+	if err != nil {
+		c.log().Warn("rest request failed", "operation", operation, "duration", duration, "error", err)
+	} else {
+		c.log().Debug("rest request", "operation", operation, "duration", duration)
+	}
+}
+
+// post sends a JSON body to url with the session headers and returns the body of the response, or an APIError if
+// its status is not 200 OK.
+func (c *Client) post(url string, operation string, bytes []byte) ([]byte, error) {
+
+	logger := c.log()
+	logging.Payload(logger, "rest request body", bytes, "operation", operation)
+
 	body := strings.NewReader(string(bytes))
 
 	req, err := http.NewRequest("POST", url, body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// set headers
@@ -55,36 +166,46 @@ func (c *Client) request(url string, params interface{}, v interface{}) error {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Connection", "keep-alive")
 
-	client := &http.Client{}
+	client := &http.Client{Timeout: c.Timeout}
 
 	resp, err := client.Do(req)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer resp.Body.Close()
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	logging.Payload(logger, "rest response body", data, "operation", operation, "status", resp.StatusCode)
+
 	if resp.StatusCode != http.StatusOK {
-		return errors.New(resp.Status)
-	}
-	if err := json.Unmarshal(data, v); err != nil {
-		return err
+		return nil, newAPIError(resp, data)
 	}
 
-	return nil
+	return data, nil
 }
 
-// NewClient creates a new Betfair client.
+// NewClient creates a new Betfair client, applying the jurisdiction, timeout and stream settings of cfg after checking
+// it with Validate.
 func NewClient(cfg *config.Config) (*Client, error) {
 
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	client := new(Client)
 	client.Session = new(Session)
 
+	client.Endpoints = Endpoints
+	if endpoints, ok := JurisdictionEndpoints[cfg.Endpoints]; ok {
+		client.Endpoints = endpoints
+	}
+	client.Timeout = cfg.TimeoutOrDefault()
+
 	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
 	if err != nil {
 		return nil, err
@@ -94,6 +215,9 @@ func NewClient(cfg *config.Config) (*Client, error) {
 	client.Config = cfg
 	client.Betting = &Betting{Client: client}
 	client.Account = &Account{Client: client}
+	client.Limiter = NewRateLimiter(DefaultRateLimits)
+	client.Retry = DefaultRetryPolicy
+	client.Transport = TransportEnum.REST
 
 	stream, err := streaming.NewStream(client.Certificates, cfg.AppKey)
 	if err != nil {
@@ -101,6 +225,16 @@ func NewClient(cfg *config.Config) (*Client, error) {
 	}
 
 	client.Streaming = stream
+	if cfg.Stream.SubscriptionTimeout > 0 {
+		stream.SubscriptionTimeout = time.Duration(cfg.Stream.SubscriptionTimeout)
+	}
+	if cfg.Stream.StaleThreshold > 0 {
+		stream.Latency.Threshold = time.Duration(cfg.Stream.StaleThreshold)
+	}
+
+	// Link placed orders to their updates on the order stream
+	client.Orders = NewOrderManager(client.Betting)
+	stream.AddHandler(streaming.StreamHandler{Orders: client.Orders})
 
 	return client, nil
 }
@@ -114,3 +248,19 @@ func (c *Client) SessionExpired() bool {
 
 	return duration.Minutes() > 200
 }
+
+// StreamEndpoint returns the Stream API endpoint selected by Config.Stream.Endpoint
+func (c *Client) StreamEndpoint() string {
+	switch c.Config.Stream.Endpoint {
+	case "", config.StreamEndpointEnum.Live:
+		return streaming.LiveEndpoint
+	case config.StreamEndpointEnum.Integration:
+		return streaming.IntegrationEndpoint
+	}
+	return c.Config.Stream.Endpoint
+}
+
+// StartStreaming starts Streaming on the StreamEndpoint with the current session. Call Login first.
+func (c *Client) StartStreaming() error {
+	return c.Streaming.Start(c.StreamEndpoint(), c.Session.SessionToken)
+}
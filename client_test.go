@@ -0,0 +1,243 @@
+package gofair
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/betfairtest"
+	"github.com/jonachehilton/gofair/config"
+	"github.com/jonachehilton/gofair/decimal"
+	"github.com/jonachehilton/gofair/logging"
+)
+
+// newTestClient returns a Client whose Endpoints point at a local RESTServer. The original Endpoints are restored when
+// the test finishes.
+func newTestClient(t *testing.T) (*Client, *betfairtest.RESTServer) {
+
+	server := betfairtest.NewRESTServer()
+	t.Cleanup(server.Close)
+
+	original := Endpoints
+	Endpoints.Login = server.LoginURL()
+	Endpoints.Identity = server.IdentityURL()
+	Endpoints.Betting = server.BettingURL()
+	Endpoints.Account = server.AccountURL()
+	Endpoints.BettingRPC = server.BettingRPCURL()
+	Endpoints.AccountRPC = server.AccountRPCURL()
+	t.Cleanup(func() { Endpoints = original })
+
+	cert, err := betfairtest.NewCertificate()
+	assert.NoError(t, err)
+	certFile, keyFile, err := cert.WriteFiles(t.TempDir())
+	assert.NoError(t, err)
+
+	client, err := NewClient(&config.Config{
+		Username: "username",
+		Password: "password",
+		AppKey:   betfairtest.AppKey,
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	})
+	assert.NoError(t, err)
+	client.Retry.InitialBackoff = time.Millisecond
+	client.Retry.Jitter = 0
+
+	return client, server
+}
+
+func TestLogin(t *testing.T) {
+	// Arrange
+	client, _ := newTestClient(t)
+
+	// Act
+	result, err := client.Login()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "SUCCESS", result.LoginStatus)
+	assert.Equal(t, betfairtest.SessionToken, client.Session.SessionToken)
+	assert.False(t, client.SessionExpired())
+}
+
+func TestLoginInvalidCredentials(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	server.Username = "someone-else"
+
+	// Act
+	result, err := client.Login()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "INVALID_USERNAME_OR_PASSWORD", result.LoginStatus)
+	assert.True(t, client.SessionExpired())
+}
+
+func TestKeepAliveAndLogout(t *testing.T) {
+	// Arrange
+	client, _ := newTestClient(t)
+	client.Login()
+
+	// Act
+	keepAlive, keepAliveErr := client.KeepAlive()
+	logout, logoutErr := client.Logout()
+
+	// Assert
+	assert.NoError(t, keepAliveErr)
+	assert.Equal(t, "SUCCESS", keepAlive.Status)
+	assert.NoError(t, logoutErr)
+	assert.Equal(t, "SUCCESS", logout.Status)
+	assert.True(t, client.SessionExpired())
+}
+
+func TestListEventTypes(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("listEventTypes", []EventTypeResult{{MarketCount: 12, EventType: EventType{ID: "7", Name: "Horse Racing"}}})
+
+	// Act
+	eventTypes, err := client.Betting.ListEventTypes(MarketFilter{TextQuery: "racing"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []EventTypeResult{{MarketCount: 12, EventType: EventType{ID: "7", Name: "Horse Racing"}}}, eventTypes)
+	requests := server.Requests()
+	last := requests[len(requests)-1]
+	assert.Equal(t, "/betting/listEventTypes/", last.Path)
+	assert.Equal(t, betfairtest.AppKey, last.AppKey)
+	assert.Equal(t, betfairtest.SessionToken, last.SessionToken)
+	assert.JSONEq(t, `{"filter":{"textQuery":"racing"}}`, string(last.Body))
+}
+
+func TestGetAccountFunds(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleAccount("getAccountFunds", AccountFundsResponse{AvailableToBetBalance: 100.5, Exposure: -20})
+
+	// Act
+	funds, err := client.Account.GetAccountFunds()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, decimal.Money(100.5), funds.AvailableToBetBalance)
+	assert.Equal(t, decimal.Money(-20), funds.Exposure)
+}
+
+func TestRequestErrorStatus(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleStatus("/betting/listMarketBook", http.StatusServiceUnavailable)
+
+	// Act
+	_, err := client.Betting.ListMarketBook([]string{"1.23"}, false)
+
+	// Assert
+	assert.EqualError(t, err, "503 Service Unavailable")
+}
+
+func TestLoggingRedactsCredentials(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	var buf bytes.Buffer
+	client.SetLogger(logging.New(&buf, slog.LevelDebug))
+	server.HandleBetting("listEventTypes", []EventTypeResult{})
+
+	// Act
+	_, err := client.Login()
+	assert.NoError(t, err)
+	_, err = client.Betting.ListEventTypes(MarketFilter{TextQuery: "racing"})
+	assert.NoError(t, err)
+
+	// Assert
+	assert.NotContains(t, buf.String(), "password=password")
+	assert.NotContains(t, buf.String(), betfairtest.SessionToken)
+	assert.Contains(t, buf.String(), `"msg":"logged in"`)
+	assert.Contains(t, buf.String(), `"msg":"rest request body","operation":"listEventTypes"`)
+}
+
+func TestLoggingRequestFailure(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	var buf bytes.Buffer
+	client.SetLogger(logging.New(&buf, slog.LevelWarn))
+	client.Login()
+	server.HandleStatus("/betting/listMarketBook", http.StatusServiceUnavailable)
+
+	// Act
+	client.Betting.ListMarketBook([]string{"1.23"}, false)
+
+	// Assert
+	assert.Contains(t, buf.String(), `"msg":"rest request failed","operation":"listMarketBook"`)
+	assert.NotContains(t, buf.String(), logging.PayloadKey)
+	assert.NotContains(t, buf.String(), "logged in")
+}
+
+func TestNewClientAppliesConfig(t *testing.T) {
+	// Arrange
+	cert, err := betfairtest.NewCertificate()
+	assert.NoError(t, err)
+	certFile, keyFile, err := cert.WriteFiles(t.TempDir())
+	assert.NoError(t, err)
+
+	// Act
+	client, err := NewClient(&config.Config{
+		Username:  "username",
+		Password:  "password",
+		AppKey:    betfairtest.AppKey,
+		CertFile:  certFile,
+		KeyFile:   keyFile,
+		Endpoints: config.EndpointsEnum.Italy,
+		Timeout:   config.Duration(5 * time.Second),
+		Stream: config.StreamConfig{
+			Endpoint:            config.StreamEndpointEnum.Integration,
+			SubscriptionTimeout: config.Duration(time.Second),
+			StaleThreshold:      config.Duration(500 * time.Millisecond),
+		},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, JurisdictionEndpoints[config.EndpointsEnum.Italy], client.Endpoints)
+	assert.Equal(t, 5*time.Second, client.Timeout)
+	assert.Equal(t, "stream-api-integration.betfair.com:443", client.StreamEndpoint())
+	assert.Equal(t, time.Second, client.Streaming.SubscriptionTimeout)
+	assert.Equal(t, 500*time.Millisecond, client.Streaming.Latency.Threshold)
+}
+
+func TestNewClientValidatesConfig(t *testing.T) {
+	// Act
+	_, err := NewClient(&config.Config{Username: "username"})
+
+	// Assert
+	var fieldErr *config.FieldError
+	assert.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "password", fieldErr.Field)
+}
+
+func TestLocale(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	client.Config.Locale = "it"
+	server.HandleBetting("listEvents", []EventResult{})
+	server.HandleBetting("listMarketProfitAndLoss", []MarketProfitAndLoss{})
+
+	// Act
+	_, eventsErr := client.Betting.ListEvents(MarketFilter{EventTypeIds: []string{"1"}})
+	_, profitErr := client.Betting.ListMarketProfitAndLoss([]string{"1.23"})
+
+	// Assert
+	assert.NoError(t, eventsErr)
+	assert.NoError(t, profitErr)
+	requests := server.Requests()
+	assert.JSONEq(t, `{"filter": {"eventTypeIds": ["1"]}, "locale": "it"}`, string(requests[1].Body))
+	assert.JSONEq(t, `{"marketIds": ["1.23"]}`, string(requests[2].Body))
+}
@@ -0,0 +1,555 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/jonachehilton/gofair"
+	"github.com/jonachehilton/gofair/config"
+	"github.com/jonachehilton/gofair/decimal"
+)
+
+// parseNone parses the flags of a command which takes no arguments
+func (app *app) parseNone(name string, args []string) error {
+	flags := app.newFlagSet(name)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%s takes no arguments", name)
+	}
+	return nil
+}
+
+func runLogin(app *app, args []string) error {
+	if err := app.parseNone("login", args); err != nil {
+		return err
+	}
+	client, err := app.newClient()
+	if err != nil {
+		return err
+	}
+	if err := app.login(client); err != nil {
+		return err
+	}
+
+	result := map[string]string{"status": "SUCCESS", "username": client.Config.Username}
+	return app.print(result, func(t *table) {
+		t.headers = []string{"STATUS", "USERNAME"}
+		t.add(result["status"], result["username"])
+	})
+}
+
+func runKeepAlive(app *app, args []string) error {
+	if err := app.parseNone("keepalive", args); err != nil {
+		return err
+	}
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	result, err := client.KeepAlive()
+	if err != nil {
+		return err
+	}
+	if result.Status != "SUCCESS" {
+		return fmt.Errorf("keep alive failed: %s", result.Error)
+	}
+	if err := app.save(client); err != nil {
+		return err
+	}
+
+	status := map[string]string{"status": result.Status}
+	return app.print(status, func(t *table) {
+		t.headers = []string{"STATUS"}
+		t.add(result.Status)
+	})
+}
+
+func runLogout(app *app, args []string) error {
+	if err := app.parseNone("logout", args); err != nil {
+		return err
+	}
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	result, err := client.Logout()
+	if err != nil {
+		return err
+	}
+	if err := app.forget(); err != nil {
+		return err
+	}
+
+	status := map[string]string{"status": result.Status}
+	return app.print(status, func(t *table) {
+		t.headers = []string{"STATUS"}
+		t.add(result.Status)
+	})
+}
+
+// parseFilter parses the filter flags of a list command
+func (app *app) parseFilter(name string, args []string, extra func(flags *flag.FlagSet)) (gofair.MarketFilter, error) {
+	flags := app.newFlagSet(name)
+	filter := addFilterFlags(flags)
+	if extra != nil {
+		extra(flags)
+	}
+	if err := flags.Parse(args); err != nil {
+		return gofair.MarketFilter{}, err
+	}
+	if flags.NArg() > 0 {
+		return gofair.MarketFilter{}, fmt.Errorf("%s takes no arguments, use the filter flags", name)
+	}
+	return filter.build()
+}
+
+func runEventTypes(app *app, args []string) error {
+	filter, err := app.parseFilter("event-types", args, nil)
+	if err != nil {
+		return err
+	}
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	results, err := client.Betting.ListEventTypes(filter)
+	if err != nil {
+		return err
+	}
+
+	return app.print(results, func(t *table) {
+		t.headers = []string{"ID", "NAME", "MARKETS"}
+		for _, result := range results {
+			t.add(result.EventType.ID, result.EventType.Name, result.MarketCount)
+		}
+	})
+}
+
+func runCompetitions(app *app, args []string) error {
+	filter, err := app.parseFilter("competitions", args, nil)
+	if err != nil {
+		return err
+	}
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	results, err := client.Betting.ListCompetitions(filter)
+	if err != nil {
+		return err
+	}
+
+	return app.print(results, func(t *table) {
+		t.headers = []string{"ID", "NAME", "REGION", "MARKETS"}
+		for _, result := range results {
+			t.add(result.Competition.ID, result.Competition.Name, result.CompetitionRegion, result.MarketCount)
+		}
+	})
+}
+
+func runEvents(app *app, args []string) error {
+	filter, err := app.parseFilter("events", args, nil)
+	if err != nil {
+		return err
+	}
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	results, err := client.Betting.ListEvents(filter)
+	if err != nil {
+		return err
+	}
+
+	return app.print(results, func(t *table) {
+		t.headers = []string{"ID", "NAME", "COUNTRY", "OPENS", "MARKETS"}
+		for _, result := range results {
+			t.add(result.Event.ID, result.Event.Name, result.Event.CountryCode, result.Event.OpenDate, result.MarketCount)
+		}
+	})
+}
+
+func runMarkets(app *app, args []string) error {
+	var projection, sort string
+	var maxResults int
+	filter, err := app.parseFilter("markets", args, func(flags *flag.FlagSet) {
+		flags.StringVar(&projection, "projection", "EVENT,MARKET_START_TIME", "comma separated market `projections`")
+		flags.StringVar(&sort, "sort", string(gofair.MarketSortEnum.FirstToStart), "market sort `order`")
+		flags.IntVar(&maxResults, "max", 100, "maximum number of markets, at most 1000")
+	})
+	if err != nil {
+		return err
+	}
+
+	var projections []gofair.MarketProjection
+	for _, p := range split(projection) {
+		projections = append(projections, gofair.MarketProjection(strings.ToUpper(p)))
+	}
+
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	markets, err := client.Betting.ListMarketCatalogue(filter, projections, gofair.MarketSort(strings.ToUpper(sort)), maxResults)
+	if err != nil {
+		return err
+	}
+
+	return app.print(markets, func(t *table) {
+		t.headers = []string{"ID", "NAME", "EVENT", "START", "MATCHED"}
+		for _, market := range markets {
+			t.add(market.MarketID, market.MarketName, market.Event.Name, market.MarketStartTime, market.TotalMatched)
+		}
+	})
+}
+
+// best returns the first price and size of a side of the book
+func best(offers []gofair.PriceSize) string {
+	if len(offers) == 0 {
+		return "-"
+	}
+	return offers[0].Size.String() + " @ " + offers[0].Price.String()
+}
+
+func runBook(app *app, args []string) error {
+	flags := app.newFlagSet("book")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("book needs at least one market id")
+	}
+
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	books, err := client.Betting.ListMarketBook(flags.Args(), false)
+	if err != nil {
+		return err
+	}
+
+	return app.print(books, func(t *table) {
+		t.headers = []string{"MARKET", "STATUS", "SELECTION", "RUNNER STATUS", "LAST", "BACK", "LAY", "MATCHED"}
+		for _, book := range books {
+			for _, runner := range book.Runners {
+				t.add(book.MarketID, book.Status, runner.SelectionID, runner.Status, runner.LastPriceTraded,
+					best(runner.ExchangePrices.AvailableToBack), best(runner.ExchangePrices.AvailableToLay), runner.TotalMatched)
+			}
+		}
+	})
+}
+
+func runBalance(app *app, args []string) error {
+	if err := app.parseNone("balance", args); err != nil {
+		return err
+	}
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	funds, err := client.Account.GetAccountFunds()
+	if err != nil {
+		return err
+	}
+
+	return app.print(funds, func(t *table) {
+		t.headers = []string{"AVAILABLE", "EXPOSURE", "EXPOSURE LIMIT", "RETAINED COMMISSION", "POINTS"}
+		t.add(funds.AvailableToBetBalance, funds.Exposure, funds.ExposureLimit, funds.RetainedCommission, funds.PointsBalance)
+	})
+}
+
+func runOrders(app *app, args []string) error {
+	flags := app.newFlagSet("orders")
+	markets := flags.String("market", "", "comma separated market `ids`")
+	bets := flags.String("bet", "", "comma separated bet `ids`")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	report, err := client.Betting.ListCurrentOrders(split(*bets), split(*markets), gofair.OrderProjectionEnum.All)
+	if err != nil {
+		return err
+	}
+
+	return app.print(report, func(t *table) {
+		t.headers = []string{"BET", "MARKET", "SELECTION", "SIDE", "PRICE", "SIZE", "MATCHED", "REMAINING", "STATUS", "PLACED"}
+		for _, order := range report.CurrentOrders {
+			t.add(order.BetID, order.MarketID, order.SelectionID, order.Side, order.PriceSize.Price, order.PriceSize.Size,
+				order.SizeMatched, order.SizeRemaining, order.Status, order.PlacedDate)
+		}
+	})
+}
+
+func runCleared(app *app, args []string) error {
+	flags := app.newFlagSet("cleared")
+	status := flags.String("status", string(gofair.BetStatusEnum.Settled), "bet `status`: SETTLED, VOIDED, LAPSED or CANCELLED")
+	markets := flags.String("market", "", "comma separated market `ids`")
+	fromFlag := flags.String("from", "", "only orders settled from this RFC 3339 `time`")
+	toFlag := flags.String("to", "", "only orders settled until this RFC 3339 `time`")
+	maxResults := flags.Int("max", 100, "maximum number of orders, at most 1000")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	from, err := parseTime("from", *fromFlag)
+	if err != nil {
+		return err
+	}
+	to, err := parseTime("to", *toFlag)
+	if err != nil {
+		return err
+	}
+	var settled *gofair.TimeRangeFilter
+	if !from.IsZero() || !to.IsZero() {
+		settled = &gofair.TimeRangeFilter{From: from, To: to}
+	}
+
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	report, err := client.Betting.ListClearedOrders(gofair.BetStatus(strings.ToUpper(*status)), split(*markets), settled, 0, *maxResults)
+	if err != nil {
+		return err
+	}
+
+	return app.print(report, func(t *table) {
+		t.headers = []string{"BET", "MARKET", "SELECTION", "SIDE", "PRICE", "SIZE", "OUTCOME", "PROFIT", "SETTLED"}
+		for _, order := range report.ClearedOrders {
+			t.add(order.BetID, order.MarketID, order.SelectionID, order.Side, order.PriceMatched, order.SizeSettled,
+				order.BetOutcome, order.Profit, order.SettledDate)
+		}
+	})
+}
+
+func priceFlag(flags *flag.FlagSet, name string, usage string) *decimal.Price {
+	p := new(decimal.Price)
+	flags.Func(name, usage, func(s string) (err error) {
+		*p, err = decimal.ParsePrice(s)
+		return err
+	})
+	return p
+}
+
+func moneyFlag(flags *flag.FlagSet, name string, usage string) *decimal.Money {
+	m := new(decimal.Money)
+	flags.Func(name, usage, func(s string) (err error) {
+		*m, err = decimal.ParseMoney(s)
+		return err
+	})
+	return m
+}
+
+// dryRun prints the request a transaction would have sent
+func (app *app) dryRun(operation string, request interface{}, tabulate func(t *table)) error {
+	output := map[string]interface{}{"dryRun": true, "operation": operation, "request": request}
+	return app.print(output, tabulate)
+}
+
+// validatePlace checks the instructions before they are sent: the prices against the market's own price ladder and,
+// when the account currency is given, the stakes against the Exchange's minimums for it. Accounts on the Italian
+// exchange are always held to its sizing rules. Anything not checked is left to the Exchange.
+func validatePlace(client *gofair.Client, marketID string, currency string, instructions []gofair.PlaceInstruction) error {
+
+	catalogues, err := client.Betting.ListMarketCatalogue(
+		gofair.MarketFilter{MarketIds: []string{marketID}},
+		[]gofair.MarketProjection{gofair.MarketProjectionEnum.MarketDescription},
+		"", 1,
+	)
+	if err != nil {
+		return err
+	}
+	if len(catalogues) == 0 {
+		return fmt.Errorf("market %s not found", marketID)
+	}
+	ladder, err := catalogues[0].MarketCatalogueDescription.Ladder()
+	if err != nil {
+		return err
+	}
+
+	italian := client.Config.Endpoints == config.EndpointsEnum.Italy
+	if currency == "" && !italian {
+		return gofair.ValidatePrices(ladder, instructions)
+	}
+	return gofair.ValidateInstructions(instructions, gofair.ValidationOptions{Currency: strings.ToUpper(currency), Ladder: ladder, Italian: italian})
+}
+
+func runPlace(app *app, args []string) error {
+	flags := app.newFlagSet("place")
+	market := flags.String("market", "", "market `id`")
+	selection := flags.Int("selection", 0, "selection `id`")
+	side := flags.String("side", "", "BACK or LAY")
+	price := priceFlag(flags, "price", "limit `price`")
+	size := moneyFlag(flags, "size", "stake in the account currency")
+	persistence := flags.String("persistence", string(gofair.PersistenceTypeEnum.Lapse), "what happens at turn in play: LAPSE, PERSIST or MARKET_ON_CLOSE")
+	ref := flags.String("ref", "", "customer order `ref`")
+	strategy := flags.String("strategy", "", "customer strategy `ref`")
+	currency := flags.String("currency", "", "account `currency`, such as GBP, to check the stake against its minimum before sending")
+	dryRun := flags.Bool("dry-run", false, "validate and print the order without placing it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *market == "" || *selection == 0 || *side == "" {
+		return errors.New("place needs -market, -selection, -side, -price and -size")
+	}
+
+	instructions := []gofair.PlaceInstruction{{
+		OrderType:   gofair.OrderTypeEnum.Limit,
+		SelectionID: *selection,
+		Side:        gofair.Side(strings.ToUpper(*side)),
+		LimitOrder: gofair.LimitOrder{
+			Price:           *price,
+			Size:            *size,
+			PersistenceType: gofair.PersistenceType(strings.ToUpper(*persistence)),
+		},
+		CustomerOrderRef: *ref,
+	}}
+
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	if err := validatePlace(client, *market, *currency, instructions); err != nil {
+		return err
+	}
+
+	if *dryRun {
+		request := map[string]interface{}{"marketId": *market, "instructions": instructions, "customerStrategyRef": *strategy}
+		return app.dryRun("placeOrders", request, func(t *table) {
+			t.headers = []string{"DRY RUN", "MARKET", "SELECTION", "SIDE", "PRICE", "SIZE", "PERSISTENCE"}
+			for _, instruction := range instructions {
+				t.add("placeOrders", *market, instruction.SelectionID, instruction.Side, instruction.LimitOrder.Price,
+					instruction.LimitOrder.Size, instruction.LimitOrder.PersistenceType)
+			}
+		})
+	}
+
+	report, err := client.Betting.PlaceOrdersWithOptions(*market, instructions, gofair.PlaceOrdersOptions{CustomerStrategyRef: *strategy})
+	if err != nil {
+		return err
+	}
+
+	return app.print(report, func(t *table) {
+		t.headers = []string{"STATUS", "MARKET", "BET", "SELECTION", "PRICE", "SIZE", "MATCHED", "AVERAGE PRICE", "ERROR"}
+		for _, r := range report.InstructionReports {
+			t.add(r.Status, report.MarketID, r.BetID, r.Instruction.SelectionID, r.Instruction.LimitOrder.Price, r.Instruction.LimitOrder.Size,
+				r.SizeMatched, r.AveragePriceMatched, r.ErrorCode)
+		}
+		if len(report.InstructionReports) == 0 {
+			t.add(report.Status, report.MarketID, "", "", "", "", "", "", report.ErrorCode)
+		}
+	})
+}
+
+func runCancel(app *app, args []string) error {
+	flags := app.newFlagSet("cancel")
+	market := flags.String("market", "", "market `id`, every order in the market is cancelled if -bet is not given")
+	bet := flags.String("bet", "", "bet `id`")
+	reduce := moneyFlag(flags, "reduce", "cancel only this much of the bet's unmatched size")
+	dryRun := flags.Bool("dry-run", false, "print the cancellation without sending it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *market == "" {
+		return errors.New("cancel needs -market")
+	}
+	if *bet == "" && *reduce != 0 {
+		return errors.New("cancel -reduce needs -bet")
+	}
+
+	var instructions []gofair.CancelInstruction
+	if *bet != "" {
+		instructions = append(instructions, gofair.CancelInstruction{BetID: *bet, SizeReduction: *reduce})
+	}
+
+	if *dryRun {
+		request := map[string]interface{}{"marketId": *market, "instructions": instructions}
+		return app.dryRun("cancelOrders", request, func(t *table) {
+			t.headers = []string{"DRY RUN", "MARKET", "BET", "REDUCTION"}
+			if len(instructions) == 0 {
+				t.add("cancelOrders", *market, "all", "-")
+			}
+			for _, instruction := range instructions {
+				t.add("cancelOrders", *market, instruction.BetID, instruction.SizeReduction)
+			}
+		})
+	}
+
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	report, err := client.Betting.CancelOrders(*market, instructions)
+	if err != nil {
+		return err
+	}
+
+	return app.print(report, func(t *table) {
+		t.headers = []string{"STATUS", "BET", "CANCELLED", "ERROR"}
+		for _, r := range report.InstructionReports {
+			t.add(r.Status, r.Instruction.BetID, r.SizeCancelled, r.ErrorCode)
+		}
+		if len(report.InstructionReports) == 0 {
+			t.add(report.Status, "all", "-", report.ErrorCode)
+		}
+	})
+}
+
+func runReplace(app *app, args []string) error {
+	flags := app.newFlagSet("replace")
+	market := flags.String("market", "", "market `id`")
+	bet := flags.String("bet", "", "bet `id`")
+	price := priceFlag(flags, "price", "new `price`")
+	dryRun := flags.Bool("dry-run", false, "print the replacement without sending it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *market == "" || *bet == "" || *price == 0 {
+		return errors.New("replace needs -market, -bet and -price")
+	}
+
+	instructions := []gofair.ReplaceInstruction{{BetID: *bet, NewPrice: *price}}
+
+	if *dryRun {
+		request := map[string]interface{}{"marketId": *market, "instructions": instructions}
+		return app.dryRun("replaceOrders", request, func(t *table) {
+			t.headers = []string{"DRY RUN", "MARKET", "BET", "NEW PRICE"}
+			t.add("replaceOrders", *market, *bet, *price)
+		})
+	}
+
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	report, err := client.Betting.ReplaceOrders(*market, instructions)
+	if err != nil {
+		return err
+	}
+
+	return app.print(report, func(t *table) {
+		t.headers = []string{"STATUS", "OLD BET", "NEW BET", "NEW PRICE", "ERROR"}
+		for _, r := range report.InstructionReports {
+			oldBet, newBet := "", ""
+			if r.CancelInstructionReport != nil {
+				oldBet = r.CancelInstructionReport.Instruction.BetID
+			}
+			if r.PlaceInstructionReport != nil {
+				newBet = r.PlaceInstructionReport.BetID
+			}
+			t.add(r.Status, oldBet, newBet, *price, r.ErrorCode)
+		}
+		if len(report.InstructionReports) == 0 {
+			t.add(report.Status, *bet, "", *price, report.ErrorCode)
+		}
+	})
+}
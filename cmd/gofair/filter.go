@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jonachehilton/gofair"
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+// filterFlags are the MarketFilter flags shared by the list and stream commands
+type filterFlags struct {
+	eventTypes, events, competitions, markets, countries, marketTypes, venues, text string
+	inPlay, turnInPlay                                                              bool
+	from, to                                                                        string
+	within                                                                          time.Duration
+}
+
+func addFilterFlags(flags *flag.FlagSet) *filterFlags {
+	f := new(filterFlags)
+	flags.StringVar(&f.eventTypes, "event-type", "", "comma separated event type `ids`")
+	flags.StringVar(&f.events, "event", "", "comma separated event `ids`")
+	flags.StringVar(&f.competitions, "competition", "", "comma separated competition `ids`")
+	flags.StringVar(&f.markets, "market", "", "comma separated market `ids`")
+	flags.StringVar(&f.countries, "country", "", "comma separated country `codes`")
+	flags.StringVar(&f.marketTypes, "market-type", "", "comma separated market type `codes`, such as MATCH_ODDS")
+	flags.StringVar(&f.venues, "venue", "", "comma separated `venues`")
+	flags.StringVar(&f.text, "text", "", "text `query`")
+	flags.BoolVar(&f.inPlay, "in-play", false, "only markets which are in play")
+	flags.BoolVar(&f.turnInPlay, "turn-in-play", false, "only markets which will turn in play")
+	flags.StringVar(&f.from, "from", "", "only markets starting from this RFC 3339 `time`")
+	flags.StringVar(&f.to, "to", "", "only markets starting until this RFC 3339 `time`")
+	flags.DurationVar(&f.within, "within", 0, "only markets starting within this `duration` from now")
+	return f
+}
+
+// split returns the values of a comma separated list, nil if it is empty
+func split(list string) []string {
+	if list == "" {
+		return nil
+	}
+	values := strings.Split(list, ",")
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+	}
+	return values
+}
+
+func parseTime(name string, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, fmt.Errorf("invalid -%s: %w", name, err)
+	}
+	return t, nil
+}
+
+func (f *filterFlags) builder() (*gofair.MarketFilterBuilder, error) {
+
+	builder := gofair.NewMarketFilter().
+		TextQuery(f.text).
+		EventTypes(split(f.eventTypes)...).
+		Events(split(f.events)...).
+		Competitions(split(f.competitions)...).
+		Markets(split(f.markets)...).
+		Countries(split(f.countries)...).
+		MarketTypes(split(f.marketTypes)...).
+		Venues(split(f.venues)...)
+
+	if f.inPlay {
+		builder.InPlayOnly()
+	}
+	if f.turnInPlay {
+		builder.TurnInPlayEnabled()
+	}
+
+	from, err := parseTime("from", f.from)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseTime("to", f.to)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case f.within > 0:
+		builder.StartingWithin(f.within)
+	case !from.IsZero() || !to.IsZero():
+		builder.StartingBetween(from, to)
+	}
+
+	return builder, nil
+}
+
+// build returns the MarketFilter for the betting API
+func (f *filterFlags) build() (gofair.MarketFilter, error) {
+	builder, err := f.builder()
+	if err != nil {
+		return gofair.MarketFilter{}, err
+	}
+	return builder.Build()
+}
+
+// stream returns the MarketFilter for the Stream API
+func (f *filterFlags) stream() (*models.MarketFilter, error) {
+	builder, err := f.builder()
+	if err != nil {
+		return nil, err
+	}
+	return builder.Stream()
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/jonachehilton/gofair"
+	"github.com/jonachehilton/gofair/price"
+	"github.com/jonachehilton/gofair/streaming"
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+// ladderFields are the market data fields the ladder subscribes to, the full depth is needed to draw every price
+var ladderFields = []string{
+	string(streaming.MarketDataFilterEnum.ExAllOffers),
+	string(streaming.MarketDataFilterEnum.ExTraded),
+	string(streaming.MarketDataFilterEnum.ExTradedVol),
+	string(streaming.MarketDataFilterEnum.ExLTP),
+	string(streaming.MarketDataFilterEnum.ExMarketDef),
+}
+
+// ladderRunner is a copy of the depth and orders of a runner
+type ladderRunner struct {
+	// back and lay are the prices available, best first
+	back, lay []streaming.PriceSize
+	traded    []streaming.PriceSize
+
+	// matchedBacks and matchedLays are our matched sizes by price, as [price, size] pairs
+	matchedBacks, matchedLays [][]float64
+	// unmatched are our executable orders
+	unmatched []models.Order
+}
+
+// ladderSnapshot is the state of a market drawn by the ladder, in addition to its MarketBook
+type ladderSnapshot struct {
+	ladder  *price.Ladder
+	runners map[int64]ladderRunner
+}
+
+// ladderFeed copies the depth and orders of a market from the Stream's caches. It is registered as a StreamHandler so
+// that the copies are taken on the read goroutine, after the built-in handlers have updated the caches, and never
+// while the caches are being written.
+type ladderFeed struct {
+	stream   *streaming.Stream
+	marketID string
+
+	mu     sync.Mutex
+	ladder *price.Ladder
+	depth  map[int64]ladderRunner
+	orders map[int64]ladderRunner
+}
+
+func newLadderFeed(stream *streaming.Stream, marketID string) *ladderFeed {
+	return &ladderFeed{stream: stream, marketID: marketID, ladder: price.Classic}
+}
+
+// handler returns the StreamHandler which keeps the feed up to date
+func (feed *ladderFeed) handler() streaming.StreamHandler {
+	return streaming.StreamHandler{Markets: ladderMarkets{feed}, Orders: ladderOrders{feed}}
+}
+
+// copyMarket copies the depth of every runner, each copy is replaced rather than modified so snapshots can share them
+func (feed *ladderFeed) copyMarket() {
+
+	cache, ok := feed.stream.MarketCache[feed.marketID]
+	if !ok {
+		return
+	}
+
+	ladder, err := price.ForDefinition(cache.MarketDefinition)
+	if err != nil {
+		ladder = price.Classic
+	}
+	depth := make(map[int64]ladderRunner, len(cache.Runners))
+	for id, runner := range cache.Runners {
+		depth[id] = ladderRunner{
+			back:   append([]streaming.PriceSize(nil), runner.AvailableToBack.Prices...),
+			lay:    append([]streaming.PriceSize(nil), runner.AvailableToLay.Prices...),
+			traded: append([]streaming.PriceSize(nil), runner.Traded.Prices...),
+		}
+	}
+
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	feed.ladder = ladder
+	feed.depth = depth
+}
+
+// copyOrders copies our matched sizes and executable orders on every runner
+func (feed *ladderFeed) copyOrders() {
+
+	book, ok := feed.stream.OrderCache[feed.marketID]
+	if !ok {
+		return
+	}
+
+	orders := make(map[int64]ladderRunner, len(book.Runners))
+	for id, runner := range book.Runners {
+		copied := ladderRunner{
+			matchedBacks: append([][]float64(nil), runner.Mb...),
+			matchedLays:  append([][]float64(nil), runner.Ml...),
+		}
+		for _, order := range runner.Uo {
+			if order != nil && streaming.OrderStatus(order.Status) == streaming.OrderStatusEnum.Executable {
+				copied.unmatched = append(copied.unmatched, *order)
+			}
+		}
+		orders[id] = copied
+	}
+
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	feed.orders = orders
+}
+
+// snapshot returns the latest copies of the depth and orders
+func (feed *ladderFeed) snapshot() ladderSnapshot {
+
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	runners := make(map[int64]ladderRunner, len(feed.depth))
+	for id, depth := range feed.depth {
+		runners[id] = depth
+	}
+	for id, orders := range feed.orders {
+		runner := runners[id]
+		runner.matchedBacks = orders.matchedBacks
+		runner.matchedLays = orders.matchedLays
+		runner.unmatched = orders.unmatched
+		runners[id] = runner
+	}
+	return ladderSnapshot{ladder: feed.ladder, runners: runners}
+}
+
+// ladderMarkets is the IMarketHandler of a ladderFeed
+type ladderMarkets struct{ feed *ladderFeed }
+
+func (h ladderMarkets) OnSubscribe(models.MarketChangeMessage)   { h.feed.copyMarket() }
+func (h ladderMarkets) OnResubscribe(models.MarketChangeMessage) { h.feed.copyMarket() }
+func (h ladderMarkets) OnHeartbeat(models.MarketChangeMessage)   {}
+func (h ladderMarkets) OnUpdate(models.MarketChangeMessage)      { h.feed.copyMarket() }
+
+// ladderOrders is the IOrderHandler of a ladderFeed
+type ladderOrders struct{ feed *ladderFeed }
+
+func (h ladderOrders) OnSubscribe(models.OrderChangeMessage)   { h.feed.copyOrders() }
+func (h ladderOrders) OnResubscribe(models.OrderChangeMessage) { h.feed.copyOrders() }
+func (h ladderOrders) OnHeartbeat(models.OrderChangeMessage)   {}
+func (h ladderOrders) OnUpdate(models.OrderChangeMessage)      { h.feed.copyOrders() }
+
+func runLadder(app *app, args []string) error {
+
+	flags := app.newFlagSet("ladder")
+	levels := flags.Int("levels", 3, "price `levels` shown each side of every runner")
+	selection := flags.Int64("selection", 0, "draw a vertical price ladder for this selection `id` instead of every runner")
+	rows := flags.Int("rows", 21, "prices shown by the vertical ladder")
+	withOrders := flags.Bool("orders", true, "show our matched and unmatched orders")
+	once := flags.Bool("once", false, "print the ladder once, without redrawing, and exit")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("ladder needs one market id")
+	}
+	if app.output != outputTable {
+		return errors.New("ladder only draws tables, use stream markets for JSON")
+	}
+	marketID := flags.Arg(0)
+
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+
+	view := ladderView{levels: *levels, selection: *selection, rows: *rows, orders: *withOrders}
+	if err := view.describe(client, marketID); err != nil {
+		return err
+	}
+
+	feed := newLadderFeed(client.Streaming, marketID)
+	client.Streaming.AddHandler(feed.handler())
+	err = client.Streaming.Channels.SetDeliveryPolicies(streaming.DeliveryPolicies{
+		MarketUpdate: streaming.DeliveryPolicyEnum.CoalesceLatest,
+		OrderUpdate:  streaming.DeliveryPolicyEnum.CoalesceLatest,
+	})
+	if err != nil {
+		return err
+	}
+	if streamTLSConfig != nil {
+		client.Streaming.TLSConfig = streamTLSConfig
+	}
+	if err := client.StartStreaming(); err != nil {
+		return err
+	}
+	defer client.Streaming.Stop()
+
+	// Orders are subscribed first, the subscription returning once their image is in the OrderCache, so that orders
+	// resting before the ladder started are in the snapshot taken for the market's initial image
+	if *withOrders {
+		if _, err := client.Streaming.SubscribeToOrders(); err != nil {
+			return err
+		}
+	}
+	filter := models.MarketFilter{MarketIds: []string{marketID}}
+	dataFilter := models.MarketDataFilter{Fields: ladderFields}
+	if _, err := client.Streaming.SubscribeToMarkets(&filter, &dataFilter); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *once {
+		book, err := nextBook(ctx, client.Streaming.Channels, marketID)
+		if err != nil || book == nil {
+			return err
+		}
+		_, err = app.stdout.Write([]byte(strings.Join(view.render(*book, feed.snapshot()), "\n") + "\n"))
+		return err
+	}
+
+	screen := newScreen(app.stdout)
+	defer screen.close()
+	return watch(ctx, client.Streaming.Channels, marketID, func(book streaming.MarketBook) error {
+		return screen.draw(view.render(book, feed.snapshot()))
+	})
+}
+
+// describe looks up the names of the market and its runners
+func (view *ladderView) describe(client *gofair.Client, marketID string) error {
+
+	catalogues, err := client.Betting.ListMarketCatalogue(
+		gofair.MarketFilter{MarketIds: []string{marketID}},
+		[]gofair.MarketProjection{gofair.MarketProjectionEnum.Event, gofair.MarketProjectionEnum.RunnerDescription},
+		"", 1,
+	)
+	if err != nil {
+		return err
+	}
+
+	view.names = make(map[int64]string)
+	for _, catalogue := range catalogues {
+		view.title = catalogue.MarketName
+		if catalogue.Event.Name != "" {
+			view.title = catalogue.Event.Name + " / " + catalogue.MarketName
+		}
+		for _, runner := range catalogue.Runners {
+			view.names[int64(runner.SelectionID)] = runner.RunnerName
+		}
+	}
+	return nil
+}
+
+// nextBook waits for the first MarketBook of the market, it returns nil if ctx is done first
+func nextBook(ctx context.Context, channels *streaming.StreamChannels, marketID string) (*streaming.MarketBook, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case err := <-channels.Err:
+			return nil, err
+		case book := <-channels.MarketUpdate:
+			if book.MarketID == marketID {
+				return &book, nil
+			}
+		case <-channels.OrderUpdate:
+		}
+	}
+}
+
+// watch calls draw with the latest MarketBook of the market after each market or order update, until ctx is done or
+// the stream fails. Updates which queued up while drawing are skipped in favour of the latest one.
+func watch(ctx context.Context, channels *streaming.StreamChannels, marketID string, draw func(book streaming.MarketBook) error) error {
+
+	book, err := nextBook(ctx, channels, marketID)
+	if err != nil || book == nil {
+		return err
+	}
+
+	for {
+		if err := draw(*book); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-channels.Err:
+			return err
+		case update := <-channels.MarketUpdate:
+			if update.MarketID == marketID {
+				book = &update
+			}
+		case <-channels.OrderUpdate:
+		}
+
+	drain:
+		for {
+			select {
+			case update := <-channels.MarketUpdate:
+				if update.MarketID == marketID {
+					book = &update
+				}
+			case <-channels.OrderUpdate:
+			default:
+				break drain
+			}
+		}
+	}
+}
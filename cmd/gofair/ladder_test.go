@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jonachehilton/gofair"
+	"github.com/jonachehilton/gofair/betfairtest"
+	"github.com/jonachehilton/gofair/price"
+	"github.com/jonachehilton/gofair/streaming"
+	"github.com/jonachehilton/gofair/streaming/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBook() streaming.MarketBook {
+	return streaming.MarketBook{
+		MarketID:     "1.23",
+		Status:       "OPEN",
+		InPlay:       true,
+		BetDelay:     5,
+		TotalMatched: 1500,
+		Runners: []streaming.Runner{
+			{SelectionID: 1, Status: "ACTIVE", LastPriceTraded: 2.5, TotalMatched: 1000},
+			{SelectionID: 2, Status: "REMOVED"},
+		},
+	}
+}
+
+func testSnapshot() ladderSnapshot {
+	return ladderSnapshot{
+		ladder: price.Classic,
+		runners: map[int64]ladderRunner{
+			1: {
+				back:         []streaming.PriceSize{{Price: 2.48, Size: 20}, {Price: 2.46, Size: 35}},
+				lay:          []streaming.PriceSize{{Price: 2.52, Size: 15}},
+				traded:       []streaming.PriceSize{{Price: 2.5, Size: 1000}},
+				matchedBacks: [][]float64{{2.4, 10}, {2.6, 10}},
+				unmatched:    []models.Order{{ID: "b1", P: 3, Sr: 2, Side: "B", Status: "E"}},
+			},
+		},
+	}
+}
+
+func TestLadderGrid(t *testing.T) {
+	// Arrange
+	view := ladderView{title: "Ascot / 2m Hcap", names: map[int64]string{1: "Red Rum"}, levels: 2, orders: true}
+
+	// Act
+	lines := view.render(testBook(), testSnapshot())
+
+	// Assert
+	assert.Contains(t, lines[0], "1.23  Ascot / 2m Hcap")
+	assert.Contains(t, lines[0], "OPEN  IN-PLAY  delay 5s  matched 1500")
+	assert.Equal(t, []string{"RUNNER", "LTP", "TRADED", "BACK2", "BACK1", "LAY1", "LAY2", "MATCHED", "UNMATCHED"}, strings.Fields(lines[2]))
+	runner := lines[3]
+	assert.True(t, strings.HasPrefix(runner, "Red Rum"))
+	assert.Regexp(t, `2\.46 \(35\)\s+2\.48 \(20\)\s+2\.52 \(15\)\s+-`, runner)
+	assert.Contains(t, runner, "B 20 @ 2.5")
+	assert.Contains(t, runner, "B 2 @ 3")
+	assert.True(t, strings.HasPrefix(lines[4], "2 (REMOVED)"))
+}
+
+func TestLadderVertical(t *testing.T) {
+	cases := []struct {
+		name      string
+		selection int64
+		want      []string
+	}{
+		{"centred on the last traded price", 1, []string{"2.56", "*2.5", "2.44"}},
+		{"unknown selection", 3, []string{"selection 3 is not in market 1.23"}},
+		{"no prices", 2, []string{"no prices"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			view := ladderView{selection: tc.selection, rows: 7, orders: true}
+
+			// Act
+			lines := view.render(testBook(), testSnapshot())
+
+			// Assert
+			frame := strings.Join(lines, "\n")
+			for _, want := range tc.want {
+				assert.Contains(t, frame, want)
+			}
+		})
+	}
+}
+
+func TestLadderVerticalRows(t *testing.T) {
+	// Arrange
+	view := ladderView{selection: 1, rows: 7, orders: true}
+
+	// Act
+	lines := view.render(testBook(), testSnapshot())
+
+	// Assert
+	rows := lines[4:]
+	assert.Equal(t, []string{"BACKS", "BACK", "PRICE", "LAY", "LAYS", "TRADED"}, strings.Fields(rows[0]))
+	assert.Len(t, rows, 8)
+	assert.Equal(t, []string{"2.52", "15"}, strings.Fields(rows[3]))
+	assert.Equal(t, []string{"*2.5", "1000"}, strings.Fields(rows[4]))
+	assert.Equal(t, []string{"20", "2.48"}, strings.Fields(rows[5]))
+}
+
+func TestScreenRedrawsChangedLines(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	s := newScreen(&out)
+
+	// Act
+	first := s.draw([]string{"header", "a", "b"})
+	firstOutput := out.String()
+	out.Reset()
+	second := s.draw([]string{"header", "c"})
+	secondOutput := out.String()
+	out.Reset()
+	third := s.draw([]string{"header", "c"})
+
+	// Assert
+	assert.NoError(t, first)
+	assert.NoError(t, second)
+	assert.NoError(t, third)
+	assert.Equal(t, hideCursor+clearScreen+moveTo(0)+"header"+clearLine+moveTo(1)+"a"+clearLine+moveTo(2)+"b"+clearLine, firstOutput)
+	assert.Equal(t, moveTo(1)+"c"+clearLine+moveTo(2)+clearLine, secondOutput)
+	assert.Empty(t, out.String())
+}
+
+func TestLadderCommand(t *testing.T) {
+	// Arrange
+	server, session := newTestServer(t)
+	server.HandleBetting("listMarketCatalogue", []gofair.MarketCatalogue{{
+		MarketID:   "1.23",
+		MarketName: "Match Odds",
+		Event:      gofair.Event{Name: "Arsenal v Chelsea"},
+		Runners:    []gofair.RunnerCatalogue{{SelectionID: 1, RunnerName: "Arsenal"}},
+	}})
+
+	stream, err := betfairtest.NewStreamServer()
+	assert.NoError(t, err)
+	defer stream.Close()
+	stream.Script("orderSubscription",
+		`{"op":"ocm","ct":"SUB_IMAGE","initialClk":"AA==","clk":"AA==","pt":1,"oc":[{"id":"1.23","orc":[{"id":1,"mb":[[2.5,10]],"uo":[{"id":"b1","p":3,"s":2,"sr":2,"side":"B","status":"E","pt":"L","ot":"L"}]}]}]}`,
+	)
+	stream.Script("marketSubscription",
+		`{"op":"mcm","ct":"SUB_IMAGE","initialClk":"AA==","clk":"AA==","pt":1,"mc":[{"id":"1.23","marketDefinition":{"status":"OPEN","inPlay":false,"runners":[{"id":1,"status":"ACTIVE","sortPriority":1}]},"rc":[{"id":1,"ltp":2.5,"tv":100,"atb":[[2.48,20]],"atl":[[2.52,15]]}]}]}`,
+	)
+	t.Setenv("GOFAIR_STREAM_ENDPOINT", stream.Addr)
+	streamTLSConfig = stream.ClientTLSConfig()
+	t.Cleanup(func() { streamTLSConfig = nil })
+
+	// Act
+	status, stdout, stderr := execute("-session", session, "ladder", "-levels", "1", "-once", "1.23")
+
+	// Assert
+	assert.Equal(t, 0, status, stderr)
+	assert.Contains(t, stdout, "1.23  Arsenal v Chelsea / Match Odds  |  OPEN  PRE-PLAY")
+	assert.Regexp(t, `Arsenal\s+2\.5\s+100\s+2\.48 \(20\)\s+2\.52 \(15\)\s+B 10 @ 2\.5\s+B 2 @ 3`, stdout)
+	assert.NotContains(t, stdout, "\x1b[", "-once prints plain lines")
+}
@@ -0,0 +1,145 @@
+// Command gofair performs everyday Betfair Exchange tasks from the command line.
+//
+// Usage:
+//
+//	gofair [-config file] [-profile name] [-output table|json] [-session file] <command> [flags] [args]
+//
+// The configuration is read with config.Load, from the -config file (or GOFAIR_CONFIG) and GOFAIR_ environment
+// variables. The session token is kept in the -session file between commands, logging in again when it has expired.
+// Run "gofair help" for the list of commands.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+)
+
+// Output modes
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// app holds the global flags shared by every command
+type app struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	configPath  string
+	profile     string
+	output      string
+	sessionPath string
+}
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(app *app, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"login", "", "log in and save the session", runLogin},
+		{"keepalive", "", "extend the saved session", runKeepAlive},
+		{"logout", "", "log out and remove the saved session", runLogout},
+		{"event-types", "[filter flags]", "list event types (sports)", runEventTypes},
+		{"competitions", "[filter flags]", "list competitions", runCompetitions},
+		{"events", "[filter flags]", "list events", runEvents},
+		{"markets", "[filter flags] [-projection list] [-sort order] [-max n]", "list markets", runMarkets},
+		{"book", "<market id>...", "show the best prices of markets", runBook},
+		{"balance", "", "show the account balance", runBalance},
+		{"orders", "[-market ids] [-bet ids]", "list current orders", runOrders},
+		{"cleared", "[-status status] [-market ids] [-from time] [-to time] [-max n]", "list cleared orders", runCleared},
+		{"place", "-market id -selection id -side BACK|LAY -price p -size s [-currency c] [-dry-run]", "place a limit order", runPlace},
+		{"cancel", "-market id [-bet id [-reduce size]] [-dry-run]", "cancel orders", runCancel},
+		{"replace", "-market id -bet id -price p [-dry-run]", "move an order to a new price", runReplace},
+		{"stream", "markets [filter flags] | orders", "print stream updates as JSON lines", runStream},
+		{"ladder", "[-levels n] [-selection id [-rows n]] [-orders=false] [-once] <market id>", "watch a live trading ladder of a market", runLadder},
+		{"help", "", "show this help", nil},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes a command line, returning the exit status
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+
+	app := &app{stdout: stdout, stderr: stderr}
+
+	flags := flag.NewFlagSet("gofair", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&app.configPath, "config", os.Getenv("GOFAIR_CONFIG"), "JSON or YAML config `file`, GOFAIR_ environment variables only if empty")
+	flags.StringVar(&app.profile, "profile", "", "config profile to use")
+	flags.StringVar(&app.output, "output", outputTable, "output `mode`, table or json")
+	flags.StringVar(&app.sessionPath, "session", defaultSessionPath(), "`file` the session token is saved in")
+	flags.Usage = func() { usage(stderr, flags) }
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if app.output != outputTable && app.output != outputJSON {
+		fmt.Fprintf(stderr, "gofair: unknown output mode %q\n", app.output)
+		return 2
+	}
+	if flags.NArg() == 0 || flags.Arg(0) == "help" {
+		usage(stderr, flags)
+		return 2
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != flags.Arg(0) || cmd.run == nil {
+			continue
+		}
+		err := cmd.run(app, flags.Args()[1:])
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, "gofair:", err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "gofair: unknown command %q\n", flags.Arg(0))
+	return 2
+}
+
+func usage(w io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintln(w, "usage: gofair [flags] <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", cmd.name, cmd.args, cmd.summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "flags:")
+	flags.PrintDefaults()
+}
+
+// newFlagSet creates the flags of a command, which report errors and usage to stderr
+func (app *app) newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("gofair "+name, flag.ContinueOnError)
+	flags.SetOutput(app.stderr)
+	return flags
+}
+
+func defaultSessionPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gofair", "session.json")
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jonachehilton/gofair"
+	"github.com/jonachehilton/gofair/betfairtest"
+	"github.com/jonachehilton/gofair/price"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer points the gofair endpoints and the GOFAIR_ environment at a RESTServer, and returns the server and
+// the session file for -session
+func newTestServer(t *testing.T) (*betfairtest.RESTServer, string) {
+
+	server := betfairtest.NewRESTServer()
+	t.Cleanup(server.Close)
+
+	original := gofair.Endpoints
+	gofair.Endpoints.Login = server.LoginURL()
+	gofair.Endpoints.Identity = server.IdentityURL()
+	gofair.Endpoints.Betting = server.BettingURL()
+	gofair.Endpoints.Account = server.AccountURL()
+	t.Cleanup(func() { gofair.Endpoints = original })
+
+	cert, err := betfairtest.NewCertificate()
+	assert.NoError(t, err)
+	certFile, keyFile, err := cert.WriteFiles(t.TempDir())
+	assert.NoError(t, err)
+
+	t.Setenv("GOFAIR_CONFIG", "")
+	t.Setenv("GOFAIR_PROFILE", "")
+	t.Setenv("GOFAIR_USERNAME", "username")
+	t.Setenv("GOFAIR_PASSWORD", "password")
+	t.Setenv("GOFAIR_APP_KEY", betfairtest.AppKey)
+	t.Setenv("GOFAIR_CERT_FILE", certFile)
+	t.Setenv("GOFAIR_KEY_FILE", keyFile)
+
+	return server, filepath.Join(t.TempDir(), "session.json")
+}
+
+// execute runs a command line, returning the exit status, stdout and stderr
+func execute(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+// operations returns the paths of the requests the server received, other than logins
+func operations(server *betfairtest.RESTServer) []string {
+	var paths []string
+	for _, request := range server.Requests() {
+		if !strings.Contains(request.Path, "certlogin") {
+			paths = append(paths, request.Path)
+		}
+	}
+	return paths
+}
+
+func TestLoginSavesSession(t *testing.T) {
+	// Arrange
+	server, session := newTestServer(t)
+	server.HandleBetting("listEventTypes", []gofair.EventTypeResult{})
+
+	// Act
+	status, stdout, _ := execute("-session", session, "login")
+	saved, err := os.ReadFile(session)
+	secondStatus, _, _ := execute("-session", session, "event-types")
+
+	// Assert
+	assert.Equal(t, 0, status)
+	assert.Contains(t, stdout, "SUCCESS")
+	assert.NotContains(t, stdout, betfairtest.SessionToken)
+	assert.NoError(t, err)
+	assert.Contains(t, string(saved), betfairtest.SessionToken)
+	assert.Equal(t, 0, secondStatus)
+	assert.Len(t, server.Requests(), 2, "the saved session is reused")
+}
+
+func TestEventTypesOutput(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{"table", "table", []string{"ID", "NAME", "MARKETS", "Horse Racing", "12"}},
+		{"json", "json", []string{`"marketCount": 12`, `"name": "Horse Racing"`}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			server, session := newTestServer(t)
+			server.HandleBetting("listEventTypes", []gofair.EventTypeResult{{MarketCount: 12, EventType: gofair.EventType{ID: "7", Name: "Horse Racing"}}})
+
+			// Act
+			status, stdout, stderr := execute("-session", session, "-output", tc.output, "event-types", "-country", "GB")
+
+			// Assert
+			assert.Equal(t, 0, status, stderr)
+			for _, want := range tc.want {
+				assert.Contains(t, stdout, want)
+			}
+			requests := server.Requests()
+			assert.Contains(t, string(requests[len(requests)-1].Body), `"marketCountries":["GB"]`)
+		})
+	}
+}
+
+func TestBalance(t *testing.T) {
+	// Arrange
+	server, session := newTestServer(t)
+	server.HandleAccount("getAccountFunds", gofair.AccountFundsResponse{AvailableToBetBalance: 100.5, Exposure: -20})
+
+	// Act
+	status, stdout, stderr := execute("-session", session, "-output", "json", "balance")
+	var funds gofair.AccountFundsResponse
+	err := json.Unmarshal([]byte(stdout), &funds)
+
+	// Assert
+	assert.Equal(t, 0, status, stderr)
+	assert.NoError(t, err)
+	assert.Equal(t, 100.5, funds.AvailableToBetBalance.Float64())
+	assert.Equal(t, -20.0, funds.Exposure.Float64())
+}
+
+func TestPlace(t *testing.T) {
+	cases := []struct {
+		name       string
+		ladder     price.LadderType
+		args       []string
+		status     int
+		operations []string
+	}{
+		{"dry run", price.LadderTypeEnum.Classic, []string{"-dry-run"}, 0, []string{"/betting/listMarketCatalogue/"}},
+		{"placed", price.LadderTypeEnum.Classic, nil, 0, []string{"/betting/listMarketCatalogue/", "/betting/placeOrders/"}},
+		{"invalid price", price.LadderTypeEnum.Classic, []string{"-dry-run", "-price", "2.01"}, 1, []string{"/betting/listMarketCatalogue/"}},
+		{"price on the market's ladder", price.LadderTypeEnum.Finest, []string{"-dry-run", "-price", "2.03"}, 0, []string{"/betting/listMarketCatalogue/"}},
+		{"stake below the currency minimum", price.LadderTypeEnum.Classic, []string{"-dry-run", "-currency", "usd"}, 1, []string{"/betting/listMarketCatalogue/"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			server, session := newTestServer(t)
+			server.HandleBetting("listMarketCatalogue", []gofair.MarketCatalogue{{
+				MarketID:                   "1.23",
+				MarketCatalogueDescription: gofair.MarketCatalogueDescription{PriceLadderDescription: gofair.PriceLadderDescription{Type: tc.ladder}},
+			}})
+			server.HandleBetting("placeOrders", gofair.PlaceExecutionReport{MarketID: "1.23", Status: "SUCCESS"})
+			args := append([]string{"-session", session, "place", "-market", "1.23", "-selection", "47972", "-side", "back", "-price", "2.02", "-size", "2"}, tc.args...)
+
+			// Act
+			status, stdout, stderr := execute(args...)
+
+			// Assert
+			assert.Equal(t, tc.status, status, stderr)
+			assert.Equal(t, tc.operations, operations(server))
+			if tc.status == 0 {
+				assert.Contains(t, stdout, "1.23")
+			}
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	cases := []struct {
+		name   string
+		args   []string
+		status int
+		stderr string
+	}{
+		{"help", []string{"help"}, 2, "commands:"},
+		{"unknown command", []string{"bet"}, 2, `unknown command "bet"`},
+		{"unknown output", []string{"-output", "xml", "balance"}, 2, `unknown output mode "xml"`},
+		{"missing flags", []string{"replace", "-market", "1.23"}, 1, "replace needs -market, -bet and -price"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			status, _, stderr := execute(tc.args...)
+
+			// Assert
+			assert.Equal(t, tc.status, status)
+			assert.Contains(t, stderr, tc.stderr)
+		})
+	}
+}
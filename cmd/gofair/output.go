@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// table is the table output of a command
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+func (t *table) add(cells ...interface{}) {
+	row := make([]string, len(cells))
+	for i, cell := range cells {
+		switch value := cell.(type) {
+		case time.Time:
+			row[i] = formatTime(value)
+		default:
+			row[i] = fmt.Sprint(value)
+		}
+	}
+	t.rows = append(t.rows, row)
+}
+
+// print writes v as indented JSON, or the table built by tabulate, according to the output mode
+func (app *app) print(v interface{}, tabulate func(t *table)) error {
+
+	if app.output == outputJSON {
+		encoder := json.NewEncoder(app.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	var t table
+	tabulate(&t)
+
+	w := tabwriter.NewWriter(app.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(t.headers, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jonachehilton/gofair/decimal"
+	"github.com/jonachehilton/gofair/streaming"
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+// ladderView draws a market as lines of text, either a grid of every runner's best prices or, with a selection, a
+// vertical price ladder of one runner
+type ladderView struct {
+	title     string
+	names     map[int64]string
+	levels    int
+	selection int64
+	rows      int
+	orders    bool
+}
+
+// render returns the lines of the view of book and snapshot
+func (view *ladderView) render(book streaming.MarketBook, snapshot ladderSnapshot) []string {
+
+	lines := []string{view.header(book), ""}
+	if view.selection != 0 {
+		return append(lines, view.ladder(book, snapshot)...)
+	}
+	return append(lines, view.grid(book, snapshot)...)
+}
+
+// header describes the market, its status and when it was last updated
+func (view *ladderView) header(book streaming.MarketBook) string {
+
+	title := book.MarketID
+	if view.title != "" {
+		title += "  " + view.title
+	}
+
+	play := "PRE-PLAY"
+	if book.InPlay {
+		play = "IN-PLAY"
+	}
+
+	updated := "-"
+	if book.PublishTime > 0 {
+		updated = time.UnixMilli(book.PublishTime).Local().Format("15:04:05")
+	}
+
+	return fmt.Sprintf("%s  |  %s  %s  delay %ds  matched %s  updated %s", title, book.Status, play, book.BetDelay, book.TotalMatched, updated)
+}
+
+func (view *ladderView) name(runner streaming.Runner) string {
+	name, ok := view.names[runner.SelectionID]
+	if !ok {
+		name = fmt.Sprint(runner.SelectionID)
+	}
+	if runner.Status != "" && runner.Status != "ACTIVE" {
+		name += " (" + runner.Status + ")"
+	}
+	return name
+}
+
+// grid draws a row for each runner with its best prices, the best back and lay next to each other
+func (view *ladderView) grid(book streaming.MarketBook, snapshot ladderSnapshot) []string {
+
+	headers := []string{"RUNNER", "LTP", "TRADED"}
+	for level := view.levels; level > 0; level-- {
+		headers = append(headers, fmt.Sprintf("BACK%d", level))
+	}
+	for level := 1; level <= view.levels; level++ {
+		headers = append(headers, fmt.Sprintf("LAY%d", level))
+	}
+	if view.orders {
+		headers = append(headers, "MATCHED", "UNMATCHED")
+	}
+
+	rows := [][]string{headers}
+	for _, runner := range book.Runners {
+		depth := snapshot.runners[runner.SelectionID]
+
+		row := []string{view.name(runner), formatPrice(runner.LastPriceTraded), runner.TotalMatched.String()}
+		for level := view.levels - 1; level >= 0; level-- {
+			row = append(row, formatLevel(depth.back, level))
+		}
+		for level := 0; level < view.levels; level++ {
+			row = append(row, formatLevel(depth.lay, level))
+		}
+		if view.orders {
+			row = append(row, formatMatched(depth), formatUnmatched(depth.unmatched))
+		}
+		rows = append(rows, row)
+	}
+
+	return tabulate(rows)
+}
+
+// ladder draws the prices around the last traded price of the selection, highest first, with the sizes available,
+// our unmatched orders and the volume traded at each
+func (view *ladderView) ladder(book streaming.MarketBook, snapshot ladderSnapshot) []string {
+
+	var runner *streaming.Runner
+	for i := range book.Runners {
+		if book.Runners[i].SelectionID == view.selection {
+			runner = &book.Runners[i]
+		}
+	}
+	if runner == nil {
+		return []string{fmt.Sprintf("selection %d is not in market %s", view.selection, book.MarketID)}
+	}
+	depth := snapshot.runners[runner.SelectionID]
+
+	summary := fmt.Sprintf("%s  |  LTP %s  traded %s", view.name(*runner), formatPrice(runner.LastPriceTraded), runner.TotalMatched)
+	if view.orders {
+		summary += "  matched " + formatMatched(depth)
+	}
+	lines := []string{summary, ""}
+
+	centre := runner.LastPriceTraded.Float64()
+	switch {
+	case centre > 0:
+	case len(depth.back) > 0:
+		centre = depth.back[0].Price.Float64()
+	case len(depth.lay) > 0:
+		centre = depth.lay[0].Price.Float64()
+	default:
+		return append(lines, "no prices")
+	}
+	centre, err := snapshot.ladder.Round(centre)
+	if err != nil {
+		return append(lines, err.Error())
+	}
+
+	back, lay, traded := byTick(depth.back), byTick(depth.lay), byTick(depth.traded)
+	ourBacks, ourLays := make(map[int64]float64), make(map[int64]float64)
+	for _, order := range depth.unmatched {
+		if streaming.OrderSide(order.Side) == streaming.OrderSideEnum.Back {
+			ourBacks[tick(order.P)] += order.Sr
+		} else {
+			ourLays[tick(order.P)] += order.Sr
+		}
+	}
+
+	rows := [][]string{{"BACKS", "BACK", "PRICE", "LAY", "LAYS", "TRADED"}}
+	if !view.orders {
+		rows[0][0], rows[0][4] = "", ""
+	}
+	half := view.rows / 2
+	for offset := half; offset >= -half; offset-- {
+		p, err := snapshot.ladder.AddTicks(centre, offset)
+		if err != nil {
+			continue
+		}
+		t := tick(p)
+		cell := decimal.Price(p).String()
+		if t == tick(runner.LastPriceTraded.Float64()) {
+			cell = "*" + cell
+		}
+		rows = append(rows, []string{
+			formatSize(ourBacks[t]), formatSize(back[t]), cell, formatSize(lay[t]), formatSize(ourLays[t]), formatSize(traded[t]),
+		})
+	}
+
+	return append(lines, tabulate(rows)...)
+}
+
+// tabulate aligns rows into columns
+func tabulate(rows [][]string) []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+// tick is the key of a price in maps, prices being at most two decimal places
+func tick(p float64) int64 {
+	return int64(math.Round(p * 100))
+}
+
+func byTick(levels []streaming.PriceSize) map[int64]float64 {
+	sizes := make(map[int64]float64, len(levels))
+	for _, level := range levels {
+		sizes[tick(level.Price.Float64())] = level.Size.Float64()
+	}
+	return sizes
+}
+
+func formatPrice(p decimal.Price) string {
+	if p == 0 {
+		return "-"
+	}
+	return p.String()
+}
+
+func formatSize(size float64) string {
+	if size == 0 {
+		return ""
+	}
+	return decimal.Money(size).String()
+}
+
+func formatLevel(levels []streaming.PriceSize, level int) string {
+	if level >= len(levels) {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%s)", levels[level].Price, levels[level].Size)
+}
+
+// formatMatched returns our matched size and average price on each side
+func formatMatched(runner ladderRunner) string {
+	var sides []string
+	for _, side := range []struct {
+		name    string
+		matched [][]float64
+	}{{"B", runner.matchedBacks}, {"L", runner.matchedLays}} {
+		var size, value float64
+		for _, entry := range side.matched {
+			if len(entry) == 2 {
+				size += entry[1]
+				value += entry[0] * entry[1]
+			}
+		}
+		if size > 0 {
+			sides = append(sides, fmt.Sprintf("%s %s @ %s", side.name, decimal.Money(size), decimal.Price(value/size)))
+		}
+	}
+	if len(sides) == 0 {
+		return "-"
+	}
+	return strings.Join(sides, " ")
+}
+
+// formatUnmatched returns the remaining size and price of each of our executable orders
+func formatUnmatched(orders []models.Order) string {
+	if len(orders) == 0 {
+		return "-"
+	}
+	cells := make([]string, len(orders))
+	for i, order := range orders {
+		side := streaming.OrderSideEnum.Back
+		if streaming.OrderSide(order.Side) != streaming.OrderSideEnum.Back {
+			side = streaming.OrderSideEnum.Lay
+		}
+		cells[i] = fmt.Sprintf("%s %s @ %s", side, decimal.Money(order.Sr), decimal.Price(order.P))
+	}
+	return strings.Join(cells, ", ")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// ANSI escape sequences
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	clearLine   = "\x1b[K"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+)
+
+// moveTo returns the escape sequence which moves the cursor to the start of a line, counted from zero
+func moveTo(line int) string {
+	return fmt.Sprintf("\x1b[%d;1H", line+1)
+}
+
+// screen redraws frames on a terminal. Only the lines which differ from the previous frame are written, in a single
+// write, so that a fast moving market does not flicker or flood a slow terminal.
+type screen struct {
+	w     io.Writer
+	lines []string
+	drawn bool
+}
+
+func newScreen(w io.Writer) *screen {
+	return &screen{w: w}
+}
+
+// draw replaces the previous frame with frame
+func (s *screen) draw(frame []string) error {
+
+	var buf bytes.Buffer
+	if !s.drawn {
+		buf.WriteString(hideCursor + clearScreen)
+	}
+
+	for i, line := range frame {
+		if s.drawn && i < len(s.lines) && s.lines[i] == line {
+			continue
+		}
+		buf.WriteString(moveTo(i) + line + clearLine)
+	}
+	for i := len(frame); i < len(s.lines); i++ {
+		buf.WriteString(moveTo(i) + clearLine)
+	}
+
+	s.lines = append(s.lines[:0], frame...)
+	s.drawn = true
+	if buf.Len() == 0 {
+		return nil
+	}
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+// close leaves the cursor visible below the last frame
+func (s *screen) close() error {
+	if !s.drawn {
+		return nil
+	}
+	_, err := io.WriteString(s.w, moveTo(len(s.lines))+showCursor)
+	return err
+}
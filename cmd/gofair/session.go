@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jonachehilton/gofair"
+	"github.com/jonachehilton/gofair/config"
+)
+
+// savedSession is the session kept between commands
+type savedSession struct {
+	Username  string    `json:"username"`
+	Token     string    `json:"token"`
+	LoginTime time.Time `json:"loginTime"`
+}
+
+// newClient creates a Client from the configuration, without logging in
+func (app *app) newClient() (*gofair.Client, error) {
+	cfg, err := config.Load(app.configPath, app.profile)
+	if err != nil {
+		return nil, err
+	}
+	return gofair.NewClient(cfg)
+}
+
+// session creates a Client with the saved session, logging in and saving a new one if there is none or it has
+// expired
+func (app *app) session() (*gofair.Client, error) {
+
+	client, err := app.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if saved, err := app.load(); err == nil && saved.Username == client.Config.Username {
+		client.Session.SessionToken = saved.Token
+		client.Session.LoginTime = saved.LoginTime
+	}
+	if !client.SessionExpired() {
+		return client, nil
+	}
+
+	if err := app.login(client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// login logs client in and saves the session
+func (app *app) login(client *gofair.Client) error {
+	result, err := client.Login()
+	if err != nil {
+		return err
+	}
+	if result.LoginStatus != "SUCCESS" {
+		return fmt.Errorf("login failed: %s", result.LoginStatus)
+	}
+	return app.save(client)
+}
+
+func (app *app) load() (savedSession, error) {
+	var saved savedSession
+	if app.sessionPath == "" {
+		return saved, os.ErrNotExist
+	}
+	data, err := os.ReadFile(app.sessionPath)
+	if err != nil {
+		return saved, err
+	}
+	err = json.Unmarshal(data, &saved)
+	return saved, err
+}
+
+// save writes the session of client to the session file, readable only by the user
+func (app *app) save(client *gofair.Client) error {
+	if app.sessionPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(savedSession{Username: client.Config.Username, Token: client.Session.SessionToken, LoginTime: client.Session.LoginTime})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(app.sessionPath), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(app.sessionPath, data, 0600)
+}
+
+// forget removes the session file
+func (app *app) forget() error {
+	if app.sessionPath == "" {
+		return nil
+	}
+	if err := os.Remove(app.sessionPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
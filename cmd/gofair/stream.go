@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/jonachehilton/gofair"
+	"github.com/jonachehilton/gofair/streaming"
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+// streamTLSConfig, if set, is used by the stream command to dial the Exchange, which lets the tests connect to a local
+// server
+var streamTLSConfig *tls.Config
+
+func runStream(app *app, args []string) error {
+
+	if len(args) == 0 {
+		return errors.New("stream needs markets or orders")
+	}
+	kind, args := args[0], args[1:]
+
+	flags := app.newFlagSet("stream " + kind)
+	var filter *filterFlags
+	var fields string
+	var levels int
+	switch kind {
+	case "markets":
+		filter = addFilterFlags(flags)
+		flags.StringVar(&fields, "fields", strings.Join([]string{
+			string(streaming.MarketDataFilterEnum.ExBestOffers),
+			string(streaming.MarketDataFilterEnum.ExLTP),
+			string(streaming.MarketDataFilterEnum.ExMarketDef),
+		}, ","), "comma separated market data `fields`")
+		flags.IntVar(&levels, "levels", 3, "ladder `levels` of the best offers, 1 to 10")
+	case "orders":
+	default:
+		return fmt.Errorf("unknown stream %q, use markets or orders", kind)
+	}
+	count := flags.Int("count", 0, "stop after `n` updates, never if 0")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var marketFilter *models.MarketFilter
+	if filter != nil {
+		var err error
+		if marketFilter, err = filter.stream(); err != nil {
+			return err
+		}
+	}
+
+	client, err := app.session()
+	if err != nil {
+		return err
+	}
+	if streamTLSConfig != nil {
+		client.Streaming.TLSConfig = streamTLSConfig
+	}
+	if err := client.StartStreaming(); err != nil {
+		return err
+	}
+	defer client.Streaming.Stop()
+
+	if marketFilter != nil {
+		dataFilter := models.MarketDataFilter{Fields: split(fields), LadderLevels: int32(levels)}
+		_, err = client.Streaming.SubscribeToMarkets(marketFilter, &dataFilter)
+	} else {
+		_, err = client.Streaming.SubscribeToOrders()
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return tail(ctx, client, json.NewEncoder(app.stdout), *count)
+}
+
+// tail writes each market or order update as a line of JSON until ctx is done, count updates have been written or
+// the stream fails
+func tail(ctx context.Context, client *gofair.Client, encoder *json.Encoder, count int) error {
+	channels := client.Streaming.Channels
+	for written := 0; count == 0 || written < count; written++ {
+		var err error
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-channels.Err:
+			return err
+		case update := <-channels.MarketUpdate:
+			err = encoder.Encode(update)
+		case update := <-channels.OrderUpdate:
+			err = encoder.Encode(update)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
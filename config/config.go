@@ -4,19 +4,85 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"time"
 )
 
-// Config holds login data
+// Config holds login data and the settings NewClient applies to a Client
 type Config struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-	AppKey   string `json:"api_key"`
-	CertFile string `json:"ssl_cert"`
-	KeyFile  string `json:"ssl_key"`
-	Locale   string
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	AppKey   string `json:"api_key" yaml:"api_key"`
+	CertFile string `json:"ssl_cert" yaml:"ssl_cert"`
+	KeyFile  string `json:"ssl_key" yaml:"ssl_key"`
+
+	// PasswordFile and AppKeyFile name files to read the Password and AppKey from, such as mounted secrets
+	PasswordFile string `json:"password_file" yaml:"password_file"`
+	AppKeyFile   string `json:"api_key_file" yaml:"api_key_file"`
+
+	// Locale is the language of the names returned by the betting API, such as "en" or "it", the account's own if empty
+	Locale string `json:"locale" yaml:"locale"`
+
+	// Endpoints is the jurisdiction whose API endpoints are used, see EndpointsEnum, the global Exchange if empty
+	Endpoints string `json:"endpoints" yaml:"endpoints"`
+
+	// Timeout limits each REST request, DefaultTimeout if zero
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+
+	Stream StreamConfig `json:"stream" yaml:"stream"`
+}
+
+// StreamConfig holds the settings NewClient applies to the Client's Stream
+type StreamConfig struct {
+	// Endpoint is "live", "integration" or a host:port, live if empty
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+
+	// SubscriptionTimeout is how long subscriptions wait to be confirmed, the streaming default if zero
+	SubscriptionTimeout Duration `json:"subscription_timeout" yaml:"subscription_timeout"`
+
+	// StaleThreshold is the latency above which the feed is reported stale, the streaming default if zero
+	StaleThreshold Duration `json:"stale_threshold" yaml:"stale_threshold"`
+}
+
+// DefaultTimeout limits each REST request when Config.Timeout is zero
+const DefaultTimeout = 30 * time.Second
+
+// EndpointsEnum lists the jurisdictions supported by Config.Endpoints.
+var EndpointsEnum = struct {
+	Global, Italy, Spain string
+}{
+	Global: "global",
+	Italy:  "italy",
+	Spain:  "spain",
+}
+
+// StreamEndpointEnum lists the named Stream API endpoints supported by StreamConfig.Endpoint.
+var StreamEndpointEnum = struct {
+	Live, Integration string
+}{
+	Live:        "live",
+	Integration: "integration",
+}
+
+// Duration is a time.Duration written as a string such as "30s" or "1m30s"
+type Duration time.Duration
+
+// UnmarshalText parses a duration with time.ParseDuration
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText writes the duration as time.Duration.String does
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
 }
 
-// LoadConfig loads a specified config.json file.
+// LoadConfig loads a specified config.json file, without profiles, environment variables or validation. Use Load to
+// apply them.
 func LoadConfig(configPath string) (*Config, error) {
 	jsonFile, err := os.Open(configPath)
 
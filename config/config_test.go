@@ -0,0 +1,170 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFiles writes files into a temporary directory, returning its path
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	return dir
+}
+
+const yamlConfig = `
+username: user
+password: base-password
+api_key: live-key
+ssl_cert: {{dir}}/client.crt
+ssl_key: {{dir}}/client.key
+timeout: 10s
+stream:
+  endpoint: live
+profiles:
+  integration:
+    api_key: delayed-key
+    stream:
+      endpoint: integration
+      subscription_timeout: 5s
+  italy:
+    endpoints: italy
+    locale: it
+    password_file: {{dir}}/password
+`
+
+func loadFixture(t *testing.T, name string, content string) string {
+	dir := writeFiles(t, map[string]string{"client.crt": "cert", "client.key": "key", "password": "secret\n"})
+	content = strings.ReplaceAll(content, "{{dir}}", dir)
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadYAMLProfiles(t *testing.T) {
+	cases := []struct {
+		name     string
+		profile  string
+		expected func(dir string) Config
+	}{
+		{"Base", "", func(dir string) Config {
+			return Config{Username: "user", Password: "base-password", AppKey: "live-key", CertFile: dir + "/client.crt", KeyFile: dir + "/client.key",
+				Timeout: Duration(10 * time.Second), Stream: StreamConfig{Endpoint: "live"}}
+		}},
+		{"Integration", "integration", func(dir string) Config {
+			return Config{Username: "user", Password: "base-password", AppKey: "delayed-key", CertFile: dir + "/client.crt", KeyFile: dir + "/client.key",
+				Timeout: Duration(10 * time.Second), Stream: StreamConfig{Endpoint: "integration", SubscriptionTimeout: Duration(5 * time.Second)}}
+		}},
+		{"Jurisdiction with secret file", "italy", func(dir string) Config {
+			return Config{Username: "user", Password: "secret", PasswordFile: dir + "/password", AppKey: "live-key", CertFile: dir + "/client.crt",
+				KeyFile: dir + "/client.key", Locale: "it", Endpoints: "italy", Timeout: Duration(10 * time.Second), Stream: StreamConfig{Endpoint: "live"}}
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			path := loadFixture(t, "gofair.yaml", yamlConfig)
+
+			// Act
+			cfg, err := Load(path, c.profile)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected(filepath.Dir(path)), *cfg)
+		})
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	// Arrange
+	path := loadFixture(t, "config.json", `{
+		"username": "user", "password": "password", "api_key": "key",
+		"ssl_cert": "{{dir}}/client.crt", "ssl_key": "{{dir}}/client.key",
+		"profile": "fast",
+		"profiles": {"fast": {"timeout": "2s", "stream": {"stale_threshold": "500ms"}}}
+	}`)
+
+	// Act
+	cfg, err := Load(path, "")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "key", cfg.AppKey)
+	assert.Equal(t, 2*time.Second, cfg.TimeoutOrDefault())
+	assert.Equal(t, Duration(500*time.Millisecond), cfg.Stream.StaleThreshold)
+}
+
+func TestLoadEnvironmentTakesPrecedence(t *testing.T) {
+	// Arrange
+	path := loadFixture(t, "gofair.yml", yamlConfig)
+	t.Setenv("GOFAIR_PROFILE", "integration")
+	t.Setenv("GOFAIR_APP_KEY", "env-key")
+	t.Setenv("GOFAIR_PASSWORD_FILE", filepath.Join(filepath.Dir(path), "password"))
+	t.Setenv("GOFAIR_STREAM_SUBSCRIPTION_TIMEOUT", "1m")
+
+	// Act
+	cfg, err := Load(path, "")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "env-key", cfg.AppKey)
+	assert.Equal(t, "secret", cfg.Password)
+	assert.Equal(t, "integration", cfg.Stream.Endpoint)
+	assert.Equal(t, Duration(time.Minute), cfg.Stream.SubscriptionTimeout)
+}
+
+func TestLoadFromEnvironmentOnly(t *testing.T) {
+	// Arrange
+	dir := writeFiles(t, map[string]string{"client.crt": "cert", "client.key": "key", "app_key": " key \n"})
+	t.Setenv("GOFAIR_USERNAME", "user")
+	t.Setenv("GOFAIR_PASSWORD", "password")
+	t.Setenv("GOFAIR_APP_KEY_FILE", filepath.Join(dir, "app_key"))
+	t.Setenv("GOFAIR_CERT_FILE", filepath.Join(dir, "client.crt"))
+	t.Setenv("GOFAIR_KEY_FILE", filepath.Join(dir, "client.key"))
+
+	// Act
+	cfg, err := Load("", "")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "key", cfg.AppKey)
+	assert.Equal(t, DefaultTimeout, cfg.TimeoutOrDefault())
+}
+
+func TestLoadUnknownProfile(t *testing.T) {
+	// Arrange
+	path := loadFixture(t, "gofair.yaml", yamlConfig)
+
+	// Act
+	_, err := Load(path, "staging")
+
+	// Assert
+	assert.Equal(t, &ProfileError{Profile: "staging", Path: path}, err)
+}
+
+func TestValidate(t *testing.T) {
+	// Arrange
+	cfg := Config{Username: "user", CertFile: "/missing/client.crt", KeyFile: "/missing/client.key", Endpoints: "atlantis", Timeout: -1}
+
+	// Act
+	err := cfg.Validate()
+
+	// Assert
+	var fields []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var fieldErr *FieldError
+		assert.True(t, errors.As(e, &fieldErr))
+		fields = append(fields, fieldErr.Field)
+	}
+	assert.Equal(t, []string{"password", "api_key", "ssl_cert", "ssl_key", "endpoints", "timeout"}, fields)
+	assert.Contains(t, err.Error(), "Invalid config password: required, set it in the config file or GOFAIR_PASSWORD")
+}
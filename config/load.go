@@ -0,0 +1,202 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the name of every environment variable read by Load, such as GOFAIR_APP_KEY
+const EnvPrefix = "GOFAIR_"
+
+// file is the layout of a configuration file: a base Config with optional named profiles which override it
+type file struct {
+	Config `yaml:",inline"`
+
+	// Profile is the profile used when Load is not given one and GOFAIR_PROFILE is not set
+	Profile  string            `json:"profile" yaml:"profile"`
+	Profiles map[string]Config `json:"profiles" yaml:"profiles"`
+}
+
+// ProfileError is returned by Load when the requested profile is not in the configuration file.
+type ProfileError struct {
+	Profile string
+	Path    string
+}
+
+func (err *ProfileError) Error() string {
+	return fmt.Sprintf("Profile %s not found in %s", err.Profile, err.Path)
+}
+
+// Load reads the configuration from a JSON or YAML file, chosen by its extension, and the environment. Later sources
+// take precedence over earlier ones:
+//
+//  1. the base settings of the file at path, which may be empty to configure from the environment alone
+//  2. the named profile from the file's profiles, if any: the profile argument, else GOFAIR_PROFILE, else the file's
+//     own profile setting
+//  3. environment variables, such as GOFAIR_USERNAME, GOFAIR_APP_KEY or GOFAIR_STREAM_ENDPOINT
+//
+// Password and AppKey are then read from PasswordFile and AppKeyFile if they are set, and the result is validated.
+func Load(path string, profile string) (*Config, error) {
+
+	var f file
+	if path != "" {
+		if err := readFile(path, &f); err != nil {
+			return nil, err
+		}
+	}
+
+	if profile == "" {
+		profile = os.Getenv(EnvPrefix + "PROFILE")
+	}
+	if profile == "" {
+		profile = f.Profile
+	}
+
+	config := f.Config
+	if profile != "" {
+		overrides, ok := f.Profiles[profile]
+		if !ok {
+			return nil, &ProfileError{Profile: profile, Path: path}
+		}
+		config.merge(overrides)
+	}
+
+	env, err := fromEnv()
+	if err != nil {
+		return nil, err
+	}
+	config.merge(env)
+
+	if err := config.readSecrets(); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func readFile(path string, f *file) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, f)
+	default:
+		err = json.Unmarshal(data, f)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// fromEnv returns the settings given by environment variables
+func fromEnv() (Config, error) {
+
+	var config Config
+	values := map[string]*string{
+		"USERNAME":        &config.Username,
+		"PASSWORD":        &config.Password,
+		"PASSWORD_FILE":   &config.PasswordFile,
+		"APP_KEY":         &config.AppKey,
+		"APP_KEY_FILE":    &config.AppKeyFile,
+		"CERT_FILE":       &config.CertFile,
+		"KEY_FILE":        &config.KeyFile,
+		"LOCALE":          &config.Locale,
+		"ENDPOINTS":       &config.Endpoints,
+		"STREAM_ENDPOINT": &config.Stream.Endpoint,
+	}
+	for name, field := range values {
+		*field = os.Getenv(EnvPrefix + name)
+	}
+
+	durations := map[string]*Duration{
+		"TIMEOUT":                     &config.Timeout,
+		"STREAM_SUBSCRIPTION_TIMEOUT": &config.Stream.SubscriptionTimeout,
+		"STREAM_STALE_THRESHOLD":      &config.Stream.StaleThreshold,
+	}
+	for name, field := range durations {
+		if value := os.Getenv(EnvPrefix + name); value != "" {
+			if err := field.UnmarshalText([]byte(value)); err != nil {
+				return config, fmt.Errorf("Invalid %s%s: %w", EnvPrefix, name, err)
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// merge overrides the settings of config with those set in overrides. A Password or AppKey replaces a file reference
+// from an earlier source, and a file reference replaces a value.
+func (config *Config) merge(overrides Config) {
+
+	set := func(field *string, value string) {
+		if value != "" {
+			*field = value
+		}
+	}
+	setDuration := func(field *Duration, value Duration) {
+		if value != 0 {
+			*field = value
+		}
+	}
+
+	if overrides.Password != "" || overrides.PasswordFile != "" {
+		config.Password, config.PasswordFile = overrides.Password, overrides.PasswordFile
+	}
+	if overrides.AppKey != "" || overrides.AppKeyFile != "" {
+		config.AppKey, config.AppKeyFile = overrides.AppKey, overrides.AppKeyFile
+	}
+
+	set(&config.Username, overrides.Username)
+	set(&config.CertFile, overrides.CertFile)
+	set(&config.KeyFile, overrides.KeyFile)
+	set(&config.Locale, overrides.Locale)
+	set(&config.Endpoints, overrides.Endpoints)
+	set(&config.Stream.Endpoint, overrides.Stream.Endpoint)
+	setDuration(&config.Timeout, overrides.Timeout)
+	setDuration(&config.Stream.SubscriptionTimeout, overrides.Stream.SubscriptionTimeout)
+	setDuration(&config.Stream.StaleThreshold, overrides.Stream.StaleThreshold)
+}
+
+// readSecrets reads the Password and AppKey from their files, ignoring surrounding whitespace
+func (config *Config) readSecrets() error {
+
+	secrets := []struct {
+		path  string
+		field *string
+	}{
+		{config.PasswordFile, &config.Password},
+		{config.AppKeyFile, &config.AppKey},
+	}
+	for _, secret := range secrets {
+		if secret.path == "" {
+			continue
+		}
+		data, err := os.ReadFile(secret.path)
+		if err != nil {
+			return fmt.Errorf("Unable to read secret: %w", err)
+		}
+		*secret.field = strings.TrimSpace(string(data))
+	}
+	return nil
+}
+
+// TimeoutOrDefault returns Timeout, or DefaultTimeout if it is zero
+func (config *Config) TimeoutOrDefault() time.Duration {
+	if config.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return time.Duration(config.Timeout)
+}
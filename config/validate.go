@@ -0,0 +1,71 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// FieldError describes a missing or invalid setting.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (err *FieldError) Error() string {
+	return fmt.Sprintf("Invalid config %s: %s", err.Field, err.Reason)
+}
+
+// Validate checks that the credentials and certificate are present and the other settings are valid, returning every
+// problem found as a FieldError.
+func (config *Config) Validate() error {
+
+	var errs []error
+	fail := func(field string, reason string) {
+		errs = append(errs, &FieldError{Field: field, Reason: reason})
+	}
+
+	required := []struct {
+		field string
+		value string
+		env   string
+	}{
+		{"username", config.Username, "USERNAME"},
+		{"password", config.Password, "PASSWORD"},
+		{"api_key", config.AppKey, "APP_KEY"},
+		{"ssl_cert", config.CertFile, "CERT_FILE"},
+		{"ssl_key", config.KeyFile, "KEY_FILE"},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			fail(r.field, "required, set it in the config file or "+EnvPrefix+r.env)
+		}
+	}
+
+	for _, file := range []struct{ field, path string }{{"ssl_cert", config.CertFile}, {"ssl_key", config.KeyFile}} {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			fail(file.field, err.Error())
+		}
+	}
+
+	jurisdictions := []string{"", EndpointsEnum.Global, EndpointsEnum.Italy, EndpointsEnum.Spain}
+	if !slices.Contains(jurisdictions, config.Endpoints) {
+		fail("endpoints", fmt.Sprintf("unknown jurisdiction %q", config.Endpoints))
+	}
+
+	if config.Timeout < 0 {
+		fail("timeout", "must not be negative")
+	}
+	if config.Stream.SubscriptionTimeout < 0 {
+		fail("stream.subscription_timeout", "must not be negative")
+	}
+	if config.Stream.StaleThreshold < 0 {
+		fail("stream.stale_threshold", "must not be negative")
+	}
+
+	return errors.Join(errs...)
+}
@@ -211,6 +211,7 @@ var ExecutionReportErrorCodeEnum = struct {
 	InvalidProfitRatio:      "INVALID_PROFIT_RATIO",
 }
 
+// MinimumStakeSizeGBP is the minimum stake in GBP, see Currencies for other currencies
 const (
 	MinimumStakeSizeGBP = 1.00
 )
@@ -232,3 +233,69 @@ var BetTargetTypeEnum = struct {
 	BackersProfit: "BACKERS_PROFIT",
 	Payout:        "PAYOUT",
 }
+
+type BetStatus string
+
+// BetStatusEnum describes the cleared orders returned by listClearedOrders.
+var BetStatusEnum = struct {
+	Settled, Voided, Lapsed, Cancelled BetStatus
+}{
+	Settled:   "SETTLED",
+	Voided:    "VOIDED",
+	Lapsed:    "LAPSED",
+	Cancelled: "CANCELLED",
+}
+
+type MarketProjection string
+
+// MarketProjectionEnum describes the data listMarketCatalogue returns in addition to the market id, name and total matched.
+var MarketProjectionEnum = struct {
+	Competition, Event, EventType, MarketStartTime, MarketDescription, RunnerDescription, RunnerMetadata MarketProjection
+}{
+	Competition:       "COMPETITION",
+	Event:             "EVENT",
+	EventType:         "EVENT_TYPE",
+	MarketStartTime:   "MARKET_START_TIME",
+	MarketDescription: "MARKET_DESCRIPTION",
+	RunnerDescription: "RUNNER_DESCRIPTION",
+	RunnerMetadata:    "RUNNER_METADATA",
+}
+
+type MarketSort string
+
+// MarketSortEnum describes the order of the markets returned by listMarketCatalogue.
+var MarketSortEnum = struct {
+	MinimumTraded, MaximumTraded, MinimumAvailable, MaximumAvailable, FirstToStart, LastToStart MarketSort
+}{
+	MinimumTraded:    "MINIMUM_TRADED",
+	MaximumTraded:    "MAXIMUM_TRADED",
+	MinimumAvailable: "MINIMUM_AVAILABLE",
+	MaximumAvailable: "MAXIMUM_AVAILABLE",
+	FirstToStart:     "FIRST_TO_START",
+	LastToStart:      "LAST_TO_START",
+}
+
+type MarketBettingType string
+
+// MarketBettingTypeEnum describes the betting type of a market.
+var MarketBettingTypeEnum = struct {
+	Odds, Line, Range, AsianHandicapDoubleLine, AsianHandicapSingleLine, FixedOdds MarketBettingType
+}{
+	Odds:                    "ODDS",
+	Line:                    "LINE",
+	Range:                   "RANGE",
+	AsianHandicapDoubleLine: "ASIAN_HANDICAP_DOUBLE_LINE",
+	AsianHandicapSingleLine: "ASIAN_HANDICAP_SINGLE_LINE",
+	FixedOdds:               "FIXED_ODDS",
+}
+
+type TimeGranularity string
+
+// TimeGranularityEnum describes the granularity of the time ranges returned by listTimeRanges.
+var TimeGranularityEnum = struct {
+	Days, Hours, Minutes TimeGranularity
+}{
+	Days:    "DAYS",
+	Hours:   "HOURS",
+	Minutes: "MINUTES",
+}
@@ -0,0 +1,145 @@
+// Package decimal provides the Price and Money types used for odds and amounts throughout gofair.
+//
+// Both are float64s which marshal to and from JSON as the shortest decimal that represents them, after rounding away
+// the binary noise of floating point arithmetic, so that 1.1 is always sent as 1.1 and never 1.0999999. Arithmetic on
+// Money is rounded in the same way so that totals do not drift.
+package decimal
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+)
+
+// places is the precision beyond which digits are treated as floating point noise
+const places = 8
+
+var scale = math.Pow10(places)
+
+func round(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+	return math.Round(f*scale) / scale
+}
+
+func format(f float64) string {
+	return strconv.FormatFloat(round(f), 'f', -1, 64)
+}
+
+// fromFloat32 converts via the shortest decimal representation, so that float32(1.01) becomes 1.01 not 1.00999999
+func fromFloat32(f float32) float64 {
+	v, _ := strconv.ParseFloat(strconv.FormatFloat(float64(f), 'g', -1, 32), 64)
+	return v
+}
+
+// unmarshal accepts a JSON number, a quoted number or null
+func unmarshal(data []byte) (float64, error) {
+	data = bytes.Trim(data, `"`)
+	if len(data) == 0 || string(data) == "null" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(string(data), 64)
+}
+
+// Price is decimal odds, or the unit value of a line market
+type Price float64
+
+// PriceFromFloat32 converts a float32 price without picking up its binary error
+func PriceFromFloat32(f float32) Price {
+	return Price(fromFloat32(f))
+}
+
+// ParsePrice parses a decimal string such as "1.01"
+func ParsePrice(s string) (Price, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	return Price(f), err
+}
+
+func (p Price) Float64() float64 {
+	return float64(p)
+}
+
+func (p Price) String() string {
+	return format(float64(p))
+}
+
+// Equal reports whether two prices are the same decimal
+func (p Price) Equal(other Price) bool {
+	return round(float64(p)) == round(float64(other))
+}
+
+func (p Price) MarshalJSON() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Price) UnmarshalJSON(data []byte) error {
+	f, err := unmarshal(data)
+	*p = Price(f)
+	return err
+}
+
+// Money is a stake, liability, profit or balance in the account currency
+type Money float64
+
+// MoneyFromFloat32 converts a float32 amount without picking up its binary error
+func MoneyFromFloat32(f float32) Money {
+	return Money(fromFloat32(f))
+}
+
+// MoneyFromCents converts a whole number of pence or cents
+func MoneyFromCents(cents int64) Money {
+	return Money(float64(cents) / 100)
+}
+
+// ParseMoney parses a decimal string such as "2.50"
+func ParseMoney(s string) (Money, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	return Money(f), err
+}
+
+func (m Money) Float64() float64 {
+	return float64(m)
+}
+
+func (m Money) String() string {
+	return format(float64(m))
+}
+
+// Cents returns the amount in whole pence or cents, rounding half away from zero
+func (m Money) Cents() int64 {
+	return int64(math.Round(float64(m) * 100))
+}
+
+// Round rounds the amount to whole pence or cents, half away from zero
+func (m Money) Round() Money {
+	return MoneyFromCents(m.Cents())
+}
+
+func (m Money) Add(other Money) Money {
+	return Money(round(float64(m) + float64(other)))
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money(round(float64(m) - float64(other)))
+}
+
+// Mul scales the amount, for example by a price or a commission rate
+func (m Money) Mul(f float64) Money {
+	return Money(round(float64(m) * f))
+}
+
+// Equal reports whether two amounts are the same decimal
+func (m Money) Equal(other Money) bool {
+	return round(float64(m)) == round(float64(other))
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	f, err := unmarshal(data)
+	*m = Money(f)
+	return err
+}
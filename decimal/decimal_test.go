@@ -0,0 +1,81 @@
+package decimal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceJSON(t *testing.T) {
+	// Arrange
+	testCases := []struct {
+		price    Price
+		expected string
+	}{
+		{price: 1.01, expected: "1.01"},
+		{price: 1000, expected: "1000"},
+		{price: PriceFromFloat32(1.1), expected: "1.1"},
+		{price: Price(0.1 + 0.2), expected: "0.3"},
+		{price: 2.34567, expected: "2.34567"},
+	}
+
+	for _, testCase := range testCases {
+		// Act
+		data, err := json.Marshal(testCase.price)
+		var decoded Price
+		decodeErr := json.Unmarshal(data, &decoded)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, decodeErr)
+		assert.Equal(t, testCase.expected, string(data))
+		assert.True(t, decoded.Equal(testCase.price))
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	// Arrange
+	var values struct {
+		Price  Price `json:"price"`
+		Quoted Money `json:"quoted"`
+		Null   Money `json:"null"`
+	}
+
+	// Act
+	err := json.Unmarshal([]byte(`{"price": 1.01, "quoted": "2.50", "null": null}`), &values)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, Price(1.01), values.Price)
+	assert.Equal(t, Money(2.5), values.Quoted)
+	assert.Equal(t, Money(0), values.Null)
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	// Arrange
+	total := Money(0)
+
+	// Act
+	for i := 0; i < 10; i++ {
+		total = total.Add(0.1)
+	}
+
+	// Assert
+	assert.Equal(t, Money(1), total)
+	assert.Equal(t, Money(0.7), Money(1).Sub(0.3))
+	assert.Equal(t, Money(2.5), Money(1.25).Mul(2))
+	assert.Equal(t, int64(1235), Money(12.345).Cents())
+	assert.Equal(t, Money(-0.01), Money(-0.005).Round())
+	assert.Equal(t, Money(2.5), MoneyFromFloat32(2.5))
+	assert.Equal(t, "2.5", Money(2.5).String())
+}
+
+func TestFromFloat32(t *testing.T) {
+	// Act
+	price := PriceFromFloat32(1.1)
+
+	// Assert
+	assert.NotEqual(t, 1.1, float64(float32(1.1)))
+	assert.Equal(t, 1.1, price.Float64())
+}
@@ -1,16 +1,48 @@
 package gofair
 
-// Endpoints contains all the Betfair Exchange API endpoints.
-var Endpoints = struct {
+import "github.com/jonachehilton/gofair/config"
+
+// EndpointSet contains the Betfair Exchange API endpoints of one jurisdiction.
+type EndpointSet struct {
 	Login,
 	Identity,
 	Betting,
 	Account,
+	BettingRPC,
+	AccountRPC,
 	Navigation string
-}{
+}
+
+// Endpoints contains all the Betfair Exchange API endpoints. NewClient copies them into Client.Endpoints unless the
+// Config selects another jurisdiction.
+var Endpoints = EndpointSet{
 	Login:      "https://identitysso-api.betfair.com/api/",
 	Identity:   "https://identitysso.betfair.com/api/",
 	Betting:    "https://api.betfair.com/exchange/betting/rest/v1.0/",
 	Account:    "https://api.betfair.com/exchange/account/rest/v1.0/",
+	BettingRPC: "https://api.betfair.com/exchange/betting/json-rpc/v1",
+	AccountRPC: "https://api.betfair.com/exchange/account/json-rpc/v1",
 	Navigation: "https://api.betfair.com/exchange/betting/rest/v1/en/navigation/menu.json",
 }
+
+// JurisdictionEndpoints contains the endpoints of the jurisdictions with their own Exchange, by config.EndpointsEnum.
+var JurisdictionEndpoints = map[string]EndpointSet{
+	config.EndpointsEnum.Italy: {
+		Login:      "https://identitysso-cert.betfair.it/api/",
+		Identity:   "https://identitysso.betfair.it/api/",
+		Betting:    "https://api.betfair.it/exchange/betting/rest/v1.0/",
+		Account:    "https://api.betfair.it/exchange/account/rest/v1.0/",
+		BettingRPC: "https://api.betfair.it/exchange/betting/json-rpc/v1",
+		AccountRPC: "https://api.betfair.it/exchange/account/json-rpc/v1",
+		Navigation: "https://api.betfair.it/exchange/betting/rest/v1/it/navigation/menu.json",
+	},
+	config.EndpointsEnum.Spain: {
+		Login:      "https://identitysso-cert.betfair.es/api/",
+		Identity:   "https://identitysso.betfair.es/api/",
+		Betting:    "https://api.betfair.es/exchange/betting/rest/v1.0/",
+		Account:    "https://api.betfair.es/exchange/account/rest/v1.0/",
+		BettingRPC: "https://api.betfair.es/exchange/betting/json-rpc/v1",
+		AccountRPC: "https://api.betfair.es/exchange/account/json-rpc/v1",
+		Navigation: "https://api.betfair.es/exchange/betting/rest/v1/es/navigation/menu.json",
+	},
+}
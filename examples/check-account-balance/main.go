@@ -9,10 +9,11 @@ import (
 )
 
 func main() {
-	configPath := flag.String("config", "config.json", "Path to config.json")
+	configPath := flag.String("config", "config.json", "Path to a JSON or YAML config file")
+	profile := flag.String("profile", "", "Config profile to use")
 
 	// Load our config
-	cfg, err := config.LoadConfig(*configPath)
+	cfg, err := config.Load(*configPath, *profile)
 	if err != nil {
 		log.Fatal(err)
 	}
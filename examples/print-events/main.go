@@ -16,10 +16,11 @@ func prettyPrint(i interface{}) string {
 
 func main() {
 
-	configPath := flag.String("config", "config.json", "Path to config.json")
+	configPath := flag.String("config", "config.json", "Path to a JSON or YAML config file")
+	profile := flag.String("profile", "", "Config profile to use")
 
 	// Load our config
-	cfg, err := config.LoadConfig(*configPath)
+	cfg, err := config.Load(*configPath, *profile)
 	if err != nil {
 		log.Fatal(err)
 	}
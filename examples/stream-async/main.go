@@ -26,7 +26,7 @@ func getRandomMarketID(client *gofair.Client) string {
 	eventID := events[0].Event.ID
 
 	filter.EventIds = []string{eventID}
-	marketProjection := []string{"RUNNER_DESCRIPTION", "EVENT"}
+	marketProjection := []gofair.MarketProjection{gofair.MarketProjectionEnum.RunnerDescription, gofair.MarketProjectionEnum.Event}
 
 	marketCatalogues, err := client.Betting.ListMarketCatalogue(filter, marketProjection, "", 1)
 	if err != nil {
@@ -46,10 +46,11 @@ You will also need to supply paths to your SSL cert and private key.
 */
 func main() {
 
-	configPath := flag.String("config", "config.json", "Path to config.json")
+	configPath := flag.String("config", "config.json", "Path to a JSON or YAML config file")
+	profile := flag.String("profile", "", "Config profile to use")
 
 	// Load our config
-	cfg, err := config.LoadConfig(*configPath)
+	cfg, err := config.Load(*configPath, *profile)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -100,8 +101,11 @@ func main() {
 
 	filter := models.MarketFilter{MarketIds: []string{marketID}}
 	dataFilter := models.MarketDataFilter{Fields: []string{string(gofair.PriceDataEnum.ExBestOffers), "EX_MARKET_DEF"}, LadderLevels: 1}
-	client.Streaming.SubscribeToMarkets(&filter, &dataFilter)
-	log.Printf("Sent subscription request for Market %v.", marketID)
+	_, err = client.Streaming.SubscribeToMarkets(&filter, &dataFilter)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Subscribed to Market %v.", marketID)
 
 	i := 5
 	for i > 0 {
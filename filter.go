@@ -0,0 +1,238 @@
+package gofair
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+// MarshalJSON leaves out a zero From or To, which the Exchange would otherwise read as 0001-01-01
+func (r TimeRangeFilter) MarshalJSON() ([]byte, error) {
+	timeRange := make(map[string]string)
+	if !r.From.IsZero() {
+		timeRange["from"] = r.From.UTC().Format(time.RFC3339)
+	}
+	if !r.To.IsZero() {
+		timeRange["to"] = r.To.UTC().Format(time.RFC3339)
+	}
+	return json.Marshal(timeRange)
+}
+
+// FilterError is returned by MarketFilterBuilder for a filter the Exchange would reject or silently ignore.
+type FilterError struct {
+	Field  string
+	Reason string
+}
+
+func (err *FilterError) Error() string {
+	return fmt.Sprintf("Invalid market filter %s: %s", err.Field, err.Reason)
+}
+
+// MarketFilterBuilder builds a MarketFilter for the betting API, or the equivalent models.MarketFilter for the Stream
+// API, checking it as it goes. Errors are returned by Build or Stream.
+type MarketFilterBuilder struct {
+	filter MarketFilter
+	errs   []error
+}
+
+// NewMarketFilter starts a MarketFilterBuilder selecting every market.
+func NewMarketFilter() *MarketFilterBuilder {
+	return new(MarketFilterBuilder)
+}
+
+func (builder *MarketFilterBuilder) fail(field string, reason string) {
+	builder.errs = append(builder.errs, &FilterError{Field: field, Reason: reason})
+}
+
+// ids checks a list of ids or codes, none of which may be empty
+func (builder *MarketFilterBuilder) ids(field string, ids []string) []string {
+	if slices.Contains(ids, "") {
+		builder.fail(field, "empty value")
+	}
+	return ids
+}
+
+// TextQuery restricts markets by text associated with them, such as the name, event or competition
+func (builder *MarketFilterBuilder) TextQuery(query string) *MarketFilterBuilder {
+	builder.filter.TextQuery = query
+	return builder
+}
+
+// EventTypes restricts markets to those of the given event types (sports)
+func (builder *MarketFilterBuilder) EventTypes(ids ...string) *MarketFilterBuilder {
+	builder.filter.EventTypeIds = append(builder.filter.EventTypeIds, builder.ids("eventTypeIds", ids)...)
+	return builder
+}
+
+// Events restricts markets to those of the given events
+func (builder *MarketFilterBuilder) Events(ids ...string) *MarketFilterBuilder {
+	builder.filter.EventIds = append(builder.filter.EventIds, builder.ids("eventIds", ids)...)
+	return builder
+}
+
+// Competitions restricts markets to those of the given competitions
+func (builder *MarketFilterBuilder) Competitions(ids ...string) *MarketFilterBuilder {
+	builder.filter.CompetitionIds = append(builder.filter.CompetitionIds, builder.ids("competitionIds", ids)...)
+	return builder
+}
+
+// Markets restricts the filter to the given market ids
+func (builder *MarketFilterBuilder) Markets(ids ...string) *MarketFilterBuilder {
+	builder.filter.MarketIds = append(builder.filter.MarketIds, builder.ids("marketIds", ids)...)
+	return builder
+}
+
+// Countries restricts markets to those in the given ISO 3166 two letter country codes
+func (builder *MarketFilterBuilder) Countries(codes ...string) *MarketFilterBuilder {
+	for _, code := range codes {
+		if len(code) != 2 {
+			builder.fail("marketCountries", fmt.Sprintf("%q is not a two letter country code", code))
+		}
+	}
+	builder.filter.MarketCountries = append(builder.filter.MarketCountries, codes...)
+	return builder
+}
+
+// Venues restricts markets to those at the given venues, only horse racing markets have venues
+func (builder *MarketFilterBuilder) Venues(venues ...string) *MarketFilterBuilder {
+	builder.filter.Venues = append(builder.filter.Venues, builder.ids("venues", venues)...)
+	return builder
+}
+
+// MarketTypes restricts markets to the given market type codes, such as MATCH_ODDS or WIN
+func (builder *MarketFilterBuilder) MarketTypes(codes ...string) *MarketFilterBuilder {
+	builder.filter.MarketTypeCodes = append(builder.filter.MarketTypeCodes, builder.ids("marketTypeCodes", codes)...)
+	return builder
+}
+
+// RaceTypes restricts horse racing markets to the given race types, such as Flat or Hurdle
+func (builder *MarketFilterBuilder) RaceTypes(types ...string) *MarketFilterBuilder {
+	builder.filter.RaceTypes = append(builder.filter.RaceTypes, builder.ids("raceTypes", types)...)
+	return builder
+}
+
+// BettingTypes restricts markets to the given betting types
+func (builder *MarketFilterBuilder) BettingTypes(types ...MarketBettingType) *MarketFilterBuilder {
+	valid := []MarketBettingType{
+		MarketBettingTypeEnum.Odds, MarketBettingTypeEnum.Line, MarketBettingTypeEnum.Range,
+		MarketBettingTypeEnum.AsianHandicapDoubleLine, MarketBettingTypeEnum.AsianHandicapSingleLine, MarketBettingTypeEnum.FixedOdds,
+	}
+	for _, bettingType := range types {
+		if !slices.Contains(valid, bettingType) {
+			builder.fail("marketBettingTypes", fmt.Sprintf("unknown betting type %q", bettingType))
+		}
+	}
+	builder.filter.MarketBettingTypes = append(builder.filter.MarketBettingTypes, types...)
+	return builder
+}
+
+// BSPOnly restricts markets to those which offer Betfair SP
+func (builder *MarketFilterBuilder) BSPOnly() *MarketFilterBuilder {
+	builder.filter.BSPOnly = true
+	return builder
+}
+
+// TurnInPlayEnabled restricts markets to those which will turn in play
+func (builder *MarketFilterBuilder) TurnInPlayEnabled() *MarketFilterBuilder {
+	builder.filter.TurnInPlayEnabled = true
+	return builder
+}
+
+// InPlayOnly restricts markets to those which are currently in play
+func (builder *MarketFilterBuilder) InPlayOnly() *MarketFilterBuilder {
+	builder.filter.InPlayOnly = true
+	return builder
+}
+
+// StartingBetween restricts markets to those starting from from until to, either of which may be zero to leave that
+// end of the range open
+func (builder *MarketFilterBuilder) StartingBetween(from time.Time, to time.Time) *MarketFilterBuilder {
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		builder.fail("marketStartTime", "from is after to")
+	}
+	builder.filter.MarketStartTime = &TimeRangeFilter{From: from, To: to}
+	return builder
+}
+
+// StartingAfter restricts markets to those starting from the given time
+func (builder *MarketFilterBuilder) StartingAfter(from time.Time) *MarketFilterBuilder {
+	return builder.StartingBetween(from, time.Time{})
+}
+
+// StartingBefore restricts markets to those starting until the given time
+func (builder *MarketFilterBuilder) StartingBefore(to time.Time) *MarketFilterBuilder {
+	return builder.StartingBetween(time.Time{}, to)
+}
+
+// StartingWithin restricts markets to those starting from now until d from now
+func (builder *MarketFilterBuilder) StartingWithin(d time.Duration) *MarketFilterBuilder {
+	now := time.Now()
+	return builder.StartingBetween(now, now.Add(d))
+}
+
+// WithOrders restricts markets to those in which you have orders with the given statuses, which must be EXECUTABLE or
+// EXECUTION_COMPLETE
+func (builder *MarketFilterBuilder) WithOrders(statuses ...OrderStatus) *MarketFilterBuilder {
+	for _, status := range statuses {
+		if status != OrderStatusEnum.Executable && status != OrderStatusEnum.ExecutionComplete {
+			builder.fail("withOrders", fmt.Sprintf("%q is not EXECUTABLE or EXECUTION_COMPLETE", status))
+		}
+	}
+	builder.filter.WithOrders = append(builder.filter.WithOrders, statuses...)
+	return builder
+}
+
+// Build returns the MarketFilter for the betting API, or every problem found with it.
+func (builder *MarketFilterBuilder) Build() (MarketFilter, error) {
+	if len(builder.errs) > 0 {
+		return MarketFilter{}, errors.Join(builder.errs...)
+	}
+	return builder.filter, nil
+}
+
+// Stream returns the equivalent models.MarketFilter for Stream.SubscribeToMarkets. Text queries, competitions, start
+// times, in play only and orders are not supported by the Stream API and are reported as errors rather than dropped.
+func (builder *MarketFilterBuilder) Stream() (*models.MarketFilter, error) {
+
+	errs := append([]error(nil), builder.errs...)
+	unsupported := func(field string, set bool) {
+		if set {
+			errs = append(errs, &FilterError{Field: field, Reason: "not supported by the Stream API"})
+		}
+	}
+
+	filter := builder.filter
+	unsupported("textQuery", filter.TextQuery != "")
+	unsupported("competitionIds", len(filter.CompetitionIds) > 0)
+	unsupported("marketStartTime", filter.MarketStartTime != nil)
+	unsupported("inPlayOnly", filter.InPlayOnly)
+	unsupported("withOrders", len(filter.WithOrders) > 0)
+
+	streamFilter := &models.MarketFilter{
+		BspMarket:         filter.BSPOnly,
+		CountryCodes:      filter.MarketCountries,
+		EventIds:          filter.EventIds,
+		EventTypeIds:      filter.EventTypeIds,
+		MarketIds:         filter.MarketIds,
+		MarketTypes:       filter.MarketTypeCodes,
+		RaceTypes:         filter.RaceTypes,
+		TurnInPlayEnabled: filter.TurnInPlayEnabled,
+		Venues:            filter.Venues,
+	}
+	for _, bettingType := range filter.MarketBettingTypes {
+		streamFilter.BettingTypes = append(streamFilter.BettingTypes, string(bettingType))
+	}
+	if err := streamFilter.Validate(strfmt.Default); err != nil {
+		errs = append(errs, &FilterError{Field: "bettingTypes", Reason: err.Error()})
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return streamFilter, nil
+}
@@ -0,0 +1,131 @@
+package gofair
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jonachehilton/gofair/streaming/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarketFilterBuilder(t *testing.T) {
+	// Arrange
+	from := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	to := from.Add(2 * time.Hour)
+
+	// Act
+	filter, err := NewMarketFilter().
+		EventTypes("7").
+		Countries("GB", "IE").
+		MarketTypes("WIN").
+		BettingTypes(MarketBettingTypeEnum.Odds).
+		TurnInPlayEnabled().
+		StartingBetween(from, to).
+		WithOrders(OrderStatusEnum.Executable).
+		Build()
+	bytes, marshalErr := json.Marshal(filter)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, marshalErr)
+	assert.JSONEq(t, `{
+		"eventTypeIds": ["7"],
+		"marketCountries": ["GB", "IE"],
+		"marketTypeCodes": ["WIN"],
+		"marketBettingTypes": ["ODDS"],
+		"turnInPlayEnabled": true,
+		"marketStartTime": {"from": "2024-05-01T12:00:00Z", "to": "2024-05-01T14:00:00Z"},
+		"withOrders": ["EXECUTABLE"]
+	}`, string(bytes))
+}
+
+func TestTimeRangeFilterOpenEnded(t *testing.T) {
+	// Arrange
+	from := time.Date(2024, 5, 1, 13, 0, 0, 0, time.FixedZone("BST", 3600))
+
+	// Act
+	bytes, err := json.Marshal(TimeRangeFilter{From: from})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"from": "2024-05-01T12:00:00Z"}`, string(bytes))
+}
+
+func TestMarketFilterBuilderErrors(t *testing.T) {
+	now := time.Now()
+
+	cases := []struct {
+		name    string
+		builder *MarketFilterBuilder
+		field   string
+	}{
+		{"Empty id", NewMarketFilter().Markets("1.23", ""), "marketIds"},
+		{"Country code", NewMarketFilter().Countries("GBR"), "marketCountries"},
+		{"Betting type", NewMarketFilter().BettingTypes("EVENS"), "marketBettingTypes"},
+		{"Reversed time range", NewMarketFilter().StartingBetween(now, now.Add(-time.Hour)), "marketStartTime"},
+		{"Order status", NewMarketFilter().WithOrders(OrderStatusEnum.Expired), "withOrders"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			_, err := c.builder.Build()
+
+			// Assert
+			var filterErr *FilterError
+			assert.True(t, errors.As(err, &filterErr))
+			assert.Equal(t, c.field, filterErr.Field)
+		})
+	}
+}
+
+func TestMarketFilterBuilderStream(t *testing.T) {
+	// Act
+	filter, err := NewMarketFilter().
+		EventTypes("1").
+		Countries("GB").
+		MarketTypes("MATCH_ODDS").
+		BettingTypes(MarketBettingTypeEnum.Odds).
+		BSPOnly().
+		TurnInPlayEnabled().
+		Stream()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, &models.MarketFilter{
+		BettingTypes:      []string{"ODDS"},
+		BspMarket:         true,
+		CountryCodes:      []string{"GB"},
+		EventTypeIds:      []string{"1"},
+		MarketTypes:       []string{"MATCH_ODDS"},
+		TurnInPlayEnabled: true,
+	}, filter)
+}
+
+func TestMarketFilterBuilderStreamUnsupported(t *testing.T) {
+	cases := []struct {
+		name    string
+		builder *MarketFilterBuilder
+		field   string
+	}{
+		{"Text query", NewMarketFilter().TextQuery("Ascot"), "textQuery"},
+		{"Start time", NewMarketFilter().StartingWithin(time.Hour), "marketStartTime"},
+		{"In play only", NewMarketFilter().InPlayOnly(), "inPlayOnly"},
+		{"Fixed odds", NewMarketFilter().BettingTypes(MarketBettingTypeEnum.FixedOdds), "bettingTypes"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			filter, err := c.builder.Stream()
+
+			// Assert
+			var filterErr *FilterError
+			assert.Nil(t, filter)
+			assert.True(t, errors.As(err, &filterErr))
+			assert.Equal(t, c.field, filterErr.Field)
+		})
+	}
+}
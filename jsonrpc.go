@@ -0,0 +1,94 @@
+package gofair
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Transport is the protocol used for the betting and account APIs.
+type Transport string
+
+// TransportEnum lists the transports supported by Client.
+var TransportEnum = struct {
+	REST    Transport
+	JSONRPC Transport
+}{
+	REST:    "REST",
+	JSONRPC: "JSON-RPC",
+}
+
+// JSON-RPC method prefixes of the betting and account APIs
+const (
+	bettingRPCPrefix = "SportsAPING/v1.0/"
+	accountRPCPrefix = "AccountAPING/v1.0/"
+)
+
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+	ID      int         `json:"id"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      int             `json:"id"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		APINGException struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"APINGException"`
+	} `json:"data"`
+}
+
+// apiError converts a JSON-RPC error into the APIError returned for the same failure over REST
+func (err *rpcError) apiError() *APIError {
+	return &APIError{
+		StatusCode: 200,
+		Status:     err.Message,
+		ErrorCode:  err.Data.APINGException.ErrorCode,
+		RPCCode:    err.Code,
+	}
+}
+
+// rpcMethod returns the JSON-RPC endpoint and method of a REST url to one of endpoints, ok is false for urls outside the
+// betting and account APIs, which have no JSON-RPC equivalent
+func rpcMethod(endpoints EndpointSet, url string) (endpoint string, method string, ok bool) {
+	switch {
+	case strings.HasPrefix(url, endpoints.Betting):
+		return endpoints.BettingRPC, bettingRPCPrefix + operation(url), true
+	case strings.HasPrefix(url, endpoints.Account):
+		return endpoints.AccountRPC, accountRPCPrefix + operation(url), true
+	}
+	return "", "", false
+}
+
+// call makes a single JSON-RPC 2.0 call, unmarshalling its result into v
+func (c *Client) call(endpoint string, method string, params interface{}, v interface{}) error {
+
+	bytes, err := json.Marshal(rpcRequest{JSONRPC: "2.0", Method: method, Params: params, ID: 1})
+	if err != nil {
+		return err
+	}
+
+	data, err := c.post(endpoint, operation(method), bytes)
+	if err != nil {
+		return err
+	}
+
+	var response rpcResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error.apiError()
+	}
+
+	return json.Unmarshal(response.Result, v)
+}
@@ -0,0 +1,113 @@
+package gofair
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jonachehilton/gofair/betfairtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONRPCTransport(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	client.Transport = TransportEnum.JSONRPC
+	server.HandleBetting("listMarketBook", []MarketBook{{MarketID: "1.23"}})
+	server.HandleAccount("getAccountFunds", AccountFundsResponse{AvailableToBetBalance: 100})
+
+	// Act
+	books, bookErr := client.Betting.ListMarketBook([]string{"1.23"}, false)
+	funds, fundsErr := client.Account.GetAccountFunds()
+
+	// Assert
+	assert.NoError(t, bookErr)
+	assert.NoError(t, fundsErr)
+	assert.Equal(t, "1.23", books[0].MarketID)
+	assert.Equal(t, 100.0, funds.AvailableToBetBalance.Float64())
+
+	requests := server.Requests()
+	var call struct {
+		JSONRPC string `json:"jsonrpc"`
+		Method  string `json:"method"`
+		Params  struct {
+			MarketIDs []string `json:"marketIds"`
+		} `json:"params"`
+	}
+	assert.NoError(t, json.Unmarshal(requests[1].Body, &call))
+	assert.Equal(t, "/json-rpc/betting", requests[1].Path)
+	assert.Equal(t, "2.0", call.JSONRPC)
+	assert.Equal(t, "SportsAPING/v1.0/listMarketBook", call.Method)
+	assert.Equal(t, []string{"1.23"}, call.Params.MarketIDs)
+	assert.Equal(t, "/json-rpc/account", requests[2].Path)
+}
+
+func TestJSONRPCTransportErrors(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	client.Transport = TransportEnum.JSONRPC
+	server.HandleBetting("listMarketBook", []MarketBook{{MarketID: "1.23"}})
+	server.FailNext("/betting/listMarketBook", http.StatusBadRequest, APIErrorCodeEnum.ServiceBusy, 1)
+	server.FailNext("/betting/listMarketCatalogue", http.StatusBadRequest, APIErrorCodeEnum.InvalidInputData, 1)
+
+	// Act
+	books, retriedErr := client.Betting.ListMarketBook([]string{"1.23"}, false)
+	_, err := client.Betting.ListMarketCatalogue(MarketFilter{}, nil, "", 1)
+
+	// Assert
+	assert.NoError(t, retriedErr)
+	assert.Equal(t, "1.23", books[0].MarketID)
+	assert.Equal(t, &APIError{StatusCode: 200, Status: "ANGX-0001", ErrorCode: APIErrorCodeEnum.InvalidInputData, RPCCode: betfairtest.RPCAPINGException}, err)
+}
+
+func TestBatch(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("listEventTypes", []EventTypeResult{{MarketCount: 2, EventType: EventType{ID: "1", Name: "Soccer"}}})
+	server.HandleBetting("listMarketBook", []MarketBook{{MarketID: "1.23"}})
+	server.FailNext("/betting/listCurrentOrders", http.StatusBadRequest, APIErrorCodeEnum.TooMuchData, 1)
+
+	batch := client.Betting.NewBatch()
+	eventTypes := batch.ListEventTypes(MarketFilter{})
+	books := batch.ListMarketBook([]string{"1.23"}, false)
+	orders := batch.ListCurrentOrders(nil, []string{"1.23"}, OrderProjectionEnum.All)
+	venues := batch.ListVenues(MarketFilter{})
+
+	// Act
+	err := batch.Send()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, eventTypes.Err)
+	assert.Equal(t, "Soccer", eventTypes.Result[0].EventType.Name)
+	assert.NoError(t, books.Err)
+	assert.Equal(t, "1.23", books.Result[0].MarketID)
+	assert.Equal(t, APIErrorCodeEnum.TooMuchData, orders.Err.(*APIError).ErrorCode)
+	assert.Equal(t, betfairtest.RPCMethodNotFound, venues.Err.(*APIError).RPCCode)
+
+	requests := server.Requests()
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "/json-rpc/betting", requests[1].Path)
+}
+
+func TestBatchRequestFailure(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	client.Retry.MaxAttempts = 1
+	server.HandleBetting("listMarketBook", []MarketBook{{MarketID: "1.23"}})
+	server.FailNext("/betting/listMarketBook", http.StatusServiceUnavailable, "", 1)
+
+	batch := client.Betting.NewBatch()
+	books := batch.ListMarketBook([]string{"1.23"}, false)
+
+	// Act
+	err := batch.Send()
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, err.(*APIError).StatusCode)
+	assert.Equal(t, err, books.Err)
+}
@@ -3,6 +3,8 @@ package gofair
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/jonachehilton/gofair/logging"
 )
 
 type KeepAliveResult struct {
@@ -14,22 +16,34 @@ type KeepAliveResult struct {
 
 func (c *Client) KeepAlive() (KeepAliveResult, error) {
 	// build url
-	url := createURL(Endpoints.Identity, "keepAlive")
+	url := createURL(c.Endpoints.Identity, "keepAlive")
+
+	logger := c.log()
 
 	// make request
 	resp, err := logoutRequest(c, url)
 	if err != nil {
+		logger.Error("keep alive failed", "error", err)
 		return *new(KeepAliveResult), err
 	}
 
+	logging.Payload(logger, "keep alive response body", resp)
+
 	var result KeepAliveResult
 
 	// parse json
 	err = json.Unmarshal(resp, &result)
 	if err != nil {
+		logger.Error("keep alive failed", "error", err)
 		return result, err
 	}
 
+	if result.Status != "SUCCESS" {
+		logger.Error("keep alive failed", "status", result.Status, "error", result.Error)
+	} else {
+		logger.Info("session kept alive")
+	}
+
 	c.Session.SessionToken = result.Token
 	c.Session.LoginTime = time.Now().UTC()
 	return result, nil
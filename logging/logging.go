@@ -0,0 +1,124 @@
+// Package logging provides the log/slog plumbing shared by the REST client and the Stream. Every logger passed to
+// gofair is wrapped with Redact, and message payloads are only logged at debug level with credentials masked.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// PayloadKey is the attribute key holding a request or message body
+const PayloadKey = "payload"
+
+// Redacted replaces the value of every credential
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are the attribute keys, compared case-insensitively, whose values are never logged
+var sensitiveKeys = map[string]bool{
+	"password":         true,
+	"session":          true,
+	"sessiontoken":     true,
+	"token":            true,
+	"x-authentication": true,
+}
+
+var (
+	jsonCredential = regexp.MustCompile(`"(?i:(password|session|sessionToken|token))"\s*:\s*"(?:[^"\\]|\\.)*"`)
+	formCredential = regexp.MustCompile(`(?i:(password))=[^&]*`)
+)
+
+// New returns a logger writing JSON lines to w at or above level, with credentials redacted
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(Redact(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+}
+
+// Discard returns a logger which drops every record
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// OrDiscard returns logger wrapped with Redact, or a logger which drops every record if it is nil
+func OrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return Discard()
+	}
+	if _, ok := logger.Handler().(*redactHandler); ok {
+		return logger
+	}
+	return slog.New(Redact(logger.Handler()))
+}
+
+// RedactPayload masks the passwords and session tokens in a JSON or form encoded body
+func RedactPayload(payload string) string {
+	payload = jsonCredential.ReplaceAllString(payload, `"$1":"`+Redacted+`"`)
+	return formCredential.ReplaceAllString(payload, "$1="+Redacted)
+}
+
+// Payload logs a request or message body at debug level. The body is only copied and redacted if debug logging is
+// enabled, so it may be called for every message.
+func Payload(logger *slog.Logger, msg string, payload []byte, args ...any) {
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	logger.Debug(msg, append(args, slog.String(PayloadKey, RedactPayload(string(payload))))...)
+}
+
+// Redact wraps handler so that attributes named after credentials, such as password or sessionToken, are replaced
+// with Redacted and payload attributes have their credentials masked
+func Redact(handler slog.Handler) slog.Handler {
+	if redacted, ok := handler.(*redactHandler); ok {
+		return redacted
+	}
+	return &redactHandler{handler: handler}
+}
+
+type redactHandler struct {
+	handler slog.Handler
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redact(attr))
+		return true
+	})
+	return h.handler.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = redact(attr)
+	}
+	return &redactHandler{handler: h.handler.WithAttrs(redacted)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{handler: h.handler.WithGroup(name)}
+}
+
+// redact masks a single attribute, descending into groups
+func redact(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch {
+	case sensitiveKeys[strings.ToLower(attr.Key)]:
+		return slog.String(attr.Key, Redacted)
+	case value.Kind() == slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = redact(member)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case attr.Key == PayloadKey && value.Kind() == slog.KindString:
+		return slog.String(attr.Key, RedactPayload(value.String()))
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
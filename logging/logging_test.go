@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactPayload(t *testing.T) {
+	cases := []struct {
+		name     string
+		payload  string
+		expected string
+	}{
+		{"Stream authentication", `{"op":"authentication","id":1,"appKey":"key","session":"abc=="}`, `{"op":"authentication","id":1,"appKey":"key","session":"[REDACTED]"}`},
+		{"Login response", `{"sessionToken":"abc","loginStatus":"SUCCESS"}`, `{"sessionToken":"[REDACTED]","loginStatus":"SUCCESS"}`},
+		{"Escaped quote", `{"token": "a\"b","status":"SUCCESS"}`, `{"token":"[REDACTED]","status":"SUCCESS"}`},
+		{"Form body", "username=user&password=secret", "username=user&password=[REDACTED]"},
+		{"Nothing to redact", `{"op":"mcm","clk":"AA=="}`, `{"op":"mcm","clk":"AA=="}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			redacted := RedactPayload(c.payload)
+
+			// Assert
+			assert.Equal(t, c.expected, redacted)
+		})
+	}
+}
+
+func TestRedactAttributes(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	// Act
+	logger.With("password", "secret").Info("message", "SessionToken", "abc", slog.Group("request", "token", "xyz", "op", "login"))
+
+	// Assert
+	assert.NotContains(t, buf.String(), "secret")
+	assert.NotContains(t, buf.String(), "abc")
+	assert.NotContains(t, buf.String(), "xyz")
+	assert.Contains(t, buf.String(), `"SessionToken":"[REDACTED]"`)
+	assert.Contains(t, buf.String(), `"request":{"token":"[REDACTED]","op":"login"}`)
+}
+
+func TestPayloadOnlyAtDebug(t *testing.T) {
+	cases := []struct {
+		name   string
+		level  slog.Level
+		logged bool
+	}{
+		{"Debug", slog.LevelDebug, true},
+		{"Info", slog.LevelInfo, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			var buf bytes.Buffer
+			logger := New(&buf, c.level)
+
+			// Act
+			Payload(logger, "sent", []byte(`{"session":"abc"}`), "op", "authentication")
+
+			// Assert
+			if c.logged {
+				assert.Contains(t, buf.String(), `"payload":"{\"session\":\"[REDACTED]\"}"`)
+				assert.Contains(t, buf.String(), `"op":"authentication"`)
+			} else {
+				assert.Empty(t, buf.String())
+			}
+		})
+	}
+}
+
+func TestOrDiscard(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	// Act
+	OrDiscard(nil).Info("dropped")
+	OrDiscard(logger).Info("kept", "password", "secret")
+
+	// Assert
+	assert.Contains(t, buf.String(), "password=[REDACTED]")
+	assert.NotContains(t, buf.String(), "dropped")
+}
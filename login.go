@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/jonachehilton/gofair/logging"
 )
 
 type LoginResult struct {
@@ -20,22 +22,36 @@ func (c *Client) Login() (LoginResult, error) {
 	body := strings.NewReader("username=" + c.Config.Username + "&password=" + c.Config.Password)
 
 	// build url
-	url := createURL(Endpoints.Login, "certlogin")
+	url := createURL(c.Endpoints.Login, "certlogin")
+
+	logger := c.log()
+	logging.Payload(logger, "login request body", []byte("username="+c.Config.Username+"&password="+c.Config.Password))
 
 	// make request
+	c.wait(url)
 	resp, err := loginRequest(c, url, body)
 	if err != nil {
+		logger.Error("login failed", "error", err)
 		return *new(LoginResult), err
 	}
 
+	logging.Payload(logger, "login response body", resp)
+
 	var result LoginResult
 
 	// parse json
 	err = json.Unmarshal(resp, &result)
 	if err != nil {
+		logger.Error("login failed", "error", err)
 		return result, err
 	}
 
+	if result.LoginStatus != "SUCCESS" {
+		logger.Error("login failed", "loginStatus", result.LoginStatus)
+	} else {
+		logger.Info("logged in", "username", c.Config.Username)
+	}
+
 	c.Session.SessionToken = result.SessionToken
 	c.Session.LoginTime = time.Now().UTC()
 	return result, nil
@@ -50,6 +66,7 @@ func loginRequest(c *Client, url string, body *strings.Reader) ([]byte, error) {
 	}
 
 	client := &http.Client{
+		Timeout: c.Timeout,
 		Transport: &http.Transport{
 			TLSClientConfig: ssl,
 		},
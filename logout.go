@@ -6,6 +6,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"time"
+
+	"github.com/jonachehilton/gofair/logging"
 )
 
 type LogoutResult struct {
@@ -18,22 +20,30 @@ type LogoutResult struct {
 // Logout from the current session.
 func (c *Client) Logout() (LogoutResult, error) {
 	// build url
-	url := createURL(Endpoints.Identity, "logout")
+	url := createURL(c.Endpoints.Identity, "logout")
+
+	logger := c.log()
 
 	// make request
 	resp, err := logoutRequest(c, url)
 	if err != nil {
+		logger.Error("logout failed", "error", err)
 		return LogoutResult{}, err
 	}
 
+	logging.Payload(logger, "logout response body", resp)
+
 	var result LogoutResult
 
 	// parse json
 	err = json.Unmarshal(resp, &result)
 	if err != nil {
+		logger.Error("logout failed", "error", err)
 		return result, err
 	}
 
+	logger.Info("logged out", "status", result.Status)
+
 	c.Session.SessionToken = ""
 	c.Session.LoginTime = time.Time{}
 	return result, nil
@@ -52,7 +62,7 @@ func logoutRequest(c *Client, url string) ([]byte, error) {
 	req.Header.Set("X-Authentication", c.Session.SessionToken)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{}
+	client := &http.Client{Timeout: c.Timeout}
 
 	resp, err := client.Do(req)
 
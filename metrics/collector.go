@@ -0,0 +1,313 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the REST latency histogram
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the stream latency histogram
+var DefaultLatencyBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10}
+
+// DefaultProcessingBuckets are the upper bounds, in seconds, of the stream processing time histogram
+var DefaultProcessingBuckets = []float64{0.00001, 0.00005, 0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.05}
+
+// ContentType is the Prometheus text exposition format served by Collector
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// observe adds a measurement to the histogram with the given key, creating it with bounds if needed
+func observe(histograms map[string]*histogram, key string, bounds []float64, seconds float64) {
+	h, ok := histograms[key]
+	if !ok {
+		h = &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+		histograms[key] = h
+	}
+	for i, bound := range h.bounds {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// Collector is a Recorder which keeps every measurement in memory and writes them in the Prometheus text format.
+// It is an http.Handler, so it can be mounted on an existing server for Prometheus to scrape:
+//
+//	collector := metrics.NewCollector()
+//	client.SetMetrics(collector)
+//	http.Handle("/metrics", collector)
+type Collector struct {
+	// Buckets are the upper bounds of the REST latency histogram, LatencyBuckets of the stream latency histogram and
+	// ProcessingBuckets of the stream processing time histogram. Changes only apply to operations not yet recorded.
+	Buckets           []float64
+	LatencyBuckets    []float64
+	ProcessingBuckets []float64
+
+	mu             sync.Mutex
+	now            func() time.Time
+	restRequests   map[[2]string]uint64
+	restLatency    map[string]*histogram
+	streamMessages map[[2]string]uint64
+	streamBytes    uint64
+	clks           map[string]time.Time
+	subscriptions  map[[2]string]uint64
+	caches         map[string]int
+	queues         map[string]int
+	reconnects     uint64
+	latency        map[string]*histogram
+	processing     map[string]*histogram
+	stale          bool
+}
+
+// NewCollector creates an empty Collector using DefaultBuckets, DefaultLatencyBuckets and DefaultProcessingBuckets
+func NewCollector() *Collector {
+	return &Collector{
+		Buckets:           DefaultBuckets,
+		LatencyBuckets:    DefaultLatencyBuckets,
+		ProcessingBuckets: DefaultProcessingBuckets,
+		now:               time.Now,
+		restRequests:      make(map[[2]string]uint64),
+		restLatency:       make(map[string]*histogram),
+		streamMessages:    make(map[[2]string]uint64),
+		clks:              make(map[string]time.Time),
+		subscriptions:     make(map[[2]string]uint64),
+		caches:            make(map[string]int),
+		queues:            make(map[string]int),
+		latency:           make(map[string]*histogram),
+		processing:        make(map[string]*histogram),
+	}
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+func (c *Collector) RESTRequest(operation string, duration time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.restRequests[[2]string{operation, result(err)}]++
+	observe(c.restLatency, operation, c.Buckets, duration.Seconds())
+}
+
+func (c *Collector) StreamMessage(op string, changeType string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.streamMessages[[2]string{op, changeType}]++
+}
+
+func (c *Collector) StreamBytes(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.streamBytes += uint64(n)
+}
+
+func (c *Collector) StreamClk(connectionID string, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clks[connectionID] = at
+}
+
+func (c *Collector) StreamSubscription(kind string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscriptions[[2]string{kind, result(err)}]++
+}
+
+func (c *Collector) CacheSize(cache string, entries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.caches[cache] = entries
+}
+
+func (c *Collector) QueueDepth(channel string, depth int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queues[channel] = depth
+}
+
+func (c *Collector) StreamReconnect() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reconnects++
+}
+
+func (c *Collector) StreamLatency(op string, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	observe(c.latency, op, c.LatencyBuckets, latency.Seconds())
+}
+
+func (c *Collector) StreamProcessing(op string, duration time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	observe(c.processing, op, c.ProcessingBuckets, duration.Seconds())
+}
+
+func (c *Collector) StreamStale(stale bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stale = stale
+}
+
+// labels formats label pairs, escaping the values as the exposition format requires
+func labels(pairs ...string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+escaper.Replace(pairs[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys[K comparable, V any](m map[K]V, less func(a, b K) bool) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+	return keys
+}
+
+func lessPair(a, b [2]string) bool {
+	if a[0] != b[0] {
+		return a[0] < b[0]
+	}
+	return a[1] < b[1]
+}
+
+func lessString(a, b string) bool {
+	return a < b
+}
+
+// WriteTo writes every metric in the Prometheus text format
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := &countingWriter{w: bufio.NewWriter(w)}
+	header := func(name string, kind string, help string) {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	header("gofair_rest_requests_total", "counter", "REST requests by operation and result.")
+	for _, key := range sortedKeys(c.restRequests, lessPair) {
+		fmt.Fprintf(out, "gofair_rest_requests_total%s %d\n", labels("operation", key[0], "result", key[1]), c.restRequests[key])
+	}
+
+	header("gofair_rest_request_duration_seconds", "histogram", "REST request latency by operation.")
+	writeHistograms(out, "gofair_rest_request_duration_seconds", "operation", c.restLatency)
+
+	header("gofair_stream_messages_total", "counter", "Stream messages read by op and change type.")
+	for _, key := range sortedKeys(c.streamMessages, lessPair) {
+		fmt.Fprintf(out, "gofair_stream_messages_total%s %d\n", labels("op", key[0], "ct", key[1]), c.streamMessages[key])
+	}
+
+	header("gofair_stream_read_bytes_total", "counter", "Bytes read from stream connections.")
+	fmt.Fprintf(out, "gofair_stream_read_bytes_total %d\n", c.streamBytes)
+
+	header("gofair_stream_clk_age_seconds", "gauge", "Seconds since the clk of each stream connection last advanced.")
+	now := c.now()
+	for _, connection := range sortedKeys(c.clks, lessString) {
+		fmt.Fprintf(out, "gofair_stream_clk_age_seconds%s %s\n", labels("connection", connection), formatFloat(now.Sub(c.clks[connection]).Seconds()))
+	}
+
+	header("gofair_stream_subscriptions_total", "counter", "Stream subscriptions by kind and result.")
+	for _, key := range sortedKeys(c.subscriptions, lessPair) {
+		fmt.Fprintf(out, "gofair_stream_subscriptions_total%s %d\n", labels("kind", key[0], "result", key[1]), c.subscriptions[key])
+	}
+
+	header("gofair_stream_cache_entries", "gauge", "Entries in each stream cache.")
+	for _, cache := range sortedKeys(c.caches, lessString) {
+		fmt.Fprintf(out, "gofair_stream_cache_entries%s %d\n", labels("cache", cache), c.caches[cache])
+	}
+
+	header("gofair_stream_queue_depth", "gauge", "Updates waiting on each incoming stream channel.")
+	for _, channel := range sortedKeys(c.queues, lessString) {
+		fmt.Fprintf(out, "gofair_stream_queue_depth%s %d\n", labels("channel", channel), c.queues[channel])
+	}
+
+	header("gofair_stream_reconnects_total", "counter", "Streams started again after their first connection.")
+	fmt.Fprintf(out, "gofair_stream_reconnects_total %d\n", c.reconnects)
+
+	header("gofair_stream_latency_seconds", "histogram", "Time from the Exchange publishing a change message to it being read, by op.")
+	writeHistograms(out, "gofair_stream_latency_seconds", "op", c.latency)
+
+	header("gofair_stream_processing_seconds", "histogram", "Time taken to apply a change message to the caches and handlers, by op.")
+	writeHistograms(out, "gofair_stream_processing_seconds", "op", c.processing)
+
+	stale := 0
+	if c.stale {
+		stale = 1
+	}
+	header("gofair_stream_stale", "gauge", "1 while the stream latency is above the stale threshold.")
+	fmt.Fprintf(out, "gofair_stream_stale %d\n", stale)
+
+	if out.err != nil {
+		return out.n, out.err
+	}
+	return out.n, out.w.Flush()
+}
+
+// writeHistograms writes the buckets, sum and count of each histogram labelled with its key
+func writeHistograms(out io.Writer, name string, label string, histograms map[string]*histogram) {
+	for _, key := range sortedKeys(histograms, lessString) {
+		h := histograms[key]
+		for i, bound := range h.bounds {
+			fmt.Fprintf(out, "%s_bucket%s %d\n", name, labels(label, key, "le", formatFloat(bound)), h.counts[i])
+		}
+		fmt.Fprintf(out, "%s_bucket%s %d\n", name, labels(label, key, "le", "+Inf"), h.count)
+		fmt.Fprintf(out, "%s_sum%s %s\n", name, labels(label, key), formatFloat(h.sum))
+		fmt.Fprintf(out, "%s_count%s %d\n", name, labels(label, key), h.count)
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	c.WriteTo(w)
+}
+
+// countingWriter remembers the bytes written and the first error, so that WriteTo need not check every Fprintf
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectorWriteTo(t *testing.T) {
+	// Arrange
+	collector := NewCollector()
+	collector.Buckets = []float64{0.1, 1}
+	collector.LatencyBuckets = []float64{0.5}
+	collector.ProcessingBuckets = []float64{0.001}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	collector.now = func() time.Time { return now }
+
+	// Act
+	collector.RESTRequest("placeOrders", 50*time.Millisecond, nil)
+	collector.RESTRequest("placeOrders", 500*time.Millisecond, errors.New("503 Service Unavailable"))
+	collector.StreamMessage("mcm", "SUB_IMAGE")
+	collector.StreamMessage("mcm", "")
+	collector.StreamMessage("mcm", "")
+	collector.StreamBytes(100)
+	collector.StreamBytes(20)
+	collector.StreamClk("conn-1", now.Add(-1500*time.Millisecond))
+	collector.StreamSubscription("markets", nil)
+	collector.CacheSize("markets", 3)
+	collector.QueueDepth("MarketUpdate", 7)
+	collector.StreamReconnect()
+	collector.StreamLatency("mcm", 250*time.Millisecond)
+	collector.StreamLatency("mcm", 3*time.Second)
+	collector.StreamProcessing("ocm", 100*time.Microsecond)
+	collector.StreamStale(true)
+	var out strings.Builder
+	n, err := collector.WriteTo(&out)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(out.Len()), n)
+	for _, line := range []string{
+		"# TYPE gofair_rest_requests_total counter",
+		`gofair_rest_requests_total{operation="placeOrders",result="error"} 1`,
+		`gofair_rest_requests_total{operation="placeOrders",result="success"} 1`,
+		"# TYPE gofair_rest_request_duration_seconds histogram",
+		`gofair_rest_request_duration_seconds_bucket{operation="placeOrders",le="0.1"} 1`,
+		`gofair_rest_request_duration_seconds_bucket{operation="placeOrders",le="1"} 2`,
+		`gofair_rest_request_duration_seconds_bucket{operation="placeOrders",le="+Inf"} 2`,
+		`gofair_rest_request_duration_seconds_sum{operation="placeOrders"} 0.55`,
+		`gofair_rest_request_duration_seconds_count{operation="placeOrders"} 2`,
+		`gofair_stream_messages_total{op="mcm",ct=""} 2`,
+		`gofair_stream_messages_total{op="mcm",ct="SUB_IMAGE"} 1`,
+		"gofair_stream_read_bytes_total 120",
+		`gofair_stream_clk_age_seconds{connection="conn-1"} 1.5`,
+		`gofair_stream_subscriptions_total{kind="markets",result="success"} 1`,
+		`gofair_stream_cache_entries{cache="markets"} 3`,
+		`gofair_stream_queue_depth{channel="MarketUpdate"} 7`,
+		"gofair_stream_reconnects_total 1",
+		"# TYPE gofair_stream_latency_seconds histogram",
+		`gofair_stream_latency_seconds_bucket{op="mcm",le="0.5"} 1`,
+		`gofair_stream_latency_seconds_bucket{op="mcm",le="+Inf"} 2`,
+		`gofair_stream_latency_seconds_sum{op="mcm"} 3.25`,
+		`gofair_stream_processing_seconds_bucket{op="ocm",le="0.001"} 1`,
+		`gofair_stream_processing_seconds_count{op="ocm"} 1`,
+		"gofair_stream_stale 1",
+	} {
+		assert.Contains(t, out.String(), line+"\n")
+	}
+}
+
+func TestLabelsEscaping(t *testing.T) {
+	// Act
+	formatted := labels("operation", "a\"b\\c\nd")
+
+	// Assert
+	assert.Equal(t, `{operation="a\"b\\c\nd"}`, formatted)
+}
+
+func TestCollectorServeHTTP(t *testing.T) {
+	// Arrange
+	collector := NewCollector()
+	collector.StreamBytes(5)
+	recorder := httptest.NewRecorder()
+
+	// Act
+	collector.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	// Assert
+	assert.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), "gofair_stream_read_bytes_total 5\n")
+}
+
+func TestOrNop(t *testing.T) {
+	// Arrange
+	collector := NewCollector()
+
+	// Act / Assert
+	assert.Equal(t, Nop{}, OrNop(nil))
+	assert.Same(t, collector, OrNop(collector))
+}
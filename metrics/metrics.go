@@ -0,0 +1,67 @@
+// Package metrics defines the Recorder through which gofair reports on its REST requests and streaming connections,
+// along with a Collector which keeps the measurements in memory and serves them in the Prometheus text format.
+package metrics
+
+import (
+	"time"
+)
+
+// Recorder receives measurements from the REST client and the streaming connections. Implementations must be safe
+// for concurrent use and should return quickly, as they are called on the goroutine reading the stream.
+type Recorder interface {
+	// RESTRequest is called after every REST call, err is nil on success
+	RESTRequest(operation string, duration time.Duration, err error)
+
+	// StreamMessage is called for every message read, changeType is empty for messages without one
+	StreamMessage(op string, changeType string)
+
+	// StreamBytes is called with the size of every message read
+	StreamBytes(n int)
+
+	// StreamClk is called when a change message advances the clk of a connection
+	StreamClk(connectionID string, at time.Time)
+
+	// StreamSubscription is called once a subscription has been confirmed or rejected, kind is markets, orders or races
+	StreamSubscription(kind string, err error)
+
+	// CacheSize is called with the number of entries in a cache after each update
+	CacheSize(cache string, entries int)
+
+	// QueueDepth is called with the number of updates waiting on an incoming channel after each update
+	QueueDepth(channel string, depth int)
+
+	// StreamReconnect is called when a Stream is started again after its first connection
+	StreamReconnect()
+
+	// StreamLatency is called with the time between the Exchange publishing a change message and it being read
+	StreamLatency(op string, latency time.Duration)
+
+	// StreamProcessing is called with the time taken to apply a change message to the caches and handlers
+	StreamProcessing(op string, duration time.Duration)
+
+	// StreamStale is called when the stream latency crosses the stale threshold, in either direction
+	StreamStale(stale bool)
+}
+
+// Nop is a Recorder which discards every measurement. Embed it to implement only part of Recorder.
+type Nop struct{}
+
+func (Nop) RESTRequest(operation string, duration time.Duration, err error) {}
+func (Nop) StreamMessage(op string, changeType string)                      {}
+func (Nop) StreamBytes(n int)                                               {}
+func (Nop) StreamClk(connectionID string, at time.Time)                     {}
+func (Nop) StreamSubscription(kind string, err error)                       {}
+func (Nop) CacheSize(cache string, entries int)                             {}
+func (Nop) QueueDepth(channel string, depth int)                            {}
+func (Nop) StreamReconnect()                                                {}
+func (Nop) StreamLatency(op string, latency time.Duration)                  {}
+func (Nop) StreamProcessing(op string, duration time.Duration)              {}
+func (Nop) StreamStale(stale bool)                                          {}
+
+// OrNop returns recorder, or Nop if it is nil
+func OrNop(recorder Recorder) Recorder {
+	if recorder == nil {
+		return Nop{}
+	}
+	return recorder
+}
@@ -0,0 +1,488 @@
+package gofair
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jonachehilton/gofair/decimal"
+	"github.com/jonachehilton/gofair/streaming"
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+type OrderState string
+
+// OrderStateEnum describes how far a TrackedOrder has progressed.
+var OrderStateEnum = struct {
+	Submitted, Accepted, Complete, Failed OrderState
+}{
+	Submitted: "SUBMITTED",
+	Accepted:  "ACCEPTED",
+	Complete:  "COMPLETE",
+	Failed:    "FAILED",
+}
+
+// PlacementError is the error of a TrackedOrder which the Exchange rejected.
+type PlacementError struct {
+	ErrorCode string
+}
+
+func (err *PlacementError) Error() string {
+	return "Order placement failed: " + err.ErrorCode
+}
+
+// TrackedOrder follows a single order from submission, through the order stream, to completion. The Accepted, Matched
+// and Done channels are closed as the order reaches each stage, and the matching callbacks are called.
+type TrackedOrder struct {
+	MarketID         string
+	SelectionID      int64
+	CustomerOrderRef string
+
+	// Instruction is nil for orders rebuilt from the order stream
+	Instruction *PlaceInstruction
+
+	mu                  sync.Mutex
+	betID               string
+	state               OrderState
+	sizeMatched         decimal.Money
+	sizeRemaining       decimal.Money
+	averagePriceMatched decimal.Price
+	err                 error
+	// completedAt is when the order completed or failed, see OrderManager.Prune
+	completedAt time.Time
+
+	accepted chan struct{}
+	matched  chan struct{}
+	done     chan struct{}
+
+	onAccepted []func(*TrackedOrder)
+	onMatched  []func(*TrackedOrder)
+	onDone     []func(*TrackedOrder)
+}
+
+func newTrackedOrder(marketID string, selectionID int64, customerOrderRef string) *TrackedOrder {
+	return &TrackedOrder{
+		MarketID:         marketID,
+		SelectionID:      selectionID,
+		CustomerOrderRef: customerOrderRef,
+		state:            OrderStateEnum.Submitted,
+		accepted:         make(chan struct{}),
+		matched:          make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+}
+
+// BetID returns the bet id, which is empty until the order has been accepted
+func (order *TrackedOrder) BetID() string {
+	order.mu.Lock()
+	defer order.mu.Unlock()
+	return order.betID
+}
+
+func (order *TrackedOrder) State() OrderState {
+	order.mu.Lock()
+	defer order.mu.Unlock()
+	return order.state
+}
+
+func (order *TrackedOrder) SizeMatched() decimal.Money {
+	order.mu.Lock()
+	defer order.mu.Unlock()
+	return order.sizeMatched
+}
+
+func (order *TrackedOrder) SizeRemaining() decimal.Money {
+	order.mu.Lock()
+	defer order.mu.Unlock()
+	return order.sizeRemaining
+}
+
+func (order *TrackedOrder) AveragePriceMatched() decimal.Price {
+	order.mu.Lock()
+	defer order.mu.Unlock()
+	return order.averagePriceMatched
+}
+
+// Err returns the reason the order failed, if it did
+func (order *TrackedOrder) Err() error {
+	order.mu.Lock()
+	defer order.mu.Unlock()
+	return order.err
+}
+
+// Accepted is closed once the Exchange has assigned the order a bet id
+func (order *TrackedOrder) Accepted() <-chan struct{} {
+	return order.accepted
+}
+
+// Matched is closed once the order has been fully matched
+func (order *TrackedOrder) Matched() <-chan struct{} {
+	return order.matched
+}
+
+// Done is closed once the order is complete (matched, cancelled, lapsed or voided) or has failed
+func (order *TrackedOrder) Done() <-chan struct{} {
+	return order.done
+}
+
+// OnAccepted calls fn once the order has been accepted, immediately if it already has been
+func (order *TrackedOrder) OnAccepted(fn func(*TrackedOrder)) {
+	order.register(&order.onAccepted, order.accepted, fn)
+}
+
+// OnMatched calls fn every time the matched size increases
+func (order *TrackedOrder) OnMatched(fn func(*TrackedOrder)) {
+	order.mu.Lock()
+	order.onMatched = append(order.onMatched, fn)
+	order.mu.Unlock()
+}
+
+// OnDone calls fn once the order is complete or has failed, immediately if it already is
+func (order *TrackedOrder) OnDone(fn func(*TrackedOrder)) {
+	order.register(&order.onDone, order.done, fn)
+}
+
+func (order *TrackedOrder) register(callbacks *[]func(*TrackedOrder), reached chan struct{}, fn func(*TrackedOrder)) {
+	order.mu.Lock()
+	select {
+	case <-reached:
+		order.mu.Unlock()
+		fn(order)
+		return
+	default:
+	}
+	*callbacks = append(*callbacks, fn)
+	order.mu.Unlock()
+}
+
+func closeOnce(c chan struct{}) bool {
+	select {
+	case <-c:
+		return false
+	default:
+		close(c)
+		return true
+	}
+}
+
+// The transition methods below must be called with the lock held. They return the callbacks to run once it is released.
+
+func (order *TrackedOrder) accept(betID string) []func(*TrackedOrder) {
+	if order.betID == "" {
+		order.betID = betID
+	}
+	if order.state == OrderStateEnum.Submitted {
+		order.state = OrderStateEnum.Accepted
+	}
+	if closeOnce(order.accepted) {
+		return order.onAccepted
+	}
+	return nil
+}
+
+func (order *TrackedOrder) match(sizeMatched decimal.Money, sizeRemaining decimal.Money, averagePriceMatched decimal.Price) []func(*TrackedOrder) {
+	callbacks := order.matchSize(sizeMatched, averagePriceMatched)
+	order.sizeRemaining = sizeRemaining
+	if sizeMatched > 0 && sizeRemaining == 0 {
+		closeOnce(order.matched)
+	}
+	return callbacks
+}
+
+// matchSize records the matched size without the remaining size, for reports which do not tell how much is left
+func (order *TrackedOrder) matchSize(sizeMatched decimal.Money, averagePriceMatched decimal.Price) []func(*TrackedOrder) {
+	var callbacks []func(*TrackedOrder)
+	if sizeMatched > order.sizeMatched {
+		callbacks = order.onMatched
+	}
+	order.sizeMatched = sizeMatched
+	order.averagePriceMatched = averagePriceMatched
+	return callbacks
+}
+
+func (order *TrackedOrder) complete() []func(*TrackedOrder) {
+	if order.state != OrderStateEnum.Failed {
+		order.state = OrderStateEnum.Complete
+	}
+	if closeOnce(order.done) {
+		order.completedAt = time.Now()
+		return order.onDone
+	}
+	return nil
+}
+
+func (order *TrackedOrder) fail(err error) {
+	order.mu.Lock()
+	if order.state != OrderStateEnum.Submitted {
+		order.mu.Unlock()
+		return
+	}
+	order.state = OrderStateEnum.Failed
+	order.err = err
+	callbacks := order.complete()
+	order.mu.Unlock()
+
+	run(order, callbacks)
+}
+
+// remaining returns the size left unmatched after a placeOrders report. It is only known for limit orders given a
+// Size, as the report does not include it and the size of a BetTargetType, LIMIT_ON_CLOSE or MARKET_ON_CLOSE order
+// depends on how it is matched.
+func remaining(instruction *PlaceInstruction, report PlaceInstructionReport) (decimal.Money, bool) {
+	if report.OrderStatus == OrderStatusEnum.ExecutionComplete {
+		return 0, true
+	}
+	if instruction == nil || instruction.OrderType != OrderTypeEnum.Limit || instruction.LimitOrder.BetTargetType != "" || instruction.LimitOrder.Size <= 0 {
+		return 0, false
+	}
+	sizeRemaining := instruction.LimitOrder.Size.Sub(report.SizeMatched)
+	if sizeRemaining < 0 {
+		sizeRemaining = 0
+	}
+	return sizeRemaining, true
+}
+
+// placed applies the instruction report returned by placeOrders, the order stream fills in the remaining size when the
+// report cannot
+func (order *TrackedOrder) placed(report PlaceInstructionReport) {
+	order.mu.Lock()
+	callbacks := order.accept(report.BetID)
+	if sizeRemaining, ok := remaining(order.Instruction, report); ok {
+		callbacks = append(callbacks, order.match(report.SizeMatched, sizeRemaining, report.AveragePriceMatched)...)
+	} else {
+		callbacks = append(callbacks, order.matchSize(report.SizeMatched, report.AveragePriceMatched)...)
+	}
+	if report.OrderStatus == OrderStatusEnum.ExecutionComplete {
+		callbacks = append(callbacks, order.complete()...)
+	}
+	order.mu.Unlock()
+
+	run(order, callbacks)
+}
+
+// update applies an order from the order stream
+func (order *TrackedOrder) update(update *models.Order) {
+	order.mu.Lock()
+	callbacks := order.accept(update.ID)
+	callbacks = append(callbacks, order.match(decimal.Money(update.Sm), decimal.Money(update.Sr), decimal.Price(update.Avp))...)
+	if streaming.OrderStatus(update.Status) == streaming.OrderStatusEnum.ExecutionComplete {
+		callbacks = append(callbacks, order.complete()...)
+	}
+	order.mu.Unlock()
+
+	run(order, callbacks)
+}
+
+func run(order *TrackedOrder, callbacks []func(*TrackedOrder)) {
+	for _, fn := range callbacks {
+		fn(order)
+	}
+}
+
+// OrderManager places orders and tracks them through the order stream. Register it as the Orders handler of a
+// streaming.StreamHandler (NewClient does this for Client.Orders) and subscribe to orders to receive updates. After a
+// restart the orders already on the Exchange are rebuilt from the order stream's initial image.
+type OrderManager struct {
+	betting *Betting
+
+	mu        sync.Mutex
+	byRef     map[string]*TrackedOrder
+	byBetID   map[string]*TrackedOrder
+	refPrefix string
+	refCount  int64
+}
+
+// NewOrderManager creates an OrderManager which places orders with the given Betting client.
+func NewOrderManager(betting *Betting) *OrderManager {
+	return &OrderManager{
+		betting:   betting,
+		byRef:     make(map[string]*TrackedOrder),
+		byBetID:   make(map[string]*TrackedOrder),
+		refPrefix: strconv.FormatInt(time.Now().UnixNano(), 36),
+	}
+}
+
+// nextRef generates a CustomerOrderRef unique to this manager, well inside the Exchange's 32 character limit
+func (manager *OrderManager) nextRef() string {
+	manager.refCount++
+	return manager.refPrefix + "-" + strconv.FormatInt(manager.refCount, 36)
+}
+
+// Place submits the instructions and returns a TrackedOrder for each, in the same order. Instructions without a
+// CustomerOrderRef are given one, as is the request if options has no CustomerRef, so that it can be retried safely. The orders are tracked before the request is sent, so stream updates which arrive
+// ahead of the response are not missed. With options.Async the bet ids are filled in from the order stream.
+//
+// Orders only fail when the Exchange rejects them. If the outcome is unknown, an OrderOutcomeUnknownError or an
+// instruction report with a TIMEOUT status, they stay Submitted until the order stream reports them by their
+// CustomerOrderRef.
+func (manager *OrderManager) Place(marketID string, instructions []PlaceInstruction, options PlaceOrdersOptions) ([]*TrackedOrder, error) {
+
+	orders := make([]*TrackedOrder, len(instructions))
+	placeInstructions := make([]PlaceInstruction, len(instructions))
+
+	manager.mu.Lock()
+	if options.CustomerRef == "" {
+		options.CustomerRef = manager.nextRef()
+	}
+	for i, instruction := range instructions {
+		if instruction.CustomerOrderRef == "" {
+			instruction.CustomerOrderRef = manager.nextRef()
+		}
+		placeInstructions[i] = instruction
+
+		order := newTrackedOrder(marketID, int64(instruction.SelectionID), instruction.CustomerOrderRef)
+		order.Instruction = &placeInstructions[i]
+		orders[i] = order
+		manager.byRef[order.CustomerOrderRef] = order
+	}
+	manager.mu.Unlock()
+
+	report, err := manager.betting.PlaceOrdersWithOptions(marketID, placeInstructions, options)
+	if err != nil {
+		var unknown *OrderOutcomeUnknownError
+		if !errors.As(err, &unknown) {
+			for _, order := range orders {
+				order.fail(err)
+			}
+		}
+		return orders, err
+	}
+
+	reported := make(map[*TrackedOrder]bool)
+	for i, instructionReport := range report.InstructionReports {
+		order := manager.Order(instructionReport.Instruction.CustomerOrderRef)
+		if order == nil && i < len(orders) {
+			order = orders[i]
+		}
+		if order == nil {
+			continue
+		}
+		reported[order] = true
+
+		// An instruction with a TIMEOUT status may yet be placed, it is left to the order stream
+		switch {
+		case instructionReport.Status == InstructionReportStatusEnum.Failure:
+			order.fail(&PlacementError{ErrorCode: instructionReport.ErrorCode})
+		case instructionReport.BetID != "":
+			manager.mu.Lock()
+			manager.byBetID[instructionReport.BetID] = order
+			manager.mu.Unlock()
+			order.placed(instructionReport)
+		}
+	}
+
+	if report.Status == ExecutionReportStatusEnum.Failure {
+		for _, order := range orders {
+			if !reported[order] {
+				order.fail(&PlacementError{ErrorCode: string(report.ErrorCode)})
+			}
+		}
+	}
+
+	return orders, nil
+}
+
+// Order returns the order with the given CustomerOrderRef, or nil
+func (manager *OrderManager) Order(customerOrderRef string) *TrackedOrder {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	return manager.byRef[customerOrderRef]
+}
+
+// OrderByBetID returns the order with the given bet id, or nil
+func (manager *OrderManager) OrderByBetID(betID string) *TrackedOrder {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	return manager.byBetID[betID]
+}
+
+// Orders returns every tracked order
+func (manager *OrderManager) Orders() []*TrackedOrder {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	seen := make(map[*TrackedOrder]bool)
+	orders := []*TrackedOrder{}
+	for _, order := range manager.byRef {
+		seen[order] = true
+		orders = append(orders, order)
+	}
+	for _, order := range manager.byBetID {
+		if !seen[order] {
+			orders = append(orders, order)
+		}
+	}
+	return orders
+}
+
+// Prune stops tracking the orders which completed or failed more than age ago, returning how many were removed.
+// Long-running strategies should call it periodically, as the manager otherwise keeps every order it has seen. An
+// order which appears in the order stream again after it is pruned is tracked as a new order.
+func (manager *OrderManager) Prune(age time.Duration) int {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	cutoff := time.Now().Add(-age)
+	pruned := make(map[*TrackedOrder]bool)
+	prune := func(orders map[string]*TrackedOrder) {
+		for key, order := range orders {
+			order.mu.Lock()
+			expired := !order.completedAt.IsZero() && !order.completedAt.After(cutoff)
+			order.mu.Unlock()
+			if expired {
+				delete(orders, key)
+				pruned[order] = true
+			}
+		}
+	}
+	prune(manager.byRef)
+	prune(manager.byBetID)
+
+	return len(pruned)
+}
+
+// find returns the tracked order for a stream update, creating one for orders placed elsewhere or before a restart
+func (manager *OrderManager) find(marketID string, selectionID int64, update *models.Order) *TrackedOrder {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	order, ok := manager.byBetID[update.ID]
+	if !ok && update.Rfo != "" {
+		order, ok = manager.byRef[update.Rfo]
+	}
+	if !ok {
+		order = newTrackedOrder(marketID, selectionID, update.Rfo)
+		if update.Rfo != "" {
+			manager.byRef[update.Rfo] = order
+		}
+	}
+	manager.byBetID[update.ID] = order
+
+	return order
+}
+
+func (manager *OrderManager) onChangeMessage(changeMessage models.OrderChangeMessage) {
+	for _, marketChange := range changeMessage.Oc {
+		for _, runnerChange := range marketChange.Orc {
+			for _, update := range runnerChange.Uo {
+				manager.find(marketChange.ID, runnerChange.ID, update).update(update)
+			}
+		}
+	}
+}
+
+func (manager *OrderManager) OnSubscribe(changeMessage models.OrderChangeMessage) {
+	manager.onChangeMessage(changeMessage)
+}
+
+func (manager *OrderManager) OnResubscribe(changeMessage models.OrderChangeMessage) {
+	manager.onChangeMessage(changeMessage)
+}
+
+func (manager *OrderManager) OnHeartbeat(changeMessage models.OrderChangeMessage) {
+}
+
+func (manager *OrderManager) OnUpdate(changeMessage models.OrderChangeMessage) {
+	manager.onChangeMessage(changeMessage)
+}
@@ -0,0 +1,217 @@
+package gofair
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/decimal"
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+func orderUpdate(marketID string, selectionID int64, orders ...*models.Order) models.OrderChangeMessage {
+	return models.OrderChangeMessage{
+		Oc: []*models.OrderMarketChange{
+			{ID: marketID, Orc: []*models.OrderRunnerChange{{ID: selectionID, Uo: orders}}},
+		},
+	}
+}
+
+func isClosed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestOrderManagerPlace(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("placeOrders", PlaceExecutionReport{
+		MarketID: "1.23",
+		Status:   ExecutionReportStatusEnum.Success,
+		InstructionReports: []PlaceInstructionReport{
+			{Status: InstructionReportStatusEnum.Success, OrderStatus: OrderStatusEnum.Executable, BetID: "100", SizeMatched: 0.5},
+		},
+	})
+	instructions := []PlaceInstruction{
+		{OrderType: OrderTypeEnum.Limit, SelectionID: 1, Side: SideEnum.Back, LimitOrder: LimitOrder{Price: 2, Size: 2}},
+	}
+	var accepted, matched int
+
+	// Act
+	orders, err := client.Orders.Place("1.23", instructions, PlaceOrdersOptions{})
+	order := orders[0]
+	order.OnAccepted(func(*TrackedOrder) { accepted++ })
+	order.OnMatched(func(*TrackedOrder) { matched++ })
+	client.Orders.OnUpdate(orderUpdate("1.23", 1, &models.Order{ID: "100", Sm: 2, Sr: 0, Avp: 2, Status: "EC"}))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotEmpty(t, order.CustomerOrderRef)
+	assert.LessOrEqual(t, len(order.CustomerOrderRef), 32)
+	assert.Equal(t, "100", order.BetID())
+	assert.Equal(t, OrderStateEnum.Complete, order.State())
+	assert.Equal(t, decimal.Money(2), order.SizeMatched())
+	assert.Equal(t, 1, accepted)
+	assert.Equal(t, 1, matched)
+	assert.True(t, isClosed(order.Matched()))
+	assert.True(t, isClosed(order.Done()))
+	assert.Same(t, order, client.Orders.OrderByBetID("100"))
+	assert.Same(t, order, client.Orders.Order(order.CustomerOrderRef))
+}
+
+func TestOrderManagerPlaceFailure(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("placeOrders", PlaceExecutionReport{
+		MarketID:  "1.23",
+		Status:    ExecutionReportStatusEnum.Failure,
+		ErrorCode: ExecutionReportErrorCodeEnum.MarketSuspended,
+	})
+	instructions := []PlaceInstruction{
+		{OrderType: OrderTypeEnum.Limit, SelectionID: 1, Side: SideEnum.Back, LimitOrder: LimitOrder{Price: 2, Size: 2}},
+	}
+	var done bool
+
+	// Act
+	orders, err := client.Orders.Place("1.23", instructions, PlaceOrdersOptions{})
+	orders[0].OnDone(func(*TrackedOrder) { done = true })
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, OrderStateEnum.Failed, orders[0].State())
+	assert.EqualError(t, orders[0].Err(), "Order placement failed: MARKET_SUSPENDED")
+	assert.True(t, done)
+	assert.False(t, isClosed(orders[0].Accepted()))
+}
+
+func TestOrderManagerAsync(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("placeOrders", PlaceExecutionReport{
+		MarketID: "1.23",
+		Status:   ExecutionReportStatusEnum.Success,
+		InstructionReports: []PlaceInstructionReport{
+			{Status: InstructionReportStatusEnum.Success, OrderStatus: OrderStatusEnum.Pending},
+		},
+	})
+	instructions := []PlaceInstruction{
+		{OrderType: OrderTypeEnum.Limit, SelectionID: 1, Side: SideEnum.Lay, LimitOrder: LimitOrder{Price: 3, Size: 5}, CustomerOrderRef: "my-ref"},
+	}
+
+	// Act
+	orders, err := client.Orders.Place("1.23", instructions, PlaceOrdersOptions{Async: true})
+	pending := orders[0].State()
+	client.Orders.OnUpdate(orderUpdate("1.23", 1, &models.Order{ID: "200", Rfo: "my-ref", Sr: 5, Status: "E"}))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, OrderStateEnum.Submitted, pending)
+	assert.Equal(t, OrderStateEnum.Accepted, orders[0].State())
+	assert.Equal(t, "200", orders[0].BetID())
+	assert.True(t, isClosed(orders[0].Accepted()))
+	assert.False(t, isClosed(orders[0].Done()))
+}
+
+func TestOrderManagerRebuildFromImage(t *testing.T) {
+	// Arrange
+	manager := NewOrderManager(nil)
+	image := orderUpdate("1.23", 7,
+		&models.Order{ID: "1", Rfo: "ref-1", Sm: 1, Sr: 1, Avp: 4, Status: "E"},
+		&models.Order{ID: "2", Sm: 3, Status: "EC"},
+	)
+
+	// Act
+	manager.OnSubscribe(image)
+
+	// Assert
+	assert.Len(t, manager.Orders(), 2)
+	first := manager.Order("ref-1")
+	assert.Same(t, first, manager.OrderByBetID("1"))
+	assert.Equal(t, "1.23", first.MarketID)
+	assert.Equal(t, int64(7), first.SelectionID)
+	assert.Equal(t, OrderStateEnum.Accepted, first.State())
+	assert.Equal(t, decimal.Price(4), first.AveragePriceMatched())
+	assert.Equal(t, OrderStateEnum.Complete, manager.OrderByBetID("2").State())
+}
+
+func TestOrderManagerPlaceOutcomeUnknown(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	client.Retry.MaxAttempts = 1
+	server.HandleBetting("placeOrders", PlaceExecutionReport{MarketID: "1.23", Status: ExecutionReportStatusEnum.Timeout})
+	server.HandleBetting("listCurrentOrders", CurrentOrderSummaryReport{})
+	instructions := []PlaceInstruction{
+		{OrderType: OrderTypeEnum.Limit, SelectionID: 1, Side: SideEnum.Back, LimitOrder: LimitOrder{Price: 2, Size: 2}, CustomerOrderRef: "my-ref"},
+	}
+
+	// Act
+	orders, err := client.Orders.Place("1.23", instructions, PlaceOrdersOptions{})
+	unknown := orders[0].State()
+	client.Orders.OnUpdate(orderUpdate("1.23", 1, &models.Order{ID: "300", Rfo: "my-ref", Sr: 2, Status: "E"}))
+
+	// Assert
+	var outcome *OrderOutcomeUnknownError
+	assert.ErrorAs(t, err, &outcome)
+	assert.Equal(t, OrderStateEnum.Submitted, unknown)
+	assert.Equal(t, OrderStateEnum.Accepted, orders[0].State())
+	assert.Equal(t, "300", orders[0].BetID())
+	assert.NoError(t, orders[0].Err())
+}
+
+func TestOrderManagerPlacedRemainingSize(t *testing.T) {
+	cases := []struct {
+		name        string
+		instruction PlaceInstruction
+		matched     bool
+	}{
+		{"limit order fully matched", PlaceInstruction{OrderType: OrderTypeEnum.Limit, LimitOrder: LimitOrder{Price: 2, Size: 2}}, true},
+		{"limit order partly matched", PlaceInstruction{OrderType: OrderTypeEnum.Limit, LimitOrder: LimitOrder{Price: 2, Size: 4}}, false},
+		{"bet target order", PlaceInstruction{OrderType: OrderTypeEnum.Limit, LimitOrder: LimitOrder{Price: 2, BetTargetType: "PAYOUT", BetTargetSize: 10}}, false},
+		{"market on close order", PlaceInstruction{OrderType: OrderTypeEnum.MarketOnClose}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			instruction := tc.instruction
+			order := newTrackedOrder("1.23", 1, "ref")
+			order.Instruction = &instruction
+
+			// Act
+			order.placed(PlaceInstructionReport{Status: InstructionReportStatusEnum.Success, OrderStatus: OrderStatusEnum.Executable, BetID: "1", SizeMatched: 2, AveragePriceMatched: 2})
+
+			// Assert
+			assert.Equal(t, decimal.Money(2), order.SizeMatched())
+			assert.Equal(t, tc.matched, isClosed(order.Matched()))
+		})
+	}
+}
+
+func TestOrderManagerPrune(t *testing.T) {
+	// Arrange
+	manager := NewOrderManager(nil)
+	manager.OnUpdate(orderUpdate("1.23", 1,
+		&models.Order{ID: "1", Rfo: "ref-1", Sm: 2, Status: "EC"},
+		&models.Order{ID: "2", Rfo: "ref-2", Sr: 2, Status: "E"},
+	))
+
+	// Act
+	kept := manager.Prune(time.Hour)
+	pruned := manager.Prune(0)
+
+	// Assert
+	assert.Equal(t, 0, kept)
+	assert.Equal(t, 1, pruned)
+	assert.Nil(t, manager.Order("ref-1"))
+	assert.Nil(t, manager.OrderByBetID("1"))
+	assert.NotNil(t, manager.Order("ref-2"))
+	assert.Len(t, manager.Orders(), 1)
+}
@@ -0,0 +1,37 @@
+package price
+
+import "strconv"
+
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
+
+// InvalidPriceError is returned when a price does not fall on a tick of the ladder
+type InvalidPriceError struct {
+	Price  float64
+	Ladder LadderType
+}
+
+func (err *InvalidPriceError) Error() string {
+	return "Price " + formatPrice(err.Price) + " is not a valid " + string(err.Ladder) + " price"
+}
+
+// OutOfRangeError is returned when a price, or a tick offset from it, lies outside the ladder
+type OutOfRangeError struct {
+	Price  float64
+	Ladder LadderType
+}
+
+func (err *OutOfRangeError) Error() string {
+	return "Price " + formatPrice(err.Price) + " is outside the " + string(err.Ladder) + " ladder"
+}
+
+// LadderError is returned when a ladder cannot be built from the supplied definition
+type LadderError struct {
+	Ladder LadderType
+	Reason string
+}
+
+func (err *LadderError) Error() string {
+	return "Invalid " + string(err.Ladder) + " ladder: " + err.Reason
+}
@@ -0,0 +1,297 @@
+// Package price implements the Betfair Exchange price ladders and tick arithmetic.
+//
+// Prices are handled internally in hundredths so that tick boundaries are exact. Every function accepts and returns
+// float64 prices as used throughout the API.
+package price
+
+import (
+	"math"
+
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+type LadderType string
+
+// LadderTypeEnum describes the price ladder used by a market, as in models.PriceLadderDefinition.
+var LadderTypeEnum = struct {
+	Classic, Finest, LineRange LadderType
+}{
+	Classic:   "CLASSIC",
+	Finest:    "FINEST",
+	LineRange: "LINE_RANGE",
+}
+
+// Band is a section of a ladder in which prices move in a fixed Increment. Min is inclusive and Max is the Min of the
+// following band, or the top of the ladder for the last band.
+type Band struct {
+	Min       float64
+	Max       float64
+	Increment float64
+}
+
+// ClassicBands are the price increments of the CLASSIC ladder
+var ClassicBands = []Band{
+	{1.01, 2, 0.01},
+	{2, 3, 0.02},
+	{3, 4, 0.05},
+	{4, 6, 0.1},
+	{6, 10, 0.2},
+	{10, 20, 0.5},
+	{20, 30, 1},
+	{30, 50, 2},
+	{50, 100, 5},
+	{100, 1000, 10},
+}
+
+// FinestBands are the price increments of the FINEST ladder
+var FinestBands = []Band{
+	{1.01, 1000, 0.01},
+}
+
+// tolerance absorbs the floating point error of prices converted to hundredths
+const tolerance = 1e-6
+
+type band struct {
+	min, max, increment int64
+	// index of the band's first tick
+	first int
+}
+
+func (b band) ticks() int {
+	return int((b.max - b.min) / b.increment)
+}
+
+// Ladder is an ordered set of valid prices
+type Ladder struct {
+	Type  LadderType
+	bands []band
+	ticks int
+}
+
+var (
+	// Classic is the ladder used by most markets
+	Classic = mustLadder(LadderTypeEnum.Classic, ClassicBands)
+	// Finest is the ladder of every price from 1.01 to 1000 in increments of 0.01
+	Finest = mustLadder(LadderTypeEnum.Finest, FinestBands)
+)
+
+func mustLadder(ladderType LadderType, bands []Band) *Ladder {
+	ladder, err := NewLadder(ladderType, bands)
+	if err != nil {
+		panic(err)
+	}
+	return ladder
+}
+
+// NewLadder builds a Ladder from contiguous bands. Band limits and increments must be whole hundredths.
+func NewLadder(ladderType LadderType, bands []Band) (*Ladder, error) {
+
+	if len(bands) == 0 {
+		return nil, &LadderError{Ladder: ladderType, Reason: "no bands"}
+	}
+
+	ladder := &Ladder{Type: ladderType}
+
+	for i, b := range bands {
+		min, minExact := toUnits(b.Min)
+		max, maxExact := toUnits(b.Max)
+		increment, incrementExact := toUnits(b.Increment)
+
+		if !minExact || !maxExact || !incrementExact {
+			return nil, &LadderError{Ladder: ladderType, Reason: "band limits must be whole hundredths"}
+		}
+		if increment <= 0 || max <= min || (max-min)%increment != 0 {
+			return nil, &LadderError{Ladder: ladderType, Reason: "band " + formatPrice(b.Min) + "-" + formatPrice(b.Max) + " is not a whole number of increments"}
+		}
+		if i > 0 && ladder.bands[i-1].max != min {
+			return nil, &LadderError{Ladder: ladderType, Reason: "bands are not contiguous"}
+		}
+
+		ladder.bands = append(ladder.bands, band{min: min, max: max, increment: increment, first: ladder.ticks})
+		ladder.ticks += int((max - min) / increment)
+	}
+
+	// The top of the last band is itself a tick
+	ladder.ticks++
+
+	return ladder, nil
+}
+
+// NewLineRangeLadder builds the LINE_RANGE ladder of a line market from its lineMinUnit, lineMaxUnit and lineInterval
+func NewLineRangeLadder(minUnit float64, maxUnit float64, interval float64) (*Ladder, error) {
+	return NewLadder(LadderTypeEnum.LineRange, []Band{{minUnit, maxUnit, interval}})
+}
+
+// ForType returns the shared CLASSIC or FINEST ladder. LINE_RANGE ladders depend on the market and must be built with
+// NewLineRangeLadder. An empty type is treated as CLASSIC, the Exchange default.
+func ForType(ladderType LadderType) (*Ladder, error) {
+	switch ladderType {
+	case LadderTypeEnum.Classic, "":
+		return Classic, nil
+	case LadderTypeEnum.Finest:
+		return Finest, nil
+	}
+	return nil, &LadderError{Ladder: ladderType, Reason: "ladder requires the market's line range"}
+}
+
+// ForDefinition returns the ladder of a market from its stream MarketDefinition
+func ForDefinition(definition *models.MarketDefinition) (*Ladder, error) {
+	if definition == nil || definition.PriceLadderDefinition == nil {
+		return Classic, nil
+	}
+	ladderType := LadderType(definition.PriceLadderDefinition.Type)
+	if ladderType == LadderTypeEnum.LineRange {
+		return NewLineRangeLadder(definition.LineMinUnit, definition.LineMaxUnit, definition.LineInterval)
+	}
+	return ForType(ladderType)
+}
+
+func toUnits(price float64) (int64, bool) {
+	units := math.Round(price * 100)
+	return int64(units), math.Abs(price*100-units) < tolerance
+}
+
+func fromUnits(units int64) float64 {
+	return float64(units) / 100
+}
+
+// Min returns the lowest price on the ladder
+func (ladder *Ladder) Min() float64 {
+	return fromUnits(ladder.bands[0].min)
+}
+
+// Max returns the highest price on the ladder
+func (ladder *Ladder) Max() float64 {
+	return fromUnits(ladder.bands[len(ladder.bands)-1].max)
+}
+
+// Len returns the number of prices on the ladder
+func (ladder *Ladder) Len() int {
+	return ladder.ticks
+}
+
+// Tick returns the price at index i, where 0 is the lowest price on the ladder
+func (ladder *Ladder) Tick(i int) float64 {
+	if i < 0 || i >= ladder.ticks {
+		panic("price: tick index out of range")
+	}
+	b := ladder.bands[len(ladder.bands)-1]
+	for _, candidate := range ladder.bands {
+		if i < candidate.first+candidate.ticks() {
+			b = candidate
+			break
+		}
+	}
+	return fromUnits(b.min + int64(i-b.first)*b.increment)
+}
+
+// Prices returns every price on the ladder in ascending order
+func (ladder *Ladder) Prices() []float64 {
+	prices := make([]float64, ladder.ticks)
+	for _, b := range ladder.bands {
+		for i := 0; i < b.ticks(); i++ {
+			prices[b.first+i] = fromUnits(b.min + int64(i)*b.increment)
+		}
+	}
+	prices[ladder.ticks-1] = ladder.Max()
+	return prices
+}
+
+// find returns the band containing units, the last band also containing its upper limit
+func (ladder *Ladder) find(units int64) (band, bool) {
+	for i, b := range ladder.bands {
+		if units >= b.min && (units < b.max || (i == len(ladder.bands)-1 && units == b.max)) {
+			return b, true
+		}
+	}
+	return band{}, false
+}
+
+// Index returns the position of a valid price on the ladder, where 0 is the lowest price
+func (ladder *Ladder) Index(price float64) (int, error) {
+	units, exact := toUnits(price)
+	b, ok := ladder.find(units)
+	if !ok {
+		return 0, &OutOfRangeError{Price: price, Ladder: ladder.Type}
+	}
+	if !exact || (units-b.min)%b.increment != 0 {
+		return 0, &InvalidPriceError{Price: price, Ladder: ladder.Type}
+	}
+	return b.first + int((units-b.min)/b.increment), nil
+}
+
+// IsValid reports whether the price falls exactly on a tick of the ladder
+func (ladder *Ladder) IsValid(price float64) bool {
+	_, err := ladder.Index(price)
+	return err == nil
+}
+
+// RoundDown returns the highest valid price less than or equal to price
+func (ladder *Ladder) RoundDown(price float64) (float64, error) {
+	units := int64(math.Floor(price*100 + tolerance))
+	b, ok := ladder.find(units)
+	if !ok {
+		if units > ladder.bands[len(ladder.bands)-1].max {
+			return ladder.Max(), nil
+		}
+		return 0, &OutOfRangeError{Price: price, Ladder: ladder.Type}
+	}
+	return fromUnits(b.min + (units-b.min)/b.increment*b.increment), nil
+}
+
+// RoundUp returns the lowest valid price greater than or equal to price
+func (ladder *Ladder) RoundUp(price float64) (float64, error) {
+	units := int64(math.Ceil(price*100 - tolerance))
+	b, ok := ladder.find(units)
+	if !ok {
+		if units < ladder.bands[0].min {
+			return ladder.Min(), nil
+		}
+		return 0, &OutOfRangeError{Price: price, Ladder: ladder.Type}
+	}
+	steps := (units - b.min + b.increment - 1) / b.increment
+	return fromUnits(b.min + steps*b.increment), nil
+}
+
+// Round returns the valid price nearest to price, rounding up when it lies exactly between two ticks
+func (ladder *Ladder) Round(price float64) (float64, error) {
+	down, downErr := ladder.RoundDown(price)
+	up, upErr := ladder.RoundUp(price)
+	if downErr != nil {
+		return up, upErr
+	}
+	if upErr != nil {
+		return down, nil
+	}
+	if (price-down)*100 < (up-price)*100-tolerance {
+		return down, nil
+	}
+	return up, nil
+}
+
+// AddTicks moves a valid price the given number of ticks up (positive) or down (negative) the ladder. Moving up is
+// better for a back bet and moving down is better for a lay bet.
+func (ladder *Ladder) AddTicks(price float64, ticks int) (float64, error) {
+	index, err := ladder.Index(price)
+	if err != nil {
+		return 0, err
+	}
+	index += ticks
+	if index < 0 || index >= ladder.ticks {
+		return 0, &OutOfRangeError{Price: price, Ladder: ladder.Type}
+	}
+	return ladder.Tick(index), nil
+}
+
+// TicksBetween returns the number of ticks from one valid price to another, negative if to is below from
+func (ladder *Ladder) TicksBetween(from float64, to float64) (int, error) {
+	fromIndex, err := ladder.Index(from)
+	if err != nil {
+		return 0, err
+	}
+	toIndex, err := ladder.Index(to)
+	if err != nil {
+		return 0, err
+	}
+	return toIndex - fromIndex, nil
+}
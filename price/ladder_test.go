@@ -0,0 +1,206 @@
+package price
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+func TestLadderLengths(t *testing.T) {
+	// Arrange
+	line, err := NewLineRangeLadder(-10.5, 10.5, 0.5)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 350, Classic.Len())
+	assert.Equal(t, 99900, Finest.Len())
+	assert.Equal(t, 43, line.Len())
+	assert.Equal(t, 1.01, Classic.Min())
+	assert.Equal(t, 1000.0, Classic.Max())
+	assert.Equal(t, -10.5, line.Min())
+}
+
+func TestLadderPrices(t *testing.T) {
+	// Act
+	prices := Classic.Prices()
+
+	// Assert
+	assert.Len(t, prices, Classic.Len())
+	assert.Equal(t, []float64{1.01, 1.02, 1.03}, prices[:3])
+	assert.Equal(t, []float64{1.99, 2, 2.02}, prices[98:101])
+	assert.Equal(t, []float64{980, 990, 1000}, prices[347:])
+	for i, price := range prices {
+		assert.Equal(t, price, Classic.Tick(i))
+		index, err := Classic.Index(price)
+		assert.NoError(t, err)
+		assert.Equal(t, i, index)
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	// Arrange
+	testCases := []struct {
+		ladder *Ladder
+		price  float64
+		valid  bool
+	}{
+		{Classic, 1.01, true},
+		{Classic, 1.0, false},
+		{Classic, 1.015, false},
+		{Classic, 2.01, false},
+		{Classic, 2.02, true},
+		{Classic, 3.05, true},
+		{Classic, 3.02, false},
+		{Classic, 4.1, true},
+		{Classic, 6.1, false},
+		{Classic, 19.5, true},
+		{Classic, 32, true},
+		{Classic, 33, false},
+		{Classic, 95, true},
+		{Classic, 110, true},
+		{Classic, 115, false},
+		{Classic, 1000, true},
+		{Classic, 1010, false},
+		{Finest, 2.01, true},
+		{Finest, 115.37, true},
+		{Finest, 1000.01, false},
+	}
+
+	for _, testCase := range testCases {
+		// Act
+		valid := testCase.ladder.IsValid(testCase.price)
+
+		// Assert
+		assert.Equal(t, testCase.valid, valid, "%s %v", testCase.ladder.Type, testCase.price)
+	}
+}
+
+func TestRounding(t *testing.T) {
+	// Arrange
+	testCases := []struct {
+		price float64
+		down  float64
+		up    float64
+		round float64
+	}{
+		{2.01, 2.0, 2.02, 2.02},
+		{2.0, 2.0, 2.0, 2.0},
+		{3.11, 3.1, 3.15, 3.1},
+		{3.13, 3.1, 3.15, 3.15},
+		{5.55, 5.5, 5.6, 5.6},
+		{1.999, 1.99, 2.0, 2.0},
+		{0.5, 0, 1.01, 1.01},
+		{1500, 1000, 0, 1000},
+	}
+
+	for _, testCase := range testCases {
+		// Act
+		down, downErr := Classic.RoundDown(testCase.price)
+		up, upErr := Classic.RoundUp(testCase.price)
+		round, roundErr := Classic.Round(testCase.price)
+
+		// Assert
+		if testCase.down == 0 {
+			assert.IsType(t, &OutOfRangeError{}, downErr)
+		} else {
+			assert.Equal(t, testCase.down, down, "down %v", testCase.price)
+		}
+		if testCase.up == 0 {
+			assert.IsType(t, &OutOfRangeError{}, upErr)
+		} else {
+			assert.Equal(t, testCase.up, up, "up %v", testCase.price)
+		}
+		assert.NoError(t, roundErr)
+		assert.Equal(t, testCase.round, round, "round %v", testCase.price)
+	}
+}
+
+func TestAddTicks(t *testing.T) {
+	// Arrange
+	testCases := []struct {
+		price    float64
+		ticks    int
+		expected float64
+		err      error
+	}{
+		{1.98, 3, 2.02, nil},
+		{2.02, -3, 1.98, nil},
+		{10, 1, 10.5, nil},
+		{10, -1, 9.8, nil},
+		{1.01, -1, 0, &OutOfRangeError{Price: 1.01, Ladder: LadderTypeEnum.Classic}},
+		{990, 2, 0, &OutOfRangeError{Price: 990, Ladder: LadderTypeEnum.Classic}},
+		{2.01, 1, 0, &InvalidPriceError{Price: 2.01, Ladder: LadderTypeEnum.Classic}},
+	}
+
+	for _, testCase := range testCases {
+		// Act
+		price, err := Classic.AddTicks(testCase.price, testCase.ticks)
+
+		// Assert
+		assert.Equal(t, testCase.err, err)
+		assert.Equal(t, testCase.expected, price)
+	}
+}
+
+func TestTicksBetween(t *testing.T) {
+	// Act
+	up, upErr := Classic.TicksBetween(1.5, 2.5)
+	down, downErr := Classic.TicksBetween(4, 3.9)
+	_, invalidErr := Classic.TicksBetween(1.5, 2.01)
+
+	// Assert
+	assert.NoError(t, upErr)
+	assert.Equal(t, 75, up)
+	assert.NoError(t, downErr)
+	assert.Equal(t, -2, down)
+	assert.IsType(t, &InvalidPriceError{}, invalidErr)
+}
+
+func TestLineRangeLadder(t *testing.T) {
+	// Arrange
+	ladder, err := NewLineRangeLadder(10, 20, 0.5)
+	assert.NoError(t, err)
+
+	// Act
+	next, _ := ladder.AddTicks(10.5, 4)
+	rounded, _ := ladder.Round(12.2)
+
+	// Assert
+	assert.Equal(t, LadderTypeEnum.LineRange, ladder.Type)
+	assert.True(t, ladder.IsValid(20))
+	assert.False(t, ladder.IsValid(10.25))
+	assert.Equal(t, 12.5, next)
+	assert.Equal(t, 12.0, rounded)
+}
+
+func TestNewLadderErrors(t *testing.T) {
+	// Act
+	_, uneven := NewLineRangeLadder(10, 20, 0.3)
+	_, gap := NewLadder(LadderTypeEnum.Classic, []Band{{1.01, 2, 0.01}, {3, 4, 0.05}})
+	_, lineRange := ForType(LadderTypeEnum.LineRange)
+
+	// Assert
+	assert.IsType(t, &LadderError{}, uneven)
+	assert.IsType(t, &LadderError{}, gap)
+	assert.IsType(t, &LadderError{}, lineRange)
+}
+
+func TestForDefinition(t *testing.T) {
+	// Act
+	classic, _ := ForDefinition(nil)
+	finest, _ := ForDefinition(&models.MarketDefinition{PriceLadderDefinition: &models.PriceLadderDefinition{Type: "FINEST"}})
+	line, err := ForDefinition(&models.MarketDefinition{
+		PriceLadderDefinition: &models.PriceLadderDefinition{Type: "LINE_RANGE"},
+		LineMinUnit:           0.5,
+		LineMaxUnit:           99.5,
+		LineInterval:          1,
+	})
+
+	// Assert
+	assert.Equal(t, Classic, classic)
+	assert.Equal(t, Finest, finest)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, line.Len())
+}
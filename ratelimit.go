@@ -0,0 +1,265 @@
+package gofair
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OperationClass groups the API operations which share a request rate limit.
+type OperationClass string
+
+// OperationClassEnum lists the classes used by RateLimiter.
+var OperationClassEnum = struct {
+	Login       OperationClass
+	Data        OperationClass
+	Transaction OperationClass
+	Account     OperationClass
+}{
+	Login:       "LOGIN",
+	Data:        "DATA",
+	Transaction: "TRANSACTION",
+	Account:     "ACCOUNT",
+}
+
+// TransactionWindow is the rolling period over which the Exchange counts transactions for charging
+const TransactionWindow = time.Hour
+
+// Rate allows at most Requests calls in any period of length Per. The zero Rate is unlimited.
+type Rate struct {
+	Requests int
+	Per      time.Duration
+}
+
+// RateLimits configures a RateLimiter.
+type RateLimits struct {
+	Login       Rate
+	Data        Rate
+	Transaction Rate
+	Account     Rate
+
+	// TransactionBudget is the number of placeOrders, cancelOrders and replaceOrders instructions allowed in any
+	// TransactionWindow, zero for no budget. Transactions are counted either way.
+	TransactionBudget int
+
+	// QueueTransactions makes orders which would exceed TransactionBudget wait until enough earlier transactions have
+	// left the window, rather than failing with a TransactionBudgetError
+	QueueTransactions bool
+}
+
+// DefaultRateLimits are used by NewClient. They only keep logins within the Exchange's documented limit of 100 per
+// minute; data, transaction and account requests are not throttled and there is no TransactionBudget, so a Client
+// behaves as it did before the RateLimiter was added. Set the other Rates on Client.Limiter.Limits to opt in to
+// spreading those requests out, at the cost of delaying bursts.
+var DefaultRateLimits = RateLimits{
+	Login: Rate{Requests: 100, Per: time.Minute},
+}
+
+// TransactionBudgetError is returned when placing orders would take the transactions in the last TransactionWindow
+// over the TransactionBudget.
+type TransactionBudgetError struct {
+	Used      int
+	Requested int
+	Budget    int
+	// Available is when enough earlier transactions will have left the window for the request to fit, zero if it
+	// never can
+	Available time.Time
+}
+
+func (err *TransactionBudgetError) Error() string {
+	return fmt.Sprintf("Transaction budget exceeded: %d used and %d requested of %d per hour", err.Used, err.Requested, err.Budget)
+}
+
+// RateUsage is a snapshot of a RateLimiter.
+type RateUsage struct {
+	// Requests is the number of requests of each class made within its Rate period
+	Requests map[OperationClass]int
+
+	// Transactions is the number of transactions in the last TransactionWindow
+	Transactions int
+
+	// Remaining is the number of transactions left in the TransactionBudget, or -1 if there is no budget
+	Remaining int
+
+	// NextExpiry is when the oldest transaction in the window leaves it, zero if there are none
+	NextExpiry time.Time
+}
+
+type transaction struct {
+	at    time.Time
+	count int
+}
+
+// RateLimiter throttles the requests made by a Client according to the Rate of each OperationClass and counts the
+// transactions made in a rolling TransactionWindow.
+type RateLimiter struct {
+	Limits RateLimits
+
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	mu           sync.Mutex
+	requests     map[OperationClass][]time.Time
+	transactions []transaction
+}
+
+// NewRateLimiter creates a RateLimiter enforcing limits.
+func NewRateLimiter(limits RateLimits) *RateLimiter {
+	return &RateLimiter{
+		Limits:   limits,
+		now:      time.Now,
+		sleep:    time.Sleep,
+		requests: make(map[OperationClass][]time.Time),
+	}
+}
+
+// classify returns the OperationClass of a request url to one of endpoints, or an empty class for operations which are
+// not limited
+func classify(endpoints EndpointSet, url string) OperationClass {
+	if strings.HasPrefix(url, endpoints.Account) {
+		return OperationClassEnum.Account
+	}
+	switch operation(url) {
+	case "certlogin", "login":
+		return OperationClassEnum.Login
+	case "keepAlive", "logout":
+		return ""
+	case "placeOrders", "cancelOrders", "replaceOrders", "updateOrders":
+		return OperationClassEnum.Transaction
+	}
+	return OperationClassEnum.Data
+}
+
+func (limiter *RateLimiter) rate(class OperationClass) Rate {
+	switch class {
+	case OperationClassEnum.Login:
+		return limiter.Limits.Login
+	case OperationClassEnum.Data:
+		return limiter.Limits.Data
+	case OperationClassEnum.Transaction:
+		return limiter.Limits.Transaction
+	case OperationClassEnum.Account:
+		return limiter.Limits.Account
+	}
+	return Rate{}
+}
+
+// Wait blocks until a request of the given class is allowed by its Rate and then records it.
+func (limiter *RateLimiter) Wait(class OperationClass) {
+	for {
+		limiter.mu.Lock()
+		rate := limiter.rate(class)
+		now := limiter.now()
+		if rate.Requests <= 0 || rate.Per <= 0 {
+			limiter.mu.Unlock()
+			return
+		}
+
+		requests := limiter.pruneRequests(class, rate, now)
+		if len(requests) < rate.Requests {
+			limiter.requests[class] = append(requests, now)
+			limiter.mu.Unlock()
+			return
+		}
+		delay := requests[0].Add(rate.Per).Sub(now)
+		limiter.mu.Unlock()
+
+		limiter.sleep(delay)
+	}
+}
+
+// Spend records count transactions. Orders which would exceed the TransactionBudget wait for room if
+// QueueTransactions is set and otherwise fail with a TransactionBudgetError. Cancellations are recorded but never
+// refused, so that exposure can always be reduced.
+func (limiter *RateLimiter) Spend(count int, cancellation bool) error {
+	for {
+		limiter.mu.Lock()
+		now := limiter.now()
+		limiter.pruneTransactions(now)
+
+		budget := limiter.Limits.TransactionBudget
+		used := limiter.used()
+		if budget <= 0 || cancellation || used+count <= budget {
+			limiter.transactions = append(limiter.transactions, transaction{at: now, count: count})
+			limiter.mu.Unlock()
+			return nil
+		}
+
+		available := limiter.available(count, budget)
+		queue := limiter.Limits.QueueTransactions
+		limiter.mu.Unlock()
+
+		if !queue || available.IsZero() {
+			return &TransactionBudgetError{Used: used, Requested: count, Budget: budget, Available: available}
+		}
+		limiter.sleep(available.Sub(now))
+	}
+}
+
+// Usage returns the requests and transactions currently counted against the limits.
+func (limiter *RateLimiter) Usage() RateUsage {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	usage := RateUsage{Requests: make(map[OperationClass]int), Remaining: -1}
+	for class := range limiter.requests {
+		usage.Requests[class] = len(limiter.pruneRequests(class, limiter.rate(class), now))
+	}
+
+	limiter.pruneTransactions(now)
+	usage.Transactions = limiter.used()
+	if budget := limiter.Limits.TransactionBudget; budget > 0 {
+		usage.Remaining = max(budget-usage.Transactions, 0)
+	}
+	if len(limiter.transactions) > 0 {
+		usage.NextExpiry = limiter.transactions[0].at.Add(TransactionWindow)
+	}
+	return usage
+}
+
+// pruneRequests drops the requests of a class made before the current Rate period
+func (limiter *RateLimiter) pruneRequests(class OperationClass, rate Rate, now time.Time) []time.Time {
+	requests := limiter.requests[class]
+	i := 0
+	for i < len(requests) && now.Sub(requests[i]) >= rate.Per {
+		i++
+	}
+	requests = requests[i:]
+	limiter.requests[class] = requests
+	return requests
+}
+
+// pruneTransactions drops the transactions made before the current TransactionWindow
+func (limiter *RateLimiter) pruneTransactions(now time.Time) {
+	i := 0
+	for i < len(limiter.transactions) && now.Sub(limiter.transactions[i].at) >= TransactionWindow {
+		i++
+	}
+	limiter.transactions = limiter.transactions[i:]
+}
+
+func (limiter *RateLimiter) used() int {
+	used := 0
+	for _, t := range limiter.transactions {
+		used += t.count
+	}
+	return used
+}
+
+// available returns when enough transactions will have left the window for count more to fit within budget, or zero
+// if count is larger than the budget itself
+func (limiter *RateLimiter) available(count int, budget int) time.Time {
+	if count > budget {
+		return time.Time{}
+	}
+	used := limiter.used()
+	for _, t := range limiter.transactions {
+		used -= t.count
+		if used+count <= budget {
+			return t.at.Add(TransactionWindow)
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,137 @@
+package gofair
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRateLimiter returns a RateLimiter whose clock only advances when it sleeps, recording each sleep
+func newTestRateLimiter(limits RateLimits) (*RateLimiter, *[]time.Duration) {
+	limiter := NewRateLimiter(limits)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	sleeps := []time.Duration{}
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+	}
+	return limiter, &sleeps
+}
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		url      string
+		expected OperationClass
+	}{
+		{createURL(Endpoints.Login, "certlogin"), OperationClassEnum.Login},
+		{createURL(Endpoints.Identity, "keepAlive"), ""},
+		{createURL(Endpoints.Betting, placeOrders), OperationClassEnum.Transaction},
+		{createURL(Endpoints.Betting, cancelOrders), OperationClassEnum.Transaction},
+		{createURL(Endpoints.Betting, listMarketBook), OperationClassEnum.Data},
+		{createURL(Endpoints.Account, "getAccountFunds/"), OperationClassEnum.Account},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(t *testing.T) {
+			// Act
+			class := classify(Endpoints, c.url)
+
+			// Assert
+			assert.Equal(t, c.expected, class)
+		})
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	// Arrange
+	limiter, sleeps := newTestRateLimiter(RateLimits{Data: Rate{Requests: 2, Per: time.Second}})
+
+	// Act
+	for i := 0; i < 5; i++ {
+		limiter.Wait(OperationClassEnum.Data)
+	}
+	limiter.Wait(OperationClassEnum.Login)
+
+	// Assert
+	assert.Equal(t, []time.Duration{time.Second, time.Second}, *sleeps)
+	assert.Equal(t, map[OperationClass]int{OperationClassEnum.Data: 1}, limiter.Usage().Requests)
+}
+
+func TestDefaultRateLimitsOnlyLimitLogins(t *testing.T) {
+	// Arrange
+	limiter, sleeps := newTestRateLimiter(DefaultRateLimits)
+
+	// Act
+	for i := 0; i < 101; i++ {
+		limiter.Wait(OperationClassEnum.Data)
+		limiter.Wait(OperationClassEnum.Transaction)
+		limiter.Wait(OperationClassEnum.Account)
+		limiter.Wait(OperationClassEnum.Login)
+	}
+
+	// Assert
+	assert.Equal(t, []time.Duration{time.Minute}, *sleeps)
+}
+
+func TestRateLimiterRefusesOverBudget(t *testing.T) {
+	// Arrange
+	limiter, sleeps := newTestRateLimiter(RateLimits{TransactionBudget: 10})
+
+	// Act
+	first := limiter.Spend(6, false)
+	second := limiter.Spend(5, false)
+	cancellation := limiter.Spend(5, true)
+	usage := limiter.Usage()
+
+	// Assert
+	assert.NoError(t, first)
+	assert.Equal(t, &TransactionBudgetError{Used: 6, Requested: 5, Budget: 10, Available: limiter.now().Add(TransactionWindow)}, second)
+	assert.NoError(t, cancellation)
+	assert.Empty(t, *sleeps)
+	assert.Equal(t, 11, usage.Transactions)
+	assert.Equal(t, 0, usage.Remaining)
+	assert.Equal(t, limiter.now().Add(TransactionWindow), usage.NextExpiry)
+}
+
+func TestRateLimiterQueuesOverBudget(t *testing.T) {
+	// Arrange
+	limiter, sleeps := newTestRateLimiter(RateLimits{TransactionBudget: 10, QueueTransactions: true})
+	limiter.Spend(4, false)
+	limiter.sleep(10 * time.Minute)
+	limiter.Spend(4, false)
+	*sleeps = nil
+
+	// Act
+	err := limiter.Spend(4, false)
+	tooLarge := limiter.Spend(11, false)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{50 * time.Minute}, *sleeps)
+	assert.Equal(t, 8, limiter.Usage().Transactions)
+	assert.Equal(t, &TransactionBudgetError{Used: 8, Requested: 11, Budget: 10}, tooLarge)
+}
+
+func TestPlaceOrdersOverTransactionBudget(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	client.Limiter.Limits.TransactionBudget = 1
+	server.HandleBetting("placeOrders", PlaceExecutionReport{MarketID: "1.23", Status: "SUCCESS"})
+	instructions := []PlaceInstruction{
+		{OrderType: OrderTypeEnum.Limit, SelectionID: 1, Side: SideEnum.Back, LimitOrder: LimitOrder{Size: 2, Price: 3}},
+		{OrderType: OrderTypeEnum.Limit, SelectionID: 2, Side: SideEnum.Back, LimitOrder: LimitOrder{Size: 2, Price: 3}},
+	}
+
+	// Act
+	_, err := client.Betting.PlaceOrders("1.23", instructions)
+
+	// Assert
+	assert.IsType(t, &TransactionBudgetError{}, err)
+	for _, request := range server.Requests() {
+		assert.NotEqual(t, "/betting/placeOrders/", request.Path)
+	}
+	assert.Equal(t, 0, client.Limiter.Usage().Transactions)
+}
@@ -0,0 +1,246 @@
+package gofair
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RetryPolicy configures how Client retries requests which failed with a transient error. Read-only operations are
+// retried freely; placeOrders only when a CustomerRef is set, so that the Exchange rejects a repeat of a request which
+// did in fact succeed as a DUPLICATE_TRANSACTION. Other transactions are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first, retries are disabled if it is 1 or less
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, each later wait is Multiplier times longer up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomly shortens each wait by up to this fraction, so that clients do not retry in step
+	Jitter float64
+}
+
+// DefaultRetryPolicy is used by NewClient.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// Backoff returns the wait after the given failed attempt, counting from 1
+func (policy RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := math.Max(policy.Multiplier, 1)
+	backoff := float64(policy.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if policy.MaxBackoff > 0 {
+		backoff = math.Min(backoff, float64(policy.MaxBackoff))
+	}
+	if policy.Jitter > 0 {
+		backoff -= backoff * policy.Jitter * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
+
+// APIErrorCodeEnum lists the APINGException error codes returned by the betting and account APIs.
+var APIErrorCodeEnum = struct {
+	TooMuchData, InvalidInputData, InvalidSessionInformation, NoAppKey, NoSession, UnexpectedError, InvalidAppKey,
+	TooManyRequests, ServiceBusy, TimeoutError, RequestSizeExceedsLimit, AccessDenied string
+}{
+	TooMuchData:               "TOO_MUCH_DATA",
+	InvalidInputData:          "INVALID_INPUT_DATA",
+	InvalidSessionInformation: "INVALID_SESSION_INFORMATION",
+	NoAppKey:                  "NO_APP_KEY",
+	NoSession:                 "NO_SESSION",
+	UnexpectedError:           "UNEXPECTED_ERROR",
+	InvalidAppKey:             "INVALID_APP_KEY",
+	TooManyRequests:           "TOO_MANY_REQUESTS",
+	ServiceBusy:               "SERVICE_BUSY",
+	TimeoutError:              "TIMEOUT_ERROR",
+	RequestSizeExceedsLimit:   "REQUEST_SIZE_EXCEEDS_LIMIT",
+	AccessDenied:              "ACCESS_DENIED",
+}
+
+// APIError is returned when the Exchange responds with a status other than 200 OK, or with a JSON-RPC error.
+type APIError struct {
+	StatusCode int
+	Status     string
+	// ErrorCode is the APINGException error code from the response body, if there is one
+	ErrorCode string
+	// RPCCode is the JSON-RPC error code, for errors returned by the JSON-RPC endpoints
+	RPCCode int
+}
+
+func (err *APIError) Error() string {
+	if err.ErrorCode != "" {
+		return err.Status + ": " + err.ErrorCode
+	}
+	return err.Status
+}
+
+// newAPIError reads the APINGException error code, if any, from the body of a failed response
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	var fault struct {
+		Detail struct {
+			APINGException struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"APINGException"`
+		} `json:"detail"`
+	}
+	json.Unmarshal(body, &fault)
+	return &APIError{StatusCode: resp.StatusCode, Status: resp.Status, ErrorCode: fault.Detail.APINGException.ErrorCode}
+}
+
+// Retryable reports whether err is a transient failure, such as a network error, a timeout, a 5xx status or the
+// Exchange being too busy, which may succeed if the request is repeated.
+func Retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode {
+		case APIErrorCodeEnum.ServiceBusy, APIErrorCodeEnum.TimeoutError, APIErrorCodeEnum.TooManyRequests, APIErrorCodeEnum.UnexpectedError:
+			return true
+		}
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// outcomeUnknown reports whether a request which failed with err may still have been carried out by the Exchange
+func outcomeUnknown(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.ErrorCode == APIErrorCodeEnum.TimeoutError || apiErr.ErrorCode == APIErrorCodeEnum.UnexpectedError
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// OrderOutcomeUnknownError is returned by PlaceOrders when it cannot be told whether the orders were placed, either
+// because the request failed after it may have reached the Exchange or was reported as a DUPLICATE_TRANSACTION, and the
+// orders could not be found with ListCurrentOrders. Give every instruction a CustomerOrderRef so that they can be.
+type OrderOutcomeUnknownError struct {
+	MarketID    string
+	CustomerRef string
+	// Err is the error from the last attempt, nil if the Exchange reported a DUPLICATE_TRANSACTION
+	Err error
+}
+
+func (err *OrderOutcomeUnknownError) Error() string {
+	message := "Outcome of placing orders on market " + err.MarketID + " is unknown"
+	if err.Err != nil {
+		message += ": " + err.Err.Error()
+	}
+	return message
+}
+
+func (err *OrderOutcomeUnknownError) Unwrap() error {
+	return err.Err
+}
+
+// requestWithRetry issues a request, repeating it according to the Client's RetryPolicy if it fails with a Retryable
+// error and retry is set
+func (c *Client) requestWithRetry(url string, params interface{}, v interface{}, retry bool) error {
+	return c.withRetry(operation(url), retry, func() error {
+		return c.send(url, params, v)
+	})
+}
+
+// withRetry makes attempts at an operation until one succeeds, fails with an error which is not Retryable or the
+// RetryPolicy runs out of attempts
+func (c *Client) withRetry(operation string, retry bool, attempt func() error) error {
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil || !retry || n >= c.Retry.MaxAttempts || !Retryable(err) {
+			return err
+		}
+
+		backoff := c.Retry.Backoff(n)
+		c.log().Info("retrying rest request", "operation", operation, "attempt", n+1, "backoff", backoff, "error", err)
+		time.Sleep(backoff)
+	}
+}
+
+// placementUnknown reports whether a placeOrders call may have placed orders despite not returning a successful report
+func placementUnknown(report PlaceExecutionReport, err error) bool {
+	if err != nil {
+		return outcomeUnknown(err)
+	}
+	return report.Status == ExecutionReportStatusEnum.Timeout || report.ErrorCode == ExecutionReportErrorCodeEnum.DuplicateTransaction
+}
+
+// reconcile finds the orders of a placeOrders call with an unknown outcome among the current orders of the market by
+// their CustomerOrderRef, returning a report of the orders found. As an order may take a moment to appear, the lookup
+// is repeated with the RetryPolicy's backoff until every order is found or it runs out of attempts. Instructions which
+// are still missing are reported with a TIMEOUT status, as they may yet be placed, and an OrderOutcomeUnknownError is
+// returned if none are found.
+func (b *Betting) reconcile(marketID string, instructions []PlaceInstruction, options PlaceOrdersOptions, cause error) (PlaceExecutionReport, error) {
+
+	unknown := &OrderOutcomeUnknownError{MarketID: marketID, CustomerRef: options.CustomerRef, Err: cause}
+	for _, instruction := range instructions {
+		if instruction.CustomerOrderRef == "" {
+			return PlaceExecutionReport{}, unknown
+		}
+	}
+
+	byRef := make(map[string]CurrentOrderSummary)
+	for n := 1; ; n++ {
+		current, err := b.ListCurrentOrders(nil, []string{marketID}, OrderProjectionEnum.All)
+		if err == nil {
+			for _, order := range current.CurrentOrders {
+				if order.CustomerOrderRef != "" {
+					byRef[order.CustomerOrderRef] = order
+				}
+			}
+		}
+		if n >= b.Client.Retry.MaxAttempts || foundAll(byRef, instructions) {
+			break
+		}
+
+		backoff := b.Client.Retry.Backoff(n)
+		b.Client.log().Info("retrying order lookup", "marketId", marketID, "customerRef", options.CustomerRef, "attempt", n+1, "backoff", backoff, "error", err)
+		time.Sleep(backoff)
+	}
+
+	report := PlaceExecutionReport{CustomerRef: options.CustomerRef, Status: ExecutionReportStatusEnum.Success, MarketID: marketID}
+	found := 0
+	for _, instruction := range instructions {
+		order, ok := byRef[instruction.CustomerOrderRef]
+		if !ok {
+			report.Status = ExecutionReportStatusEnum.ProcessedWithErrors
+			report.InstructionReports = append(report.InstructionReports, PlaceInstructionReport{Status: InstructionReportStatusEnum.Timeout, Instruction: instruction})
+			continue
+		}
+		found++
+		report.InstructionReports = append(report.InstructionReports, PlaceInstructionReport{
+			Status:              InstructionReportStatusEnum.Success,
+			OrderStatus:         order.Status,
+			Instruction:         instruction,
+			BetID:               order.BetID,
+			PlacedDate:          order.PlacedDate,
+			AveragePriceMatched: order.AveragePriceMatched,
+			SizeMatched:         order.SizeMatched,
+		})
+	}
+
+	b.Client.log().Warn("reconciled placeOrders", "marketId", marketID, "customerRef", options.CustomerRef, "found", found, "instructions", len(instructions), "error", cause)
+
+	if found == 0 {
+		return PlaceExecutionReport{}, unknown
+	}
+	return report, nil
+}
+
+// foundAll reports whether every instruction is among the orders by CustomerOrderRef
+func foundAll(byRef map[string]CurrentOrderSummary, instructions []PlaceInstruction) bool {
+	for _, instruction := range instructions {
+		if _, ok := byRef[instruction.CustomerOrderRef]; !ok {
+			return false
+		}
+	}
+	return true
+}
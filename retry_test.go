@@ -0,0 +1,211 @@
+package gofair
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/betfairtest"
+)
+
+func TestRetryable(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"Network error", &url.Error{Op: "Post", URL: "https://api.betfair.com", Err: errors.New("connection reset")}, true},
+		{"Service unavailable", &APIError{StatusCode: 503, Status: "503 Service Unavailable"}, true},
+		{"Too many requests", &APIError{StatusCode: 429, Status: "429 Too Many Requests"}, true},
+		{"Service busy", &APIError{StatusCode: 400, Status: "400 Bad Request", ErrorCode: APIErrorCodeEnum.ServiceBusy}, true},
+		{"Invalid session", &APIError{StatusCode: 400, Status: "400 Bad Request", ErrorCode: APIErrorCodeEnum.InvalidSessionInformation}, false},
+		{"Not found", &APIError{StatusCode: 404, Status: "404 Not Found"}, false},
+		{"Decoding error", errors.New("unexpected end of JSON input"), false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			retryable := Retryable(c.err)
+
+			// Assert
+			assert.Equal(t, c.expected, retryable)
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	// Arrange
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2}
+
+	// Act / Assert
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 300*time.Millisecond, policy.Backoff(3))
+}
+
+func countRequests(server *betfairtest.RESTServer, path string) int {
+	count := 0
+	for _, request := range server.Requests() {
+		if request.Path == path {
+			count++
+		}
+	}
+	return count
+}
+
+func TestRequestRetriesReadOnlyOperations(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("listMarketBook", []MarketBook{{MarketID: "1.23"}})
+	server.FailNext("/betting/listMarketBook", http.StatusServiceUnavailable, "", 2)
+
+	// Act
+	books, err := client.Betting.ListMarketBook([]string{"1.23"}, false)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "1.23", books[0].MarketID)
+	assert.Equal(t, 3, countRequests(server, "/betting/listMarketBook/"))
+}
+
+func TestRequestDoesNotRetryPermanentErrors(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("listMarketBook", []MarketBook{})
+	server.FailNext("/betting/listMarketBook", http.StatusBadRequest, APIErrorCodeEnum.InvalidSessionInformation, 1)
+
+	// Act
+	_, err := client.Betting.ListMarketBook([]string{"1.23"}, false)
+
+	// Assert
+	assert.Equal(t, &APIError{StatusCode: 400, Status: "400 Bad Request", ErrorCode: APIErrorCodeEnum.InvalidSessionInformation}, err)
+	assert.Equal(t, 1, countRequests(server, "/betting/listMarketBook/"))
+}
+
+var retryInstructions = []PlaceInstruction{
+	{OrderType: OrderTypeEnum.Limit, SelectionID: 1, Side: SideEnum.Back, LimitOrder: LimitOrder{Size: 2, Price: 3}, CustomerOrderRef: "order-1"},
+}
+
+func TestPlaceOrdersWithoutCustomerRefIsNotRetried(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("placeOrders", PlaceExecutionReport{MarketID: "1.23", Status: ExecutionReportStatusEnum.Success})
+	server.HandleBetting("listCurrentOrders", CurrentOrderSummaryReport{})
+	server.FailNext("/betting/placeOrders", http.StatusServiceUnavailable, "", 1)
+
+	// Act
+	_, err := client.Betting.PlaceOrders("1.23", retryInstructions)
+
+	// Assert
+	assert.Equal(t, &OrderOutcomeUnknownError{MarketID: "1.23", Err: &APIError{StatusCode: 503, Status: "503 Service Unavailable"}}, err)
+	assert.Equal(t, 1, countRequests(server, "/betting/placeOrders/"))
+	assert.Equal(t, 3, countRequests(server, "/betting/listCurrentOrders/"))
+}
+
+func TestPlaceOrdersWithCustomerRefIsRetried(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("placeOrders", PlaceExecutionReport{MarketID: "1.23", Status: ExecutionReportStatusEnum.Success, CustomerRef: "request-1"})
+	server.FailNext("/betting/placeOrders", http.StatusServiceUnavailable, "", 1)
+
+	// Act
+	report, err := client.Betting.PlaceOrdersWithOptions("1.23", retryInstructions, PlaceOrdersOptions{CustomerRef: "request-1"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, ExecutionReportStatusEnum.Success, report.Status)
+	assert.Equal(t, 2, countRequests(server, "/betting/placeOrders/"))
+}
+
+func TestPlaceOrdersReconcilesDuplicateTransaction(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("placeOrders", PlaceExecutionReport{MarketID: "1.23", Status: ExecutionReportStatusEnum.Failure, ErrorCode: ExecutionReportErrorCodeEnum.DuplicateTransaction})
+	server.HandleBetting("listCurrentOrders", CurrentOrderSummaryReport{CurrentOrders: []CurrentOrderSummary{
+		{BetID: "41", MarketID: "1.23", CustomerOrderRef: "other"},
+		{BetID: "42", MarketID: "1.23", CustomerOrderRef: "order-1", Status: OrderStatusEnum.Executable, SizeMatched: 1},
+	}})
+	server.FailNext("/betting/placeOrders", http.StatusServiceUnavailable, "", 1)
+
+	// Act
+	report, err := client.Betting.PlaceOrdersWithOptions("1.23", retryInstructions, PlaceOrdersOptions{CustomerRef: "request-1"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, ExecutionReportStatusEnum.Success, report.Status)
+	assert.Equal(t, "request-1", report.CustomerRef)
+	assert.Equal(t, []PlaceInstructionReport{{
+		Status:      InstructionReportStatusEnum.Success,
+		OrderStatus: OrderStatusEnum.Executable,
+		Instruction: retryInstructions[0],
+		BetID:       "42",
+		SizeMatched: 1,
+	}}, report.InstructionReports)
+}
+
+func TestPlaceOrdersOutcomeUnknownWithoutOrderRefs(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("placeOrders", PlaceExecutionReport{MarketID: "1.23", Status: ExecutionReportStatusEnum.Timeout})
+	instructions := []PlaceInstruction{{OrderType: OrderTypeEnum.Limit, SelectionID: 1, Side: SideEnum.Back, LimitOrder: LimitOrder{Size: 2, Price: 3}}}
+
+	// Act
+	_, err := client.Betting.PlaceOrders("1.23", instructions)
+
+	// Assert
+	assert.Equal(t, &OrderOutcomeUnknownError{MarketID: "1.23"}, err)
+}
+
+func TestPlaceOrdersRetriesOrderLookup(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("placeOrders", PlaceExecutionReport{MarketID: "1.23", Status: ExecutionReportStatusEnum.Timeout})
+	server.HandleBetting("listCurrentOrders", CurrentOrderSummaryReport{CurrentOrders: []CurrentOrderSummary{
+		{BetID: "42", MarketID: "1.23", CustomerOrderRef: "order-1", Status: OrderStatusEnum.Executable},
+	}})
+	server.FailNext("/betting/listCurrentOrders", http.StatusBadRequest, APIErrorCodeEnum.InvalidInputData, 1)
+
+	// Act
+	report, err := client.Betting.PlaceOrders("1.23", retryInstructions)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "42", report.InstructionReports[0].BetID)
+	assert.Equal(t, 2, countRequests(server, "/betting/listCurrentOrders/"))
+}
+
+func TestPlaceOrdersReconcileMarksMissingOrdersUnknown(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("placeOrders", PlaceExecutionReport{MarketID: "1.23", Status: ExecutionReportStatusEnum.Timeout})
+	server.HandleBetting("listCurrentOrders", CurrentOrderSummaryReport{CurrentOrders: []CurrentOrderSummary{
+		{BetID: "42", MarketID: "1.23", CustomerOrderRef: "order-1", Status: OrderStatusEnum.Executable},
+	}})
+	instructions := []PlaceInstruction{
+		retryInstructions[0],
+		{OrderType: OrderTypeEnum.Limit, SelectionID: 2, Side: SideEnum.Back, LimitOrder: LimitOrder{Size: 2, Price: 3}, CustomerOrderRef: "order-2"},
+	}
+
+	// Act
+	report, err := client.Betting.PlaceOrders("1.23", instructions)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, ExecutionReportStatusEnum.ProcessedWithErrors, report.Status)
+	assert.Equal(t, InstructionReportStatusEnum.Success, report.InstructionReports[0].Status)
+	assert.Equal(t, PlaceInstructionReport{Status: InstructionReportStatusEnum.Timeout, Instruction: instructions[1]}, report.InstructionReports[1])
+	assert.Equal(t, 3, countRequests(server, "/betting/listCurrentOrders/"))
+}
@@ -0,0 +1,675 @@
+package gofair
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jonachehilton/gofair/streaming"
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+// pendingTimeout is how long an order which has been sent is counted as in flight if it never reaches the order stream
+const pendingTimeout = 30 * time.Second
+
+type RiskLimit string
+
+// RiskLimitEnum names the limits of a RiskLimits.
+var RiskLimitEnum = struct {
+	MaxStake,
+	MaxMarketLiability,
+	MaxRunnerLiability,
+	MaxStrategyLiability,
+	MaxOpenOrders,
+	MaxOrdersPerSecond,
+	MinTimeBeforeOff RiskLimit
+}{
+	MaxStake:             "MaxStake",
+	MaxMarketLiability:   "MaxMarketLiability",
+	MaxRunnerLiability:   "MaxRunnerLiability",
+	MaxStrategyLiability: "MaxStrategyLiability",
+	MaxOpenOrders:        "MaxOpenOrders",
+	MaxOrdersPerSecond:   "MaxOrdersPerSecond",
+	MinTimeBeforeOff:     "MinTimeBeforeOff",
+}
+
+// RiskLimits are the limits checked before orders are sent. A zero value disables the limit.
+type RiskLimits struct {
+	// MaxStake is the largest stake of a single order
+	MaxStake float64
+
+	// MaxMarketLiability is the largest loss across the outcomes of a market if every unmatched order is matched
+	MaxMarketLiability float64
+
+	// MaxRunnerLiability is the largest total of the back stakes and lay liabilities on a runner
+	MaxRunnerLiability float64
+
+	// MaxStrategyLiability is the largest total of the back stakes and lay liabilities with the same CustomerStrategyRef
+	MaxStrategyLiability float64
+
+	// MaxOpenOrders is the largest number of executable orders across all markets
+	MaxOpenOrders int
+
+	// MaxOrdersPerSecond is the largest number of instructions sent in any second
+	MaxOrdersPerSecond int
+
+	// MinTimeBeforeOff rejects orders on markets which start sooner than this. It only applies to markets in the
+	// MarketCache, as the start time is taken from the market definition.
+	MinTimeBeforeOff time.Duration
+}
+
+// RiskLimitError is returned when orders would breach one of the RiskLimits. Nothing is sent to the Exchange.
+type RiskLimitError struct {
+	Limit RiskLimit
+	// Scope is the market, runner or strategy which would breach the limit, empty for account wide limits
+	Scope string
+	Value float64
+	Max   float64
+}
+
+func (err *RiskLimitError) Error() string {
+	scope := ""
+	if err.Scope != "" {
+		scope = " for " + err.Scope
+	}
+	if err.Limit == RiskLimitEnum.MinTimeBeforeOff {
+		return fmt.Sprintf("Risk limit %s breached%s: %.0fs is below %.0fs", err.Limit, scope, err.Value, err.Max)
+	}
+	return fmt.Sprintf("Risk limit %s breached%s: %.2f exceeds %.2f", err.Limit, scope, err.Value, err.Max)
+}
+
+// KillSwitchError is returned for every order once the RiskManager has been killed.
+type KillSwitchError struct{}
+
+func (err *KillSwitchError) Error() string {
+	return "Kill switch is active, new orders are blocked"
+}
+
+// UnknownBetError is returned when an order to be replaced is not in the order cache, so its risk cannot be assessed.
+type UnknownBetError struct {
+	BetID string
+}
+
+func (err *UnknownBetError) Error() string {
+	return "Bet " + err.BetID + " is not in the order cache"
+}
+
+// pendingOrder is an order which has been sent but may not have reached the order stream yet
+type pendingOrder struct {
+	marketID         string
+	selectionID      int64
+	strategy         string
+	customerOrderRef string
+	betID            string
+	liability        float64
+	sentAt           time.Time
+	// replacement orders take the place of an open order rather than adding one
+	replacement bool
+}
+
+// RiskManager checks orders against its Limits before passing them to Betting. Exposure is calculated from the orders
+// of a subscribed order stream plus the orders which have been sent but not yet seen on it.
+type RiskManager struct {
+	Limits RiskLimits
+
+	betting *Betting
+	stream  *streaming.Stream
+	now     func() time.Time
+
+	mu      sync.Mutex
+	killed  bool
+	pending []*pendingOrder
+	sent    []time.Time
+	// orders and markets are copies of the Stream's caches, taken on its read goroutine as they are never safe to read
+	// from another
+	orders  streaming.CachedOrders
+	markets streaming.CachedMarkets
+}
+
+// NewRiskManager creates a RiskManager which places orders with betting. It registers a StreamHandler with stream to
+// follow its orders and market definitions, so it must be created before the stream is started. The stream may be
+// nil, in which case only the orders sent through the RiskManager are counted.
+func NewRiskManager(betting *Betting, limits RiskLimits, stream *streaming.Stream) *RiskManager {
+	manager := &RiskManager{
+		Limits:  limits,
+		betting: betting,
+		stream:  stream,
+		now:     time.Now,
+		orders:  make(streaming.CachedOrders),
+		markets: make(streaming.CachedMarkets),
+	}
+	if stream != nil {
+		stream.AddHandler(manager.handler())
+	}
+	return manager
+}
+
+// handler returns the StreamHandler which keeps the copies of the caches up to date
+func (manager *RiskManager) handler() streaming.StreamHandler {
+	return streaming.StreamHandler{Markets: riskMarketHandler{manager}, Orders: riskOrderHandler{manager}}
+}
+
+// copyMarkets copies the definitions of the markets in an update from the MarketCache. Definitions are replaced
+// rather than modified by the cache, so they can be shared.
+func (manager *RiskManager) copyMarkets(message models.MarketChangeMessage) {
+
+	markets := make(map[string]*streaming.MarketCache, len(message.Mc))
+	for _, change := range message.Mc {
+		if change == nil {
+			continue
+		}
+		var copied *streaming.MarketCache
+		if cache := manager.stream.MarketCache[change.ID]; cache != nil {
+			copied = &streaming.MarketCache{MarketID: cache.MarketID, MarketDefinition: cache.MarketDefinition}
+		}
+		markets[change.ID] = copied
+	}
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	for marketID, market := range markets {
+		if market == nil {
+			delete(manager.markets, marketID)
+		} else {
+			manager.markets[marketID] = market
+		}
+	}
+}
+
+// copyOrders copies the orders and matches of the markets in an update from the OrderCache
+func (manager *RiskManager) copyOrders(message models.OrderChangeMessage) {
+
+	orders := make(map[string]*streaming.OrderBookCache, len(message.Oc))
+	for _, change := range message.Oc {
+		if change == nil {
+			continue
+		}
+		var copied *streaming.OrderBookCache
+		if cache := manager.stream.OrderCache[change.ID]; cache != nil {
+			copied = copyOrderBook(cache)
+		}
+		orders[change.ID] = copied
+	}
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	for marketID, book := range orders {
+		if book == nil {
+			delete(manager.orders, marketID)
+		} else {
+			manager.orders[marketID] = book
+		}
+	}
+}
+
+// copyOrderBook copies what the limits read from an OrderBookCache
+func copyOrderBook(cache *streaming.OrderBookCache) *streaming.OrderBookCache {
+
+	book := &streaming.OrderBookCache{MarketID: cache.MarketID, Closed: cache.Closed, Runners: make(map[int64]*models.OrderRunnerChange, len(cache.Runners))}
+	for selectionID, runner := range cache.Runners {
+		if runner == nil {
+			continue
+		}
+		copied := &models.OrderRunnerChange{
+			ID: runner.ID,
+			Mb: append([][]float64(nil), runner.Mb...),
+			Ml: append([][]float64(nil), runner.Ml...),
+		}
+		for _, order := range runner.Uo {
+			if order != nil {
+				order := *order
+				copied.Uo = append(copied.Uo, &order)
+			}
+		}
+		if runner.Smc != nil {
+			copied.Smc = make(map[string]models.StrategyMatchChange, len(runner.Smc))
+			for strategy, matches := range runner.Smc {
+				copied.Smc[strategy] = models.StrategyMatchChange{
+					Mb: append([][]float64(nil), matches.Mb...),
+					Ml: append([][]float64(nil), matches.Ml...),
+				}
+			}
+		}
+		book.Runners[selectionID] = copied
+	}
+	return book
+}
+
+// Kill blocks all new orders and cancels every unmatched order on every market.
+func (manager *RiskManager) Kill() (CancelExecutionReport, error) {
+	manager.mu.Lock()
+	manager.killed = true
+	manager.mu.Unlock()
+
+	return manager.betting.CancelOrders("", nil)
+}
+
+// Killed reports whether the kill switch is active
+func (manager *RiskManager) Killed() bool {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	return manager.killed
+}
+
+// Reset deactivates the kill switch
+func (manager *RiskManager) Reset() {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	manager.killed = false
+}
+
+// PlaceOrders checks the instructions against the limits and, if none are breached, submits them with PlaceOrders.
+func (manager *RiskManager) PlaceOrders(marketID string, placeInstructions []PlaceInstruction) (PlaceExecutionReport, error) {
+	return manager.PlaceOrdersWithOptions(marketID, placeInstructions, PlaceOrdersOptions{})
+}
+
+// PlaceOrdersWithOptions is PlaceOrders with the optional parameters of placeOrders.
+func (manager *RiskManager) PlaceOrdersWithOptions(marketID string, placeInstructions []PlaceInstruction, options PlaceOrdersOptions) (PlaceExecutionReport, error) {
+
+	orders := make([]*pendingOrder, len(placeInstructions))
+	stakes := make([]float64, len(placeInstructions))
+	for i, instruction := range placeInstructions {
+		stake, liability := instructionRisk(instruction)
+		stakes[i] = stake
+		orders[i] = &pendingOrder{
+			marketID:         marketID,
+			selectionID:      int64(instruction.SelectionID),
+			strategy:         options.CustomerStrategyRef,
+			customerOrderRef: instruction.CustomerOrderRef,
+			liability:        liability,
+		}
+	}
+
+	if err := manager.check(marketID, orders, stakes, len(orders)); err != nil {
+		return PlaceExecutionReport{}, err
+	}
+
+	report, err := manager.betting.PlaceOrdersWithOptions(marketID, placeInstructions, options)
+
+	betIDs := make([]string, len(orders))
+	for i, instructionReport := range report.InstructionReports {
+		if i < len(betIDs) && instructionReport.Status != InstructionReportStatusEnum.Failure {
+			betIDs[i] = instructionReport.BetID
+		}
+	}
+	manager.sentOrders(orders, betIDs, err == nil && report.Status != ExecutionReportStatusEnum.Failure)
+
+	return report, err
+}
+
+// ReplaceOrders checks the additional liability of moving each order to its new price and, if no limit is breached,
+// submits the instructions with ReplaceOrders. The orders must be in the order cache.
+func (manager *RiskManager) ReplaceOrders(marketID string, replaceInstructions []ReplaceInstruction) (ReplaceExecutionReport, error) {
+
+	orders := make([]*pendingOrder, len(replaceInstructions))
+	for i, instruction := range replaceInstructions {
+		selectionID, order := manager.cachedOrder(marketID, instruction.BetID)
+		if order == nil {
+			return ReplaceExecutionReport{}, fmt.Errorf("instruction %d: %w", i, &UnknownBetError{BetID: instruction.BetID})
+		}
+
+		// Only an increase counts, the cancelled part of the old order stays in the cache until the stream removes it
+		increase := liability(streaming.OrderSide(order.Side) == streaming.OrderSideEnum.Lay, instruction.NewPrice.Float64(), order.Sr) -
+			liability(streaming.OrderSide(order.Side) == streaming.OrderSideEnum.Lay, order.P, order.Sr)
+		if increase < 0 {
+			increase = 0
+		}
+		orders[i] = &pendingOrder{
+			marketID:    marketID,
+			selectionID: selectionID,
+			strategy:    order.Rfs,
+			liability:   increase,
+			replacement: true,
+		}
+	}
+
+	if err := manager.check(marketID, orders, nil, 0); err != nil {
+		return ReplaceExecutionReport{}, err
+	}
+
+	report, err := manager.betting.ReplaceOrders(marketID, replaceInstructions)
+
+	betIDs := make([]string, len(orders))
+	for i, instructionReport := range report.InstructionReports {
+		if i < len(betIDs) && instructionReport.PlaceInstructionReport != nil {
+			betIDs[i] = instructionReport.PlaceInstructionReport.BetID
+		}
+	}
+	manager.sentOrders(orders, betIDs, err == nil && report.Status != ExecutionReportStatusEnum.Failure)
+
+	return report, err
+}
+
+// check returns the first limit the orders would breach, otherwise it records them as in flight
+func (manager *RiskManager) check(marketID string, orders []*pendingOrder, stakes []float64, newOrders int) error {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	if manager.killed {
+		return &KillSwitchError{}
+	}
+
+	now := manager.now()
+	manager.prune(now)
+	limits := manager.Limits
+
+	for i, stake := range stakes {
+		if limits.MaxStake > 0 && stake > limits.MaxStake {
+			return fmt.Errorf("instruction %d: %w", i, &RiskLimitError{Limit: RiskLimitEnum.MaxStake, Value: stake, Max: limits.MaxStake})
+		}
+	}
+
+	if limits.MinTimeBeforeOff > 0 {
+		if start, ok := manager.startTime(marketID); ok && start.Sub(now) < limits.MinTimeBeforeOff {
+			return &RiskLimitError{Limit: RiskLimitEnum.MinTimeBeforeOff, Scope: marketID, Value: start.Sub(now).Seconds(), Max: limits.MinTimeBeforeOff.Seconds()}
+		}
+	}
+
+	if limits.MaxOpenOrders > 0 {
+		open := manager.openOrders() + newOrders
+		if open > limits.MaxOpenOrders {
+			return &RiskLimitError{Limit: RiskLimitEnum.MaxOpenOrders, Value: float64(open), Max: float64(limits.MaxOpenOrders)}
+		}
+	}
+
+	if limits.MaxOrdersPerSecond > 0 {
+		sent := len(manager.sent) + len(orders)
+		if sent > limits.MaxOrdersPerSecond {
+			return &RiskLimitError{Limit: RiskLimitEnum.MaxOrdersPerSecond, Value: float64(sent), Max: float64(limits.MaxOrdersPerSecond)}
+		}
+	}
+
+	marketIncrease := 0.0
+	runnerIncrease := make(map[int64]float64)
+	strategyIncrease := make(map[string]float64)
+	for _, order := range orders {
+		marketIncrease += order.liability
+		runnerIncrease[order.selectionID] += order.liability
+		if order.strategy != "" {
+			strategyIncrease[order.strategy] += order.liability
+		}
+	}
+
+	if limits.MaxRunnerLiability > 0 {
+		for selectionID, increase := range runnerIncrease {
+			total := manager.runnerLiability(marketID, selectionID) + increase
+			if total > limits.MaxRunnerLiability {
+				scope := fmt.Sprintf("%s/%d", marketID, selectionID)
+				return &RiskLimitError{Limit: RiskLimitEnum.MaxRunnerLiability, Scope: scope, Value: total, Max: limits.MaxRunnerLiability}
+			}
+		}
+	}
+
+	if limits.MaxMarketLiability > 0 {
+		total := manager.marketLiability(marketID) + marketIncrease
+		if total > limits.MaxMarketLiability {
+			return &RiskLimitError{Limit: RiskLimitEnum.MaxMarketLiability, Scope: marketID, Value: total, Max: limits.MaxMarketLiability}
+		}
+	}
+
+	if limits.MaxStrategyLiability > 0 {
+		for strategy, increase := range strategyIncrease {
+			total := manager.strategyLiability(strategy) + increase
+			if total > limits.MaxStrategyLiability {
+				return &RiskLimitError{Limit: RiskLimitEnum.MaxStrategyLiability, Scope: strategy, Value: total, Max: limits.MaxStrategyLiability}
+			}
+		}
+	}
+
+	for _, order := range orders {
+		order.sentAt = now
+		manager.pending = append(manager.pending, order)
+		manager.sent = append(manager.sent, now)
+	}
+
+	return nil
+}
+
+// sentOrders records the bet ids of the orders which were placed and stops counting the rest
+func (manager *RiskManager) sentOrders(orders []*pendingOrder, betIDs []string, accepted bool) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	rejected := make(map[*pendingOrder]bool)
+	for i, order := range orders {
+		order.betID = betIDs[i]
+		if !accepted || (order.betID == "" && order.customerOrderRef == "") {
+			rejected[order] = true
+		}
+	}
+
+	pending := manager.pending[:0]
+	for _, order := range manager.pending {
+		if !rejected[order] {
+			pending = append(pending, order)
+		}
+	}
+	manager.pending = pending
+}
+
+// prune drops the in flight orders which have reached the order stream, and the sends older than a second
+func (manager *RiskManager) prune(now time.Time) {
+	pending := manager.pending[:0]
+	for _, order := range manager.pending {
+		if now.Sub(order.sentAt) < pendingTimeout && !manager.onStream(order) {
+			pending = append(pending, order)
+		}
+	}
+	manager.pending = pending
+
+	sent := manager.sent[:0]
+	for _, at := range manager.sent {
+		if now.Sub(at) < time.Second {
+			sent = append(sent, at)
+		}
+	}
+	manager.sent = sent
+}
+
+func (manager *RiskManager) onStream(order *pendingOrder) bool {
+	if order.betID == "" && order.customerOrderRef == "" {
+		return false
+	}
+	cache := manager.orders[order.marketID]
+	if cache == nil || cache.Runners[order.selectionID] == nil {
+		return false
+	}
+	for _, cached := range cache.Runners[order.selectionID].Uo {
+		if (order.betID != "" && cached.ID == order.betID) || (order.customerOrderRef != "" && cached.Rfo == order.customerOrderRef) {
+			return true
+		}
+	}
+	return false
+}
+
+func (manager *RiskManager) startTime(marketID string) (time.Time, bool) {
+	market := manager.markets[marketID]
+	if market == nil || market.MarketDefinition == nil || time.Time(market.MarketDefinition.MarketTime).IsZero() {
+		return time.Time{}, false
+	}
+	return time.Time(market.MarketDefinition.MarketTime), true
+}
+
+// cachedOrder returns the selection and a copy of an order in the order cache, nil if it is not there
+func (manager *RiskManager) cachedOrder(marketID string, betID string) (int64, *models.Order) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	cache := manager.orders[marketID]
+	if cache == nil {
+		return 0, nil
+	}
+	for selectionID, runner := range cache.Runners {
+		for _, order := range runner.Uo {
+			if order.ID == betID {
+				copied := *order
+				return selectionID, &copied
+			}
+		}
+	}
+	return 0, nil
+}
+
+func (manager *RiskManager) openOrders() int {
+	open := 0
+	for _, order := range manager.pending {
+		if !order.replacement {
+			open++
+		}
+	}
+	for _, cache := range manager.orders {
+		for _, runner := range cache.Runners {
+			for _, order := range runner.Uo {
+				if executable(order) {
+					open++
+				}
+			}
+		}
+	}
+	return open
+}
+
+func (manager *RiskManager) runnerLiability(marketID string, selectionID int64) float64 {
+	total := 0.0
+	if cache := manager.orders[marketID]; cache != nil && cache.Runners[selectionID] != nil {
+		runner := cache.Runners[selectionID]
+		total += matchedLiability(runner.Mb, runner.Ml)
+		for _, order := range runner.Uo {
+			if executable(order) {
+				total += orderLiability(order)
+			}
+		}
+	}
+	for _, order := range manager.pending {
+		if order.marketID == marketID && order.selectionID == selectionID {
+			total += order.liability
+		}
+	}
+	return total
+}
+
+func (manager *RiskManager) marketLiability(marketID string) float64 {
+	total := 0.0
+	if cache := manager.orders[marketID]; cache != nil {
+		total -= cache.Position(manager.markets[marketID], false).PotentialExposure
+	}
+	for _, order := range manager.pending {
+		if order.marketID == marketID {
+			total += order.liability
+		}
+	}
+	return total
+}
+
+func (manager *RiskManager) strategyLiability(strategy string) float64 {
+	total := 0.0
+	for _, cache := range manager.orders {
+		for _, runner := range cache.Runners {
+			if matches, ok := runner.Smc[strategy]; ok {
+				total += matchedLiability(matches.Mb, matches.Ml)
+			}
+			for _, order := range runner.Uo {
+				if order.Rfs == strategy && executable(order) {
+					total += orderLiability(order)
+				}
+			}
+		}
+	}
+	for _, order := range manager.pending {
+		if order.strategy == strategy {
+			total += order.liability
+		}
+	}
+	return total
+}
+
+// riskMarketHandler is the IMarketHandler of a RiskManager
+type riskMarketHandler struct{ manager *RiskManager }
+
+func (h riskMarketHandler) OnSubscribe(message models.MarketChangeMessage) {
+	h.manager.copyMarkets(message)
+}
+func (h riskMarketHandler) OnResubscribe(message models.MarketChangeMessage) {
+	h.manager.copyMarkets(message)
+}
+func (h riskMarketHandler) OnHeartbeat(models.MarketChangeMessage) {}
+func (h riskMarketHandler) OnUpdate(message models.MarketChangeMessage) {
+	h.manager.copyMarkets(message)
+}
+
+// riskOrderHandler is the IOrderHandler of a RiskManager
+type riskOrderHandler struct{ manager *RiskManager }
+
+func (h riskOrderHandler) OnSubscribe(message models.OrderChangeMessage) {
+	h.manager.copyOrders(message)
+}
+func (h riskOrderHandler) OnResubscribe(message models.OrderChangeMessage) {
+	h.manager.copyOrders(message)
+}
+func (h riskOrderHandler) OnHeartbeat(models.OrderChangeMessage)      {}
+func (h riskOrderHandler) OnUpdate(message models.OrderChangeMessage) { h.manager.copyOrders(message) }
+
+// liability is what an order loses: the stake of a back, or the stake times the odds minus one of a lay
+func liability(lay bool, price float64, size float64) float64 {
+	if lay {
+		return size * (price - 1)
+	}
+	return size
+}
+
+func executable(order *models.Order) bool {
+	return streaming.OrderStatus(order.Status) == streaming.OrderStatusEnum.Executable && order.Sr > 0
+}
+
+// orderLiability is the liability of the unmatched part of an order, the matched part is in the runner's Mb and Ml
+func orderLiability(order *models.Order) float64 {
+	return liability(streaming.OrderSide(order.Side) == streaming.OrderSideEnum.Lay, order.P, order.Sr)
+}
+
+func matchedLiability(backs [][]float64, lays [][]float64) float64 {
+	total := 0.0
+	for _, matched := range backs {
+		total += liability(false, matched[0], matched[1])
+	}
+	for _, matched := range lays {
+		total += liability(true, matched[0], matched[1])
+	}
+	return total
+}
+
+// instructionRisk returns the stake and the liability of an instruction
+func instructionRisk(instruction PlaceInstruction) (float64, float64) {
+	lay := instruction.Side == SideEnum.Lay
+
+	switch instruction.OrderType {
+	case OrderTypeEnum.LimitOnClose:
+		if instruction.LimitOnCloseOrder == nil {
+			return 0, 0
+		}
+		// The liability of a LIMIT_ON_CLOSE order is its stake when backing
+		amount := instruction.LimitOnCloseOrder.Liability.Float64()
+		if lay && instruction.LimitOnCloseOrder.Price > 1 {
+			return amount / (instruction.LimitOnCloseOrder.Price.Float64() - 1), amount
+		}
+		return amount, amount
+	case OrderTypeEnum.MarketOnClose:
+		if instruction.MarketOnCloseOrder == nil {
+			return 0, 0
+		}
+		amount := instruction.MarketOnCloseOrder.Liability.Float64()
+		return amount, amount
+	}
+
+	order := instruction.LimitOrder
+	p := order.Price.Float64()
+	stake := order.Size.Float64()
+	switch order.BetTargetType {
+	case BetTargetTypeEnum.Payout:
+		stake = order.BetTargetSize.Float64() / p
+	case BetTargetTypeEnum.BackersProfit:
+		if p > 1 {
+			stake = order.BetTargetSize.Float64() / (p - 1)
+		}
+	}
+	return stake, liability(lay, p, stake)
+}
@@ -0,0 +1,253 @@
+package gofair
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/betfairtest"
+	"github.com/jonachehilton/gofair/decimal"
+	"github.com/jonachehilton/gofair/streaming"
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+func backOrder(selectionID int, price decimal.Price, size decimal.Money) PlaceInstruction {
+	return PlaceInstruction{OrderType: OrderTypeEnum.Limit, SelectionID: selectionID, Side: SideEnum.Back, LimitOrder: LimitOrder{Price: price, Size: size}}
+}
+
+func layOrder(selectionID int, price decimal.Price, size decimal.Money) PlaceInstruction {
+	return PlaceInstruction{OrderType: OrderTypeEnum.Limit, SelectionID: selectionID, Side: SideEnum.Lay, LimitOrder: LimitOrder{Price: price, Size: size}}
+}
+
+// riskOrders is an order cache with a matched lay of 10 at 3.0 and an unmatched back of 5 at 4.0 on runner 1
+func riskOrders() streaming.CachedOrders {
+	return streaming.CachedOrders{
+		"1.23": {
+			MarketID: "1.23",
+			Runners: map[int64]*models.OrderRunnerChange{
+				1: {
+					ID:  1,
+					Ml:  [][]float64{{3, 10}},
+					Uo:  []*models.Order{{ID: "50", Side: "B", P: 4, Sr: 5, Status: "E", Rfs: "strategy"}},
+					Smc: map[string]models.StrategyMatchChange{"strategy": {Ml: [][]float64{{3, 10}}}},
+				},
+			},
+		},
+	}
+}
+
+// newRiskManager creates a RiskManager following a Stream whose caches hold orders and markets
+func newRiskManager(t *testing.T, betting *Betting, limits RiskLimits, orders streaming.CachedOrders, markets streaming.CachedMarkets) (*RiskManager, *streaming.Stream) {
+	stream, err := streaming.NewStream(nil, "")
+	assert.NoError(t, err)
+	if orders != nil {
+		stream.OrderCache = orders
+	}
+	if markets != nil {
+		stream.MarketCache = markets
+	}
+
+	manager := NewRiskManager(betting, limits, stream)
+	handler := manager.handler()
+	for marketID := range stream.OrderCache {
+		handler.Orders.OnSubscribe(orderUpdate(marketID, 0))
+	}
+	for marketID := range stream.MarketCache {
+		handler.Markets.OnSubscribe(models.MarketChangeMessage{Mc: []*models.MarketChange{{ID: marketID}}})
+	}
+	return manager, stream
+}
+
+func TestRiskManagerLimits(t *testing.T) {
+	// Arrange
+	start := time.Date(2026, 1, 1, 15, 0, 0, 0, time.UTC)
+	markets := streaming.CachedMarkets{
+		"1.23": {MarketID: "1.23", MarketDefinition: &models.MarketDefinition{MarketTime: strfmt.DateTime(start)}},
+	}
+	testCases := []struct {
+		name         string
+		limits       RiskLimits
+		instructions []PlaceInstruction
+		options      PlaceOrdersOptions
+		expected     string
+	}{
+		{
+			name:         "stake",
+			limits:       RiskLimits{MaxStake: 10},
+			instructions: []PlaceInstruction{backOrder(1, 2, 10), backOrder(1, 2, 10.5)},
+			expected:     "instruction 1: Risk limit MaxStake breached: 10.50 exceeds 10.00",
+		},
+		{
+			name:         "runner liability",
+			limits:       RiskLimits{MaxRunnerLiability: 30},
+			instructions: []PlaceInstruction{layOrder(1, 2, 6)},
+			expected:     "Risk limit MaxRunnerLiability breached for 1.23/1: 31.00 exceeds 30.00",
+		},
+		{
+			name:         "market liability",
+			limits:       RiskLimits{MaxMarketLiability: 40},
+			instructions: []PlaceInstruction{layOrder(2, 11, 2.5)},
+			expected:     "Risk limit MaxMarketLiability breached for 1.23: 45.00 exceeds 40.00",
+		},
+		{
+			name:         "strategy liability",
+			limits:       RiskLimits{MaxStrategyLiability: 30},
+			instructions: []PlaceInstruction{backOrder(2, 2, 6)},
+			options:      PlaceOrdersOptions{CustomerStrategyRef: "strategy"},
+			expected:     "Risk limit MaxStrategyLiability breached for strategy: 31.00 exceeds 30.00",
+		},
+		{
+			name:         "open orders",
+			limits:       RiskLimits{MaxOpenOrders: 2},
+			instructions: []PlaceInstruction{backOrder(1, 2, 2), backOrder(2, 2, 2)},
+			expected:     "Risk limit MaxOpenOrders breached: 3.00 exceeds 2.00",
+		},
+		{
+			name:         "orders per second",
+			limits:       RiskLimits{MaxOrdersPerSecond: 1},
+			instructions: []PlaceInstruction{backOrder(1, 2, 2), backOrder(2, 2, 2)},
+			expected:     "Risk limit MaxOrdersPerSecond breached: 2.00 exceeds 1.00",
+		},
+		{
+			name:         "time before off",
+			limits:       RiskLimits{MinTimeBeforeOff: 2 * time.Minute},
+			instructions: []PlaceInstruction{backOrder(1, 2, 2)},
+			expected:     "Risk limit MinTimeBeforeOff breached for 1.23: 60s is below 120s",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			client.Login()
+			requests := len(server.Requests())
+			manager, _ := newRiskManager(t, client.Betting, testCase.limits, riskOrders(), markets)
+			manager.now = func() time.Time { return start.Add(-time.Minute) }
+
+			// Act
+			_, err := manager.PlaceOrdersWithOptions("1.23", testCase.instructions, testCase.options)
+
+			// Assert
+			assert.EqualError(t, err, testCase.expected)
+			var limitErr *RiskLimitError
+			assert.ErrorAs(t, err, &limitErr)
+			assert.Len(t, server.Requests(), requests)
+		})
+	}
+}
+
+func TestRiskManagerCountsOrdersInFlight(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("placeOrders", PlaceExecutionReport{
+		MarketID:           "1.23",
+		Status:             ExecutionReportStatusEnum.Success,
+		InstructionReports: []PlaceInstructionReport{{Status: InstructionReportStatusEnum.Success, BetID: "100"}},
+	})
+	orders := riskOrders()
+	manager, _ := newRiskManager(t, client.Betting, RiskLimits{MaxRunnerLiability: 40}, orders, nil)
+
+	// Act
+	_, first := manager.PlaceOrders("1.23", []PlaceInstruction{backOrder(1, 2, 10)})
+	_, second := manager.PlaceOrders("1.23", []PlaceInstruction{backOrder(1, 2, 10)})
+	orders["1.23"].Runners[1].Uo = append(orders["1.23"].Runners[1].Uo, &models.Order{ID: "100", Side: "B", P: 2, Sr: 10, Status: "E"})
+	manager.handler().Orders.OnUpdate(orderUpdate("1.23", 1))
+	_, third := manager.PlaceOrders("1.23", []PlaceInstruction{backOrder(1, 2, 10)})
+
+	// Assert
+	assert.NoError(t, first)
+	assert.EqualError(t, second, "Risk limit MaxRunnerLiability breached for 1.23/1: 45.00 exceeds 40.00")
+	assert.EqualError(t, third, "Risk limit MaxRunnerLiability breached for 1.23/1: 45.00 exceeds 40.00")
+	assert.Empty(t, manager.pending)
+}
+
+func TestRiskManagerReplaceOrders(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("replaceOrders", ReplaceExecutionReport{MarketID: "1.23", Status: ExecutionReportStatusEnum.Success})
+	manager, _ := newRiskManager(t, client.Betting, RiskLimits{MaxRunnerLiability: 40}, riskOrders(), nil)
+
+	// Act
+	_, unknown := manager.ReplaceOrders("1.23", []ReplaceInstruction{{BetID: "99", NewPrice: 2}})
+	_, lower := manager.ReplaceOrders("1.23", []ReplaceInstruction{{BetID: "50", NewPrice: 2}})
+
+	// Assert
+	assert.EqualError(t, unknown, "instruction 0: Bet 99 is not in the order cache")
+	var unknownErr *UnknownBetError
+	assert.ErrorAs(t, unknown, &unknownErr)
+	assert.NoError(t, lower)
+}
+
+func TestRiskManagerKill(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("cancelOrders", CancelExecutionReport{Status: "SUCCESS"})
+	server.HandleBetting("placeOrders", PlaceExecutionReport{MarketID: "1.23", Status: ExecutionReportStatusEnum.Success})
+	manager := NewRiskManager(client.Betting, RiskLimits{}, nil)
+
+	// Act
+	report, killErr := manager.Kill()
+	_, blocked := manager.PlaceOrders("1.23", []PlaceInstruction{backOrder(1, 2, 2)})
+	manager.Reset()
+	_, allowed := manager.PlaceOrders("1.23", []PlaceInstruction{backOrder(1, 2, 2)})
+
+	// Assert
+	assert.NoError(t, killErr)
+	assert.Equal(t, "SUCCESS", report.Status)
+	var killSwitch *KillSwitchError
+	assert.ErrorAs(t, blocked, &killSwitch)
+	assert.NoError(t, allowed)
+	assert.False(t, manager.Killed())
+}
+
+func TestRiskManagerCopiesOrderCache(t *testing.T) {
+	// Arrange
+	orders := riskOrders()
+	manager, _ := newRiskManager(t, nil, RiskLimits{}, orders, nil)
+
+	// Act
+	orders["1.23"].Runners[1].Uo[0].Sr = 20
+	before := manager.runnerLiability("1.23", 1)
+	manager.handler().Orders.OnUpdate(orderUpdate("1.23", 1))
+	after := manager.runnerLiability("1.23", 1)
+	delete(orders, "1.23")
+	manager.handler().Orders.OnUpdate(orderUpdate("1.23", 1))
+	removed := manager.runnerLiability("1.23", 1)
+
+	// Assert
+	assert.Equal(t, 25.0, before)
+	assert.Equal(t, 40.0, after)
+	assert.Equal(t, 0.0, removed)
+}
+
+func TestRiskManagerCountsOrdersFromImage(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	server.HandleBetting("replaceOrders", ReplaceExecutionReport{MarketID: "1.23", Status: ExecutionReportStatusEnum.Success})
+	stream, err := betfairtest.NewStreamServer()
+	assert.NoError(t, err)
+	defer stream.Close()
+	stream.Script("orderSubscription",
+		`{"op":"ocm","ct":"SUB_IMAGE","initialClk":"AA==","clk":"AA==","pt":1,"oc":[{"id":"1.23","orc":[{"id":1,"ml":[[3,10]],"uo":[{"id":"50","p":4,"s":5,"sr":5,"side":"B","status":"E"}]}]}]}`,
+	)
+	client.Streaming.TLSConfig = stream.ClientTLSConfig()
+	manager := NewRiskManager(client.Betting, RiskLimits{MaxRunnerLiability: 30}, client.Streaming)
+	assert.NoError(t, client.Streaming.Start(stream.Addr, client.Session.SessionToken))
+	defer client.Streaming.Stop()
+	_, err = client.Streaming.SubscribeToOrders()
+	assert.NoError(t, err)
+
+	// Act
+	_, breached := manager.PlaceOrders("1.23", []PlaceInstruction{layOrder(1, 2, 6)})
+	_, replaced := manager.ReplaceOrders("1.23", []ReplaceInstruction{{BetID: "50", NewPrice: 2}})
+
+	// Assert
+	assert.EqualError(t, breached, "Risk limit MaxRunnerLiability breached for 1.23/1: 31.00 exceeds 30.00")
+	assert.NoError(t, replaced)
+}
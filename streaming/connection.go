@@ -10,6 +10,9 @@ import (
 type tlsConnection struct {
 	ID   int32
 	conn *tls.Conn
+
+	// The raw ConnectionMessage sent by the Exchange when the connection was opened
+	connectionMessage []byte
 }
 
 func (conn *tlsConnection) Write(b []byte) (int, error) {
@@ -21,11 +24,15 @@ func (conn *tlsConnection) Stop() {
 	conn.conn = nil
 }
 
-func newTLSConnection(destination string, certs *tls.Certificate) (*tlsConnection, error) {
+func newTLSConnection(destination string, certs *tls.Certificate, tlsConfig *tls.Config) (*tlsConnection, error) {
 
 	connection := new(tlsConnection)
 
-	cfg := &tls.Config{Certificates: []tls.Certificate{*certs}}
+	cfg := new(tls.Config)
+	if tlsConfig != nil {
+		cfg = tlsConfig.Clone()
+	}
+	cfg.Certificates = []tls.Certificate{*certs}
 	conn, err := tls.Dial("tcp", destination, cfg)
 
 	if err != nil {
@@ -50,6 +57,7 @@ func newTLSConnection(destination string, certs *tls.Certificate) (*tlsConnectio
 	}
 
 	connection.conn = conn
+	connection.connectionMessage = append([]byte(nil), buf...)
 
 	return connection, nil
 }
@@ -1,23 +1,43 @@
 package streaming
 
 import (
-	"crypto/tls"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/jonachehilton/gofair/config"
+	"github.com/jonachehilton/gofair/betfairtest"
 )
 
 func TestTLSConnection(t *testing.T) {
 
 	// Arrange
-	cfg, _ := config.LoadConfig("config.json")
-	cert, _ := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	server, err := betfairtest.NewStreamServer()
+	assert.NoError(t, err)
+	defer server.Close()
+	cert := server.Certificate.TLS
 
 	// Act
-	conn, _ := newTLSConnection(IntegrationEndpoint, &cert)
+	conn, err := newTLSConnection(server.Addr, &cert, server.ClientTLSConfig())
 
 	// Assert
+	assert.NoError(t, err)
 	assert.NotNil(t, conn)
+	assert.Contains(t, string(conn.connectionMessage), `"connectionId":"001-1"`)
+	conn.Stop()
+}
+
+func TestTLSConnectionUntrustedServer(t *testing.T) {
+
+	// Arrange
+	server, err := betfairtest.NewStreamServer()
+	assert.NoError(t, err)
+	defer server.Close()
+	cert := server.Certificate.TLS
+
+	// Act
+	conn, err := newTLSConnection(server.Addr, &cert, nil)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, conn)
 }
@@ -25,3 +25,59 @@ var MarketDataFilterEnum = struct {
 	SPTraded:         "SP_TRADED",
 	SPProjected:      "SP_PROJECTED",
 }
+
+type OrderSide string
+
+// OrderSideEnum describes the side of an Order in the order stream.
+var OrderSideEnum = struct {
+	Back, Lay OrderSide
+}{
+	Back: "B",
+	Lay:  "L",
+}
+
+type OrderStatus string
+
+// OrderStatusEnum describes the status of an Order in the order stream.
+var OrderStatusEnum = struct {
+	Executable, ExecutionComplete OrderStatus
+}{
+	Executable:        "E",
+	ExecutionComplete: "EC",
+}
+
+type StatusErrorCode string
+
+// StatusErrorCodeEnum describes the errorCode values that can be returned in a StatusMessage when a request fails.
+// see https://docs.developer.betfair.com/display/1smk3cen4v3lu3yomq5qye0ni/Exchange+Stream+API#ExchangeStreamAPI-StatusMessage
+var StatusErrorCodeEnum = struct {
+	NoAppKey,
+	InvalidAppKey,
+	NoSession,
+	InvalidSessionInformation,
+	NotAuthorized,
+	InvalidInput,
+	InvalidClock,
+	UnexpectedError,
+	Timeout,
+	SubscriptionLimitExceeded,
+	InvalidRequest,
+	ConnectionFailed,
+	MaxConnectionLimitExceeded,
+	TooManyRequests StatusErrorCode
+}{
+	NoAppKey:                   "NO_APP_KEY",
+	InvalidAppKey:              "INVALID_APP_KEY",
+	NoSession:                  "NO_SESSION",
+	InvalidSessionInformation:  "INVALID_SESSION_INFORMATION",
+	NotAuthorized:              "NOT_AUTHORIZED",
+	InvalidInput:               "INVALID_INPUT",
+	InvalidClock:               "INVALID_CLOCK",
+	UnexpectedError:            "UNEXPECTED_ERROR",
+	Timeout:                    "TIMEOUT",
+	SubscriptionLimitExceeded:  "SUBSCRIPTION_LIMIT_EXCEEDED",
+	InvalidRequest:             "INVALID_REQUEST",
+	ConnectionFailed:           "CONNECTION_FAILED",
+	MaxConnectionLimitExceeded: "MAX_CONNECTION_LIMIT_EXCEEDED",
+	TooManyRequests:            "TOO_MANY_REQUESTS",
+}
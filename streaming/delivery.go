@@ -0,0 +1,244 @@
+package streaming
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DeliveryPolicy is the type associated with strings contained in the DeliveryPolicyEnum struct.
+type DeliveryPolicy string
+
+// DeliveryPolicyEnum describes what happens to an update when the consumer of a StreamChannels channel has fallen behind
+// and the channel buffer is full.
+//
+// Block waits for the consumer, which stalls the socket reader (the default).
+// DropOldest discards the oldest buffered update to make room for the new one.
+// CoalesceLatest holds back at most one update per market, replacing it whenever a newer one arrives, and delivers it
+// once the consumer catches up, so only the newest update per market is ever waiting. The channel is unbuffered under
+// this policy. It is only available on the MarketUpdate, OrderUpdate and RaceUpdate channels.
+var DeliveryPolicyEnum = struct {
+	Block,
+	DropOldest,
+	CoalesceLatest DeliveryPolicy
+}{
+	Block:          "BLOCK",
+	DropOldest:     "DROP_OLDEST",
+	CoalesceLatest: "COALESCE_LATEST",
+}
+
+// DeliveryPolicies sets the DeliveryPolicy for each of the incoming StreamChannels. Empty fields default to Block.
+type DeliveryPolicies struct {
+	MarketUpdate DeliveryPolicy
+	OrderUpdate  DeliveryPolicy
+	RaceUpdate   DeliveryPolicy
+	Status       DeliveryPolicy
+	Err          DeliveryPolicy
+}
+
+// DeliveryStats counts the updates that were not delivered as-is on a channel.
+type DeliveryStats struct {
+	// Dropped is the number of updates discarded under the DropOldest policy
+	Dropped uint64
+	// Coalesced is the number of updates replaced by a newer update for the same market under the CoalesceLatest policy
+	Coalesced uint64
+}
+
+// ChannelStats holds the DeliveryStats for each of the incoming StreamChannels.
+type ChannelStats struct {
+	MarketUpdate DeliveryStats
+	OrderUpdate  DeliveryStats
+	RaceUpdate   DeliveryStats
+	Status       DeliveryStats
+	Err          DeliveryStats
+}
+
+// delivery sends values on a channel according to a DeliveryPolicy
+type delivery[T any] struct {
+	out    chan T
+	key    func(T) string
+	policy DeliveryPolicy
+
+	// capacity is the buffer of out under the Block and DropOldest policies, CoalesceLatest leaves out unbuffered
+	capacity int
+
+	mu      sync.Mutex
+	pending map[string]pendingValue[T]
+	queue   []string
+	version uint64
+	pumping bool
+	wake    chan struct{}
+	done    chan struct{}
+
+	dropped   atomic.Uint64
+	coalesced atomic.Uint64
+}
+
+// pendingValue is the latest value held back for a key, version tells whether it was replaced while being sent
+type pendingValue[T any] struct {
+	value   T
+	version uint64
+}
+
+func newDelivery[T any](out chan T, key func(T) string) *delivery[T] {
+	return &delivery[T]{out: out, key: key, policy: DeliveryPolicyEnum.Block, capacity: cap(out)}
+}
+
+// checkPolicy returns the policy to apply, or an error if it cannot be used on this channel
+func (d *delivery[T]) checkPolicy(channel string, policy DeliveryPolicy) (DeliveryPolicy, error) {
+	switch policy {
+	case "":
+		return DeliveryPolicyEnum.Block, nil
+	case DeliveryPolicyEnum.Block, DeliveryPolicyEnum.DropOldest:
+		return policy, nil
+	case DeliveryPolicyEnum.CoalesceLatest:
+		if d.key != nil {
+			return policy, nil
+		}
+	}
+	return "", &DeliveryPolicyError{Channel: channel, Policy: policy}
+}
+
+// setPolicy applies a checked policy and returns the channel values are now delivered on. CoalesceLatest needs an
+// unbuffered channel, so that nothing older than the latest value per key can be waiting in a buffer.
+func (d *delivery[T]) setPolicy(policy DeliveryPolicy) chan T {
+	d.policy = policy
+	switch {
+	case policy == DeliveryPolicyEnum.CoalesceLatest && cap(d.out) != 0:
+		d.out = make(chan T)
+	case policy != DeliveryPolicyEnum.CoalesceLatest && cap(d.out) != d.capacity:
+		d.out = make(chan T, d.capacity)
+	}
+	return d.out
+}
+
+func (d *delivery[T]) stats() DeliveryStats {
+	return DeliveryStats{Dropped: d.dropped.Load(), Coalesced: d.coalesced.Load()}
+}
+
+func (d *delivery[T]) send(value T) {
+	switch d.policy {
+	case DeliveryPolicyEnum.DropOldest:
+		d.sendDropOldest(value)
+	case DeliveryPolicyEnum.CoalesceLatest:
+		d.sendCoalesced(value)
+	default:
+		d.out <- value
+	}
+}
+
+func (d *delivery[T]) sendDropOldest(value T) {
+	for {
+		select {
+		case d.out <- value:
+			return
+		default:
+		}
+
+		// The channel is full, make room by discarding the oldest buffered value
+		select {
+		case <-d.out:
+			d.dropped.Add(1)
+		default:
+			// An unbuffered channel with nobody listening, there is nothing older to drop
+			if cap(d.out) == 0 {
+				d.dropped.Add(1)
+				return
+			}
+		}
+	}
+}
+
+// sendCoalesced holds value back as the latest for its key, the pump hands it to the consumer
+func (d *delivery[T]) sendCoalesced(value T) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.pending == nil {
+		d.pending = make(map[string]pendingValue[T])
+	}
+
+	key := d.key(value)
+	if _, found := d.pending[key]; found {
+		d.coalesced.Add(1)
+	} else {
+		d.queue = append(d.queue, key)
+	}
+	d.version++
+	d.pending[key] = pendingValue[T]{value: value, version: d.version}
+
+	if d.pumping {
+		// Let a pump waiting on the consumer pick up the newer value
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+		return
+	}
+
+	if d.done == nil {
+		d.done = make(chan struct{})
+	}
+	d.wake = make(chan struct{}, 1)
+	d.pumping = true
+	go d.pump(d.wake, d.done)
+}
+
+// pump delivers the latest value of each key in the order the keys were queued, exiting once the queue is empty or
+// the delivery is stopped
+func (d *delivery[T]) pump(wake chan struct{}, done chan struct{}) {
+	for {
+		d.mu.Lock()
+		if d.done != done {
+			d.mu.Unlock()
+			return
+		}
+		if len(d.queue) == 0 {
+			d.pumping = false
+			d.mu.Unlock()
+			return
+		}
+		key := d.queue[0]
+		next := d.pending[key]
+		d.mu.Unlock()
+
+		// A newer value which arrived since the last offer is taken before offering again
+		select {
+		case <-wake:
+			continue
+		default:
+		}
+
+		select {
+		case d.out <- next.value:
+			d.mu.Lock()
+			if d.done == done && len(d.queue) > 0 && d.queue[0] == key {
+				d.queue = d.queue[1:]
+				if d.pending[key].version == next.version {
+					delete(d.pending, key)
+				} else {
+					// A newer value arrived while this one was being received, it goes to the back of the queue
+					d.queue = append(d.queue, key)
+				}
+			}
+			d.mu.Unlock()
+		case <-wake:
+			// A newer value may have replaced the one being offered
+		case <-done:
+			return
+		}
+	}
+}
+
+// stop discards the values held back and ends the pump, so that it does not wait for a consumer which has gone
+func (d *delivery[T]) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.done != nil {
+		close(d.done)
+		d.done = nil
+	}
+	d.pending = nil
+	d.queue = nil
+	d.pumping = false
+}
@@ -0,0 +1,109 @@
+package streaming
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDropOldestDelivery(t *testing.T) {
+	// Arrange
+	out := make(chan MarketBook, 2)
+	d := newDelivery(out, nil)
+	d.policy = DeliveryPolicyEnum.DropOldest
+
+	// Act
+	for i := int64(1); i <= 5; i++ {
+		d.send(MarketBook{MarketID: "1.1", Version: i})
+	}
+
+	// Assert
+	assert.Equal(t, int64(4), (<-out).Version)
+	assert.Equal(t, int64(5), (<-out).Version)
+	assert.Equal(t, DeliveryStats{Dropped: 3}, d.stats())
+}
+
+func TestDropOldestDeliveryUnbuffered(t *testing.T) {
+	// Arrange
+	out := make(chan error)
+	d := newDelivery(out, nil)
+	d.policy = DeliveryPolicyEnum.DropOldest
+
+	// Act
+	d.send(&NoConnectionError{})
+
+	// Assert
+	assert.Equal(t, DeliveryStats{Dropped: 1}, d.stats())
+}
+
+func TestCoalesceLatestDelivery(t *testing.T) {
+	// Arrange
+	d := newDelivery(make(chan MarketBook, 64), func(book MarketBook) string { return book.MarketID })
+	out := d.setPolicy(DeliveryPolicyEnum.CoalesceLatest)
+
+	// Act
+	d.send(MarketBook{MarketID: "1.1", Version: 1})
+	d.send(MarketBook{MarketID: "1.2", Version: 1})
+	d.send(MarketBook{MarketID: "1.1", Version: 2})
+	d.send(MarketBook{MarketID: "1.2", Version: 2})
+	d.send(MarketBook{MarketID: "1.1", Version: 3})
+
+	// Assert
+	var received []MarketBook
+	for len(received) < 2 {
+		select {
+		case book := <-out:
+			received = append(received, book)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for coalesced updates")
+		}
+	}
+	assert.Equal(t, []MarketBook{
+		{MarketID: "1.1", Version: 3},
+		{MarketID: "1.2", Version: 2},
+	}, received)
+	assert.Equal(t, DeliveryStats{Coalesced: 3}, d.stats())
+	assert.Equal(t, 0, cap(out))
+}
+
+func TestCoalesceLatestDeliveryStop(t *testing.T) {
+	// Arrange
+	d := newDelivery(make(chan MarketBook, 64), func(book MarketBook) string { return book.MarketID })
+	out := d.setPolicy(DeliveryPolicyEnum.CoalesceLatest)
+	d.send(MarketBook{MarketID: "1.1", Version: 1})
+
+	// Act
+	d.stop()
+	d.send(MarketBook{MarketID: "1.1", Version: 2})
+
+	// Assert
+	select {
+	case book := <-out:
+		assert.Equal(t, int64(2), book.Version, "the update held back before stopping is discarded")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an update after stopping")
+	}
+	assert.Eventually(t, func() bool {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return !d.pumping
+	}, time.Second, time.Millisecond)
+}
+
+func TestSetDeliveryPolicies(t *testing.T) {
+	// Arrange
+	channels := newStreamChannels()
+
+	// Act
+	valid := channels.SetDeliveryPolicies(DeliveryPolicies{MarketUpdate: DeliveryPolicyEnum.CoalesceLatest, Err: DeliveryPolicyEnum.DropOldest})
+	invalid := channels.SetDeliveryPolicies(DeliveryPolicies{Status: DeliveryPolicyEnum.CoalesceLatest})
+
+	// Assert
+	assert.NoError(t, valid)
+	assert.Equal(t, DeliveryPolicyEnum.CoalesceLatest, channels.marketUpdates.policy)
+	assert.Equal(t, DeliveryPolicyEnum.Block, channels.orderUpdates.policy)
+	assert.Equal(t, 0, cap(channels.MarketUpdate))
+	assert.Equal(t, 64, cap(channels.OrderUpdate))
+	assert.Equal(t, &DeliveryPolicyError{Channel: "Status", Policy: DeliveryPolicyEnum.CoalesceLatest}, invalid)
+}
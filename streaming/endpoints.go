@@ -1,6 +1,14 @@
 package streaming
 
+import "crypto/tls"
+
 const (
 	LiveEndpoint        = "stream-api.betfair.com:443"
 	IntegrationEndpoint = "stream-api-integration.betfair.com:443"
 )
+
+// validEndpoint reports whether a Stream may connect to the endpoint. Only the Betfair endpoints are accepted unless a
+// custom TLS configuration has been supplied.
+func validEndpoint(endpoint string, tlsConfig *tls.Config) bool {
+	return endpoint == LiveEndpoint || endpoint == IntegrationEndpoint || tlsConfig != nil
+}
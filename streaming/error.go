@@ -1,5 +1,7 @@
 package streaming
 
+import "strconv"
+
 type NoConnectionError struct{}
 
 func (err *NoConnectionError) Error() string {
@@ -23,3 +25,47 @@ type EndpointError struct{}
 func (err *EndpointError) Error() string {
 	return "Invalid stream endpoint"
 }
+
+type DeliveryPolicyError struct {
+	Channel string
+	Policy  DeliveryPolicy
+}
+
+func (err *DeliveryPolicyError) Error() string {
+	return "Unsupported delivery policy " + string(err.Policy) + " for " + err.Channel
+}
+
+// SubscriptionError is returned when the Exchange replies to a subscription request with a FAILURE StatusMessage.
+type SubscriptionError struct {
+	ErrorCode    StatusErrorCode
+	ErrorMessage string
+}
+
+func (err *SubscriptionError) Error() string {
+	return "Subscription failed: " + string(err.ErrorCode) + " " + err.ErrorMessage
+}
+
+type SubscriptionLimitError struct {
+	Requested int
+	Limit     int
+}
+
+func (err *SubscriptionLimitError) Error() string {
+	return "Subscription of " + strconv.Itoa(err.Requested) + " markets exceeds the limit of " + strconv.Itoa(err.Limit)
+}
+
+type SubscriptionTimeoutError struct{}
+
+func (err *SubscriptionTimeoutError) Error() string {
+	return "Timed out waiting for subscription reply"
+}
+
+// NoPriceError is returned when a hedge cannot be calculated because a runner has no available price
+type NoPriceError struct {
+	MarketID    string
+	SelectionID int64
+}
+
+func (err *NoPriceError) Error() string {
+	return "No price available for selection " + strconv.FormatInt(err.SelectionID, 10) + " in market " + err.MarketID
+}
@@ -1,6 +1,12 @@
 package streaming
 
 import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jonachehilton/gofair/logging"
+	"github.com/jonachehilton/gofair/metrics"
 	"github.com/jonachehilton/gofair/streaming/models"
 )
 
@@ -10,6 +16,7 @@ const (
 	status              = "status"
 	marketChangeMessage = "mcm"
 	orderChangeMessage  = "ocm"
+	raceChangeMessage   = "rcm"
 
 	// Change types
 	subscribe   = "SUB_IMAGE"
@@ -31,22 +38,99 @@ type IOrderHandler interface {
 	OnUpdate(ChangeMessage models.OrderChangeMessage)
 }
 
+type IRaceHandler interface {
+	OnSubscribe(ChangeMessage models.RaceChangeMessage)
+	OnResubscribe(ChangeMessage models.RaceChangeMessage)
+	OnHeartbeat(ChangeMessage models.RaceChangeMessage)
+	OnUpdate(ChangeMessage models.RaceChangeMessage)
+}
+
+type IConnectionHandler interface {
+	OnConnection(ConnectionMessage models.ConnectionMessage)
+	OnStatus(StatusMessage models.StatusMessage)
+}
+
+// StreamHandler groups the handlers a user can register with a Stream. Each handler is called synchronously from
+// the read goroutine, so messages are received in the order they arrive on the wire. Nil fields are ignored.
+type StreamHandler struct {
+	Markets    IMarketHandler
+	Orders     IOrderHandler
+	Races      IRaceHandler
+	Connection IConnectionHandler
+}
+
 type eventHandler struct {
 	Markets  IMarketHandler
 	Orders   IOrderHandler
+	Races    IRaceHandler
+	handlers []StreamHandler
+	requests *requestTracker
 	channels *StreamChannels
+
+	// lock is shared by event handlers which update the same caches from different connections
+	lock sync.Locker
+
+	metrics      metrics.Recorder
+	logger       *slog.Logger
+	latency      *LatencyMonitor
+	connectionID string
+
+	// receivedAt is when the message being handled was read from the connection
+	receivedAt time.Time
+
+	// The last clk of each op, so that the clk age is only reset when it advances
+	clks map[string]string
 }
 
-func newEventHandler(channels *StreamChannels, marketCache *CachedMarkets, orderCache *CachedOrders) *eventHandler {
+func newEventHandler(channels *StreamChannels, marketCache *CachedMarkets, orderCache *CachedOrders, raceCache *CachedRaces) *eventHandler {
 	handler := new(eventHandler)
 	handler.channels = channels
-	handler.Markets = newMarketHandler(channels, marketCache)
-	handler.Orders = newOrderHandler(channels, orderCache)
+	markets := newMarketHandler(channels, marketCache)
+	markets.receivedAt = &handler.receivedAt
+	handler.Markets = markets
+	orders := newOrderHandler(channels, orderCache)
+	orders.receivedAt = &handler.receivedAt
+	handler.Orders = orders
+	handler.Races = newRaceHandler(channels, raceCache)
+	handler.metrics = metrics.Nop{}
+	handler.logger = logging.Discard()
+	handler.clks = make(map[string]string)
 	return handler
 }
 
+// register adds user handlers, optionally removing the built-in cache handlers so that the caches and channels are
+// left untouched
+func (eh *eventHandler) register(handlers []StreamHandler, replaceBuiltIn bool) {
+	if replaceBuiltIn {
+		eh.Markets = nil
+		eh.Orders = nil
+		eh.Races = nil
+		eh.channels = nil
+	}
+	eh.handlers = append(eh.handlers, handlers...)
+}
+
+// setSnapOptions selects the extras included in every MarketBook sent by the built-in market handler
+func (eh *eventHandler) setSnapOptions(indicators *IndicatorOptions, virtualise bool) {
+	if markets, ok := eh.Markets.(*marketEventHandler); ok {
+		markets.options = snapOptions{indicators: indicators, virtualise: virtualise}
+	}
+}
+
 // onData passes a blob to the appropriate event handler based on the op code
 func (eh *eventHandler) onData(op string, data []byte) {
+	eh.onMessage(op, data, time.Now())
+}
+
+// onMessage passes a blob read at receivedAt to the appropriate event handler based on the op code
+func (eh *eventHandler) onMessage(op string, data []byte, receivedAt time.Time) {
+
+	if eh.lock != nil {
+		eh.lock.Lock()
+		defer eh.lock.Unlock()
+	}
+
+	eh.receivedAt = receivedAt
 
 	switch op {
 	case connection:
@@ -57,63 +141,283 @@ func (eh *eventHandler) onData(op string, data []byte) {
 		eh.onMarketChangeMessage(data)
 	case orderChangeMessage:
 		eh.onOrderChangeMessage(data)
+	case raceChangeMessage:
+		eh.onRaceChangeMessage(data)
+	}
+}
+
+// onDecodeError logs a message which could not be decoded and is dropped
+func (eh *eventHandler) onDecodeError(op string, data []byte, err error) {
+	eh.logger.Warn("dropped stream message", "op", op, "connectionId", eh.connectionID, "error", err)
+	logging.Payload(eh.logger, "dropped stream message body", data, "op", op, "connectionId", eh.connectionID)
+}
+
+// onChange records a change message with the metrics Recorder
+func (eh *eventHandler) onChange(op string, changeType string, clk string) {
+	eh.metrics.StreamMessage(op, changeType)
+	if clk != "" && clk != eh.clks[op] {
+		eh.clks[op] = clk
+		eh.metrics.StreamClk(eh.connectionID, time.Now())
 	}
 }
 
-func (stream *eventHandler) onConnection(data []byte) {
+// onLatency measures the time from the Exchange publishing a market or order change message to it being read
+func (eh *eventHandler) onLatency(op string, publishTime int64) {
+	if publishTime == 0 {
+		return
+	}
+
+	d := latency(publishTime, eh.receivedAt)
+	eh.metrics.StreamLatency(op, d)
+
+	if eh.latency == nil {
+		return
+	}
+	if changed, stale := eh.latency.observe(op, d, eh.receivedAt, eh.onSilent); changed {
+		eh.metrics.StreamStale(stale)
+		if stale {
+			eh.logger.Warn("stream feed stale", "connectionId", eh.connectionID, "op", op, "latency", d)
+		} else {
+			eh.logger.Info("stream feed recovered", "connectionId", eh.connectionID, "op", op, "latency", d)
+		}
+	}
+}
+
+// onSilent records the feed becoming stale because no market or order change message has arrived for silence. It is
+// called from the LatencyMonitor's timer rather than the read goroutine.
+func (eh *eventHandler) onSilent(silence time.Duration) {
+	eh.metrics.StreamStale(true)
+	eh.logger.Warn("stream feed stale", "silence", silence)
+}
+
+// onProcessed records the time taken to handle a change message since start, the time it was received
+func (eh *eventHandler) onProcessed(op string, start time.Time) {
+	d := time.Since(start)
+	eh.metrics.StreamProcessing(op, d)
+	if eh.latency != nil {
+		eh.latency.processed(op, d)
+	}
+}
+
+// recordSizes reports the size of the built-in caches and the backlog on each channel once a change has been handled
+func (eh *eventHandler) recordSizes() {
+	if markets, ok := eh.Markets.(*marketEventHandler); ok {
+		eh.metrics.CacheSize("markets", len(markets.cache))
+	}
+	if orders, ok := eh.Orders.(*orderHandler); ok {
+		eh.metrics.CacheSize("orders", len(orders.cache))
+	}
+	if races, ok := eh.Races.(*raceEventHandler); ok {
+		eh.metrics.CacheSize("races", len(races.cache))
+	}
+	if eh.channels != nil {
+		eh.metrics.QueueDepth("MarketUpdate", len(eh.channels.MarketUpdate))
+		eh.metrics.QueueDepth("OrderUpdate", len(eh.channels.OrderUpdate))
+		eh.metrics.QueueDepth("RaceUpdate", len(eh.channels.RaceUpdate))
+		eh.metrics.QueueDepth("Status", len(eh.channels.Status))
+		eh.metrics.QueueDepth("Err", len(eh.channels.Err))
+	}
+}
+
+func (eh *eventHandler) onConnection(data []byte) {
+
+	connectionMessage := new(models.ConnectionMessage)
+	err := connectionMessage.UnmarshalJSON(data)
+	if err != nil {
+		eh.onDecodeError(connection, data, err)
+		return
+	}
+
+	eh.connectionID = connectionMessage.ConnectionID
+	eh.metrics.StreamMessage(connection, "")
+	eh.logger.Info("stream connected", "connectionId", eh.connectionID)
+
+	for _, handler := range eh.handlers {
+		if handler.Connection != nil {
+			handler.Connection.OnConnection(*connectionMessage)
+		}
+	}
 }
 
-func (stream *eventHandler) onStatus(data []byte) {
+func (eh *eventHandler) onStatus(data []byte) {
 
 	statusMessage := new(models.StatusMessage)
 	err := statusMessage.UnmarshalJSON(data)
 	if err != nil {
+		eh.onDecodeError(status, data, err)
 		return
 	}
 
-	stream.channels.Status <- *statusMessage
+	eh.metrics.StreamMessage(status, "")
+	if statusMessage.ErrorCode != "" {
+		eh.logger.Warn("stream status error", "connectionId", eh.connectionID, "id", statusMessage.ID(), "errorCode",
+			statusMessage.ErrorCode, "errorMessage", statusMessage.ErrorMessage, "connectionClosed", statusMessage.ConnectionClosed)
+	}
+
+	if eh.requests != nil {
+		eh.requests.onStatus(*statusMessage)
+	}
+
+	eh.notifyStatus(*statusMessage)
+
+	if eh.channels != nil {
+		eh.channels.statuses.send(*statusMessage)
+	}
+}
+
+// notifyStatus passes a StatusMessage to the user handlers only, this is used for the authentication reply which is
+// read before anyone can be listening on the Status channel
+func (eh *eventHandler) notifyStatus(statusMessage models.StatusMessage) {
+	for _, handler := range eh.handlers {
+		if handler.Connection != nil {
+			handler.Connection.OnStatus(statusMessage)
+		}
+	}
 }
 
-// onMarketChangeMessage passes a MarketChange blob to the appropriate event handler based on the Change type
+// onImage confirms a pending subscription once its initial image arrives, this carries the heartbeat and conflation
+// rates the Exchange has actually applied
+func (eh *eventHandler) onImage(id int32, heartbeatMs int64, conflateMs int64, initialClk string) {
+	if eh.requests != nil {
+		eh.requests.onImage(id, Subscription{HeartbeatMs: heartbeatMs, ConflateMs: conflateMs, InitialClk: initialClk})
+	}
+}
+
+// onMarketChangeMessage passes a MarketChange blob to the appropriate event handlers based on the Change type
 func (eh *eventHandler) onMarketChangeMessage(data []byte) {
 
-	marketChangeMessage := new(models.MarketChangeMessage)
+	message := new(models.MarketChangeMessage)
 
-	err := marketChangeMessage.UnmarshalJSON(data)
+	err := message.UnmarshalJSON(data)
 	if err != nil {
+		eh.onDecodeError(marketChangeMessage, data, err)
 		return
 	}
 
-	switch marketChangeMessage.Ct {
+	eh.onChange(marketChangeMessage, message.Ct, message.Clk)
+	eh.onLatency(marketChangeMessage, message.Pt)
+	defer eh.recordSizes()
+	defer eh.onProcessed(marketChangeMessage, eh.receivedAt)
+
+	if eh.Markets != nil {
+		dispatchMarketChangeMessage(eh.Markets, *message)
+	}
+	for _, handler := range eh.handlers {
+		if handler.Markets != nil {
+			dispatchMarketChangeMessage(handler.Markets, *message)
+		}
+	}
+
+	// The subscription is confirmed once the image has been applied, so the caches and handlers include it by the time
+	// Subscribe returns
+	if message.Ct == subscribe {
+		eh.onImage(message.ID(), message.HeartbeatMs, message.ConflateMs, message.InitialClk)
+		if eh.latency != nil {
+			eh.latency.setHeartbeat(time.Duration(message.HeartbeatMs) * time.Millisecond)
+		}
+	}
+}
+
+func dispatchMarketChangeMessage(handler IMarketHandler, message models.MarketChangeMessage) {
+	switch message.Ct {
 	case subscribe:
-		eh.Markets.OnSubscribe(*marketChangeMessage)
+		handler.OnSubscribe(message)
 	case resubscribe:
-		eh.Markets.OnResubscribe(*marketChangeMessage)
+		handler.OnResubscribe(message)
 	case heartbeat:
-		eh.Markets.OnHeartbeat(*marketChangeMessage)
+		handler.OnHeartbeat(message)
 	default:
-		eh.Markets.OnUpdate(*marketChangeMessage)
+		handler.OnUpdate(message)
 	}
 }
 
-// onOrderChangeMessage passes an OrderChange blob to the appropriate event handler based on the Change type
+// onOrderChangeMessage passes an OrderChange blob to the appropriate event handlers based on the Change type
 func (eh *eventHandler) onOrderChangeMessage(data []byte) {
 
-	orderChangeMessage := new(models.OrderChangeMessage)
+	message := new(models.OrderChangeMessage)
+
+	err := message.UnmarshalJSON(data)
+	if err != nil {
+		eh.onDecodeError(orderChangeMessage, data, err)
+		return
+	}
+
+	eh.onChange(orderChangeMessage, message.Ct, message.Clk)
+	eh.onLatency(orderChangeMessage, message.Pt)
+	defer eh.recordSizes()
+	defer eh.onProcessed(orderChangeMessage, eh.receivedAt)
 
-	err := orderChangeMessage.UnmarshalJSON(data)
+	if eh.Orders != nil {
+		dispatchOrderChangeMessage(eh.Orders, *message)
+	}
+	for _, handler := range eh.handlers {
+		if handler.Orders != nil {
+			dispatchOrderChangeMessage(handler.Orders, *message)
+		}
+	}
+
+	// The subscription is confirmed once the image has been applied, so the caches and handlers include it by the time
+	// Subscribe returns
+	if message.Ct == subscribe {
+		eh.onImage(message.ID(), message.HeartbeatMs, message.ConflateMs, message.InitialClk)
+		if eh.latency != nil {
+			eh.latency.setHeartbeat(time.Duration(message.HeartbeatMs) * time.Millisecond)
+		}
+	}
+}
+
+func dispatchOrderChangeMessage(handler IOrderHandler, message models.OrderChangeMessage) {
+	switch message.Ct {
+	case subscribe:
+		handler.OnSubscribe(message)
+	case resubscribe:
+		handler.OnResubscribe(message)
+	case heartbeat:
+		handler.OnHeartbeat(message)
+	default:
+		handler.OnUpdate(message)
+	}
+}
+
+// onRaceChangeMessage passes a RaceChange blob to the appropriate event handlers based on the Change type
+func (eh *eventHandler) onRaceChangeMessage(data []byte) {
+
+	message := new(models.RaceChangeMessage)
+
+	err := message.UnmarshalJSON(data)
 	if err != nil {
+		eh.onDecodeError(raceChangeMessage, data, err)
 		return
 	}
 
-	switch orderChangeMessage.Ct {
+	eh.onChange(raceChangeMessage, message.Ct, message.Clk)
+	defer eh.recordSizes()
+
+	if eh.Races != nil {
+		dispatchRaceChangeMessage(eh.Races, *message)
+	}
+	for _, handler := range eh.handlers {
+		if handler.Races != nil {
+			dispatchRaceChangeMessage(handler.Races, *message)
+		}
+	}
+
+	// The subscription is confirmed once the image has been applied, so the caches and handlers include it by the time
+	// Subscribe returns
+	if message.Ct == subscribe {
+		eh.onImage(message.ID(), message.HeartbeatMs, message.ConflateMs, message.InitialClk)
+	}
+}
+
+func dispatchRaceChangeMessage(handler IRaceHandler, message models.RaceChangeMessage) {
+	switch message.Ct {
 	case subscribe:
-		eh.Orders.OnSubscribe(*orderChangeMessage)
+		handler.OnSubscribe(message)
 	case resubscribe:
-		eh.Orders.OnResubscribe(*orderChangeMessage)
+		handler.OnResubscribe(message)
 	case heartbeat:
-		eh.Orders.OnHeartbeat(*orderChangeMessage)
+		handler.OnHeartbeat(message)
 	default:
-		eh.Orders.OnUpdate(*orderChangeMessage)
+		handler.OnUpdate(message)
 	}
 }
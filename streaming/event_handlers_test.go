@@ -3,6 +3,7 @@ package streaming
 import (
 	"testing"
 
+	"github.com/jonachehilton/gofair/streaming/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -11,11 +12,132 @@ func TestNewEventHandler(t *testing.T) {
 	channels := newStreamChannels()
 	marketCache := make(CachedMarkets)
 	orderCache := make(CachedOrders)
+	raceCache := make(CachedRaces)
 
 	// Act
-	handler := newEventHandler(channels, &marketCache, &orderCache)
+	handler := newEventHandler(channels, &marketCache, &orderCache, &raceCache)
 
 	// Assert
 	assert.NotNil(t, handler.Markets)
 	assert.NotNil(t, handler.Orders)
+	assert.NotNil(t, handler.Races)
+}
+
+type recordingHandler struct {
+	received []string
+}
+
+func (h *recordingHandler) OnSubscribe(changeMessage models.MarketChangeMessage) {
+	h.received = append(h.received, "subscribe:"+changeMessage.Clk)
+}
+
+func (h *recordingHandler) OnResubscribe(changeMessage models.MarketChangeMessage) {
+	h.received = append(h.received, "resubscribe:"+changeMessage.Clk)
+}
+
+func (h *recordingHandler) OnHeartbeat(changeMessage models.MarketChangeMessage) {
+	h.received = append(h.received, "heartbeat:"+changeMessage.Clk)
+}
+
+func (h *recordingHandler) OnUpdate(changeMessage models.MarketChangeMessage) {
+	h.received = append(h.received, "update:"+changeMessage.Clk)
+}
+
+func (h *recordingHandler) OnConnection(connectionMessage models.ConnectionMessage) {
+	h.received = append(h.received, "connection:"+connectionMessage.ConnectionID)
+}
+
+func (h *recordingHandler) OnStatus(statusMessage models.StatusMessage) {
+	h.received = append(h.received, "status:"+statusMessage.StatusCode)
+}
+
+var handlerTestMessages = []string{
+	`{"op":"connection","connectionId":"002-051134157842-432409"}`,
+	`{"op":"mcm","id":1,"initialClk":"AA==","clk":"AA==","ct":"SUB_IMAGE","pt":1,"mc":[{"id":"1.23","marketDefinition":{"status":"OPEN","runners":[{"id":1,"status":"ACTIVE"}]},"rc":[{"id":1,"ltp":2.5}]}]}`,
+	`{"op":"mcm","id":1,"clk":"AB==","pt":2,"mc":[{"id":"1.23","rc":[{"id":1,"ltp":2.6}]}]}`,
+	`{"op":"mcm","id":1,"clk":"AC==","ct":"HEARTBEAT","pt":3}`,
+	`{"op":"status","id":2,"statusCode":"SUCCESS"}`,
+}
+
+func replayHandlerTestMessages(t *testing.T, handler *eventHandler) {
+	for _, message := range handlerTestMessages {
+		op, err := getOp([]byte(message))
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.onData(op, []byte(message))
+	}
+}
+
+func TestAddHandlerReceivesMessagesInOrder(t *testing.T) {
+	// Arrange
+	channels := newStreamChannels()
+	marketCache := make(CachedMarkets)
+	orderCache := make(CachedOrders)
+	raceCache := make(CachedRaces)
+	handler := newEventHandler(channels, &marketCache, &orderCache, &raceCache)
+	recorder := new(recordingHandler)
+	handler.register([]StreamHandler{{Markets: recorder, Connection: recorder}}, false)
+
+	// Act
+	go func() { <-channels.Status }()
+	replayHandlerTestMessages(t, handler)
+
+	// Assert
+	assert.Equal(t, []string{
+		"connection:002-051134157842-432409",
+		"subscribe:AA==",
+		"update:AB==",
+		"heartbeat:AC==",
+		"status:SUCCESS",
+	}, recorder.received)
+	assert.Len(t, marketCache, 1)
+	assert.Len(t, channels.MarketUpdate, 2)
+}
+
+func TestReplaceHandlersBypassesCaches(t *testing.T) {
+	// Arrange
+	channels := newStreamChannels()
+	marketCache := make(CachedMarkets)
+	orderCache := make(CachedOrders)
+	raceCache := make(CachedRaces)
+	handler := newEventHandler(channels, &marketCache, &orderCache, &raceCache)
+	recorder := new(recordingHandler)
+	handler.register([]StreamHandler{{Markets: recorder, Connection: recorder}}, true)
+
+	// Act
+	replayHandlerTestMessages(t, handler)
+
+	// Assert
+	assert.Len(t, recorder.received, 5)
+	assert.Nil(t, handler.Markets)
+	assert.Empty(t, marketCache)
+	assert.Empty(t, channels.MarketUpdate)
+}
+
+func TestOrderImageReplacesCache(t *testing.T) {
+	// Arrange
+	channels := newStreamChannels()
+	marketCache := make(CachedMarkets)
+	orderCache := make(CachedOrders)
+	raceCache := make(CachedRaces)
+	handler := newEventHandler(channels, &marketCache, &orderCache, &raceCache)
+	messages := []string{
+		`{"op":"ocm","id":1,"clk":"AA==","pt":1,"oc":[{"id":"1.23","orc":[{"id":1,"uo":[{"id":"1","p":2,"s":5,"sr":5,"side":"B","status":"E"}]}]}]}`,
+		`{"op":"ocm","id":1,"initialClk":"AB==","clk":"AB==","ct":"SUB_IMAGE","pt":2,"oc":[{"id":"1.23","orc":[{"id":2,"mb":[[3,4]],"uo":[{"id":"2","p":3,"s":6,"sr":2,"side":"B","status":"E"}]}]}]}`,
+	}
+
+	// Act
+	for _, message := range messages {
+		handler.onData(orderChangeMessage, []byte(message))
+	}
+
+	// Assert
+	assert.Len(t, orderCache, 1)
+	book := orderCache["1.23"]
+	assert.Equal(t, int64(2), book.LastPublishTime)
+	assert.NotContains(t, book.Runners, int64(1))
+	assert.Equal(t, [][]float64{{3, 4}}, book.Runners[2].Mb)
+	assert.Equal(t, "2", book.Runners[2].Uo[0].ID)
+	assert.Len(t, channels.OrderUpdate, 2)
 }
@@ -0,0 +1,192 @@
+package streaming
+
+import (
+	"github.com/jonachehilton/gofair/price"
+)
+
+// DefaultIndicatorLevels is the number of ladder levels used for weight of money when IndicatorOptions.Levels is unset
+const DefaultIndicatorLevels = 3
+
+// IndicatorOptions configures the indicators calculated for each snapshot
+type IndicatorOptions struct {
+	// Levels is the number of ladder levels on each side used for weight of money
+	Levels int
+}
+
+// MarketIndicators are calculated across every active runner in a market
+type MarketIndicators struct {
+	// BackBookPercentage and LayBookPercentage are the sums of the implied probabilities of the best back and lay prices,
+	// as percentages normalised by the number of winners, so that 100 is a fair book for any market
+	BackBookPercentage float64
+	LayBookPercentage  float64
+}
+
+// RunnerIndicators are calculated from a single runner's ladders
+type RunnerIndicators struct {
+	// WeightOfMoney is the share of the volume available to back, out of the volume available on both sides
+	WeightOfMoney float64
+	// SpreadTicks is the number of ticks between the best back and best lay prices
+	SpreadTicks int
+	// VWAP is the volume weighted average of the traded prices
+	VWAP float64
+	// VolumeSinceSnapshot is the volume traded since the market was last snapped with indicators
+	VolumeSinceSnapshot float64
+	// ImpliedProbability is the runner's share of the back book, scaled so that the probabilities of all active runners
+	// sum to the number of winners
+	ImpliedProbability float64
+}
+
+func sumSizes(prices ByPrice, levels int) float64 {
+	total := 0.0
+	for i, level := range prices {
+		if levels > 0 && i >= levels {
+			break
+		}
+		total += level.Size.Float64()
+	}
+	return total
+}
+
+// WeightOfMoney returns the volume available to back as a fraction of the volume available on both sides over the
+// best levels of each ladder, or zero if both ladders are empty. A levels of zero or less uses the whole ladder.
+func (cache *RunnerCache) WeightOfMoney(levels int) float64 {
+	back := sumSizes(cache.AvailableToBack.Prices, levels)
+	lay := sumSizes(cache.AvailableToLay.Prices, levels)
+	if back+lay == 0 {
+		return 0
+	}
+	return back / (back + lay)
+}
+
+// SpreadTicks returns the number of ticks between the best back and best lay prices on the ladder
+func (cache *RunnerCache) SpreadTicks(ladder *price.Ladder) (int, error) {
+	back := cache.AvailableToBack.Prices.GetFirstItem().Price.Float64()
+	lay := cache.AvailableToLay.Prices.GetFirstItem().Price.Float64()
+	if back == 0 || lay == 0 {
+		return 0, &NoPriceError{SelectionID: cache.SelectionId}
+	}
+	return ladder.TicksBetween(back, lay)
+}
+
+// VWAP returns the volume weighted average traded price, or zero if nothing has traded
+func (cache *RunnerCache) VWAP() float64 {
+	volume, value := 0.0, 0.0
+	for _, traded := range cache.Traded.Prices {
+		volume += traded.Size.Float64()
+		value += traded.Price.Float64() * traded.Size.Float64()
+	}
+	if volume == 0 {
+		return 0
+	}
+	return value / volume
+}
+
+// Volume returns the total traded on the runner, from the traded ladder if it is subscribed to
+func (cache *RunnerCache) Volume() float64 {
+	if traded := sumSizes(cache.Traded.Prices, 0); traded > 0 {
+		return traded
+	}
+	return *cache.TradedVolume
+}
+
+func (cache *MarketCache) numberOfWinners() float64 {
+	if cache.MarketDefinition == nil || cache.MarketDefinition.NumberOfWinners <= 0 {
+		return 1
+	}
+	return float64(cache.MarketDefinition.NumberOfWinners)
+}
+
+// active reports whether a runner counts towards the book, runners without a definition are treated as active
+func (cache *MarketCache) active(selectionID int64) bool {
+	definition := cache.GetRunnerDefinition(selectionID)
+	return definition.Status == "" || definition.Status == "ACTIVE"
+}
+
+func bookPercentage(cache *MarketCache, best func(RunnerCache) float64) float64 {
+	total := 0.0
+	for id, runner := range cache.Runners {
+		if p := best(runner); p > 0 && cache.active(id) {
+			total += 100 / p
+		}
+	}
+	return total / cache.numberOfWinners()
+}
+
+func bestBack(runner RunnerCache) float64 {
+	return runner.AvailableToBack.Prices.GetFirstItem().Price.Float64()
+}
+
+func bestLay(runner RunnerCache) float64 {
+	return runner.AvailableToLay.Prices.GetFirstItem().Price.Float64()
+}
+
+// Indicators calculates the market wide indicators
+func (cache *MarketCache) Indicators() MarketIndicators {
+	return MarketIndicators{
+		BackBookPercentage: bookPercentage(cache, bestBack),
+		LayBookPercentage:  bookPercentage(cache, bestLay),
+	}
+}
+
+// VolumeSinceSnapshot returns the volume traded on a runner since the market was last snapped with indicators, by
+// SnapWithIndicators or by the built-in handler when Stream.Indicators is set. Snap and SnapVirtual leave the baseline
+// alone. The baseline is shared by everything snapping the market with indicators, a consumer which needs its own
+// should keep the result of Volumes and pass it to VolumeSince.
+func (cache *MarketCache) VolumeSinceSnapshot(selectionID int64) float64 {
+	return cache.VolumeSince(cache.snapVolumes, selectionID)
+}
+
+// Volumes returns the volume traded on each runner, for use as a baseline with VolumeSince
+func (cache *MarketCache) Volumes() map[int64]float64 {
+	volumes := make(map[int64]float64, len(cache.Runners))
+	for id, runner := range cache.Runners {
+		volumes[id] = runner.Volume()
+	}
+	return volumes
+}
+
+// VolumeSince returns the volume traded on a runner since the baseline returned by Volumes was taken
+func (cache *MarketCache) VolumeSince(baseline map[int64]float64, selectionID int64) float64 {
+	runner, ok := cache.Runners[selectionID]
+	if !ok {
+		return 0
+	}
+	return runner.Volume() - baseline[selectionID]
+}
+
+func (cache *MarketCache) resetVolumes() {
+	cache.snapVolumes = cache.Volumes()
+}
+
+// RunnerIndicators calculates the indicators of every runner. SpreadTicks is left at zero for runners without a price
+// on both sides.
+func (cache *MarketCache) RunnerIndicators(options IndicatorOptions) map[int64]RunnerIndicators {
+
+	levels := options.Levels
+	if levels == 0 {
+		levels = DefaultIndicatorLevels
+	}
+
+	// A line market without its range has no ladder, leaving SpreadTicks unset
+	ladder, _ := price.ForDefinition(cache.MarketDefinition)
+
+	book := bookPercentage(cache, bestBack) / 100 * cache.numberOfWinners()
+
+	indicators := make(map[int64]RunnerIndicators)
+	for id, runner := range cache.Runners {
+		runnerIndicators := RunnerIndicators{
+			WeightOfMoney:       runner.WeightOfMoney(levels),
+			VWAP:                runner.VWAP(),
+			VolumeSinceSnapshot: cache.VolumeSinceSnapshot(id),
+		}
+		if ladder != nil {
+			runnerIndicators.SpreadTicks, _ = runner.SpreadTicks(ladder)
+		}
+		if p := bestBack(runner); p > 0 && book > 0 && cache.active(id) {
+			runnerIndicators.ImpliedProbability = (1 / p) / book * cache.numberOfWinners()
+		}
+		indicators[id] = runnerIndicators
+	}
+
+	return indicators
+}
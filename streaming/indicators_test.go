@@ -0,0 +1,155 @@
+package streaming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/price"
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+func newIndicatorTestMarket(numberOfWinners int32, backs ...float64) *MarketCache {
+	definition := &models.MarketDefinition{NumberOfWinners: numberOfWinners}
+	change := &models.MarketChange{ID: "1.23", MarketDefinition: definition}
+	for i, back := range backs {
+		id := int64(i + 1)
+		definition.Runners = append(definition.Runners, &models.RunnerDefinition{ID: id, Status: "ACTIVE", SortPriority: int32(id)})
+		change.Rc = append(change.Rc, &models.RunnerChange{ID: id, Atb: [][]float64{{back, 10}}})
+	}
+	return newMarketCache(&models.MarketChangeMessage{Pt: 1}, change)
+}
+
+func TestRunnerIndicators(t *testing.T) {
+	// Arrange
+	runner := newRunnerCache(&models.RunnerChange{
+		ID:  1,
+		Atb: [][]float64{{2.0, 10}, {1.99, 20}, {1.98, 30}, {1.97, 100}},
+		Atl: [][]float64{{2.02, 30}, {2.04, 10}},
+		Trd: [][]float64{{2.0, 10}, {2.02, 30}},
+	})
+
+	// Act
+	spread, err := runner.SpreadTicks(price.Classic)
+
+	// Assert
+	assert.Equal(t, 0.6, runner.WeightOfMoney(3))
+	assert.InDelta(t, 160.0/200.0, runner.WeightOfMoney(0), 1e-9)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, spread)
+	assert.InDelta(t, 2.015, runner.VWAP(), 1e-9)
+	assert.Equal(t, 40.0, runner.Volume())
+}
+
+func TestRunnerIndicatorsEmptyLadders(t *testing.T) {
+	// Arrange
+	runner := newRunnerCache(&models.RunnerChange{ID: 1})
+
+	// Act
+	_, err := runner.SpreadTicks(price.Classic)
+
+	// Assert
+	assert.Equal(t, 0.0, runner.WeightOfMoney(3))
+	assert.Equal(t, 0.0, runner.VWAP())
+	assert.IsType(t, &NoPriceError{}, err)
+}
+
+func TestBookPercentage(t *testing.T) {
+	// Arrange
+	testCases := []struct {
+		numberOfWinners int32
+		backs           []float64
+		book            float64
+		probabilities   []float64
+	}{
+		{numberOfWinners: 1, backs: []float64{2, 4, 4}, book: 100, probabilities: []float64{0.5, 0.25, 0.25}},
+		{numberOfWinners: 1, backs: []float64{1.8, 3.5, 5}, book: 104.13, probabilities: []float64{0.5335, 0.2744, 0.1921}},
+		{numberOfWinners: 2, backs: []float64{1.5, 1.5, 3}, book: 83.33, probabilities: []float64{0.8, 0.8, 0.4}},
+	}
+
+	for _, testCase := range testCases {
+		market := newIndicatorTestMarket(testCase.numberOfWinners, testCase.backs...)
+
+		// Act
+		indicators := market.Indicators()
+		runners := market.RunnerIndicators(IndicatorOptions{})
+
+		// Assert
+		assert.InDelta(t, testCase.book, indicators.BackBookPercentage, 0.01)
+		assert.Equal(t, 0.0, indicators.LayBookPercentage)
+		total := 0.0
+		for i, probability := range testCase.probabilities {
+			assert.InDelta(t, probability, runners[int64(i+1)].ImpliedProbability, 0.0001)
+			total += runners[int64(i+1)].ImpliedProbability
+		}
+		assert.InDelta(t, float64(testCase.numberOfWinners), total, 1e-9)
+	}
+}
+
+func TestBookPercentageIgnoresRemovedRunners(t *testing.T) {
+	// Arrange
+	market := newIndicatorTestMarket(1, 2, 4, 4)
+	market.MarketDefinition.Runners[2].Status = "REMOVED"
+
+	// Act
+	indicators := market.Indicators()
+
+	// Assert
+	assert.Equal(t, 75.0, indicators.BackBookPercentage)
+}
+
+func TestVolumeSinceSnapshot(t *testing.T) {
+	// Arrange
+	changeMessage := &models.MarketChangeMessage{Pt: 1}
+	market := newMarketCache(changeMessage, &models.MarketChange{ID: "1.23", Rc: []*models.RunnerChange{{ID: 1, Trd: [][]float64{{2.0, 10}}}}})
+	first := market.SnapWithIndicators(IndicatorOptions{})
+
+	// Act
+	market.UpdateCache(changeMessage, &models.MarketChange{ID: "1.23", Rc: []*models.RunnerChange{{ID: 1, Trd: [][]float64{{2.0, 15}, {2.02, 5}}}}})
+	second := market.SnapWithIndicators(IndicatorOptions{})
+	third := market.SnapWithIndicators(IndicatorOptions{})
+
+	// Assert
+	assert.Equal(t, 10.0, first.Runners[0].Indicators.VolumeSinceSnapshot)
+	assert.Equal(t, 10.0, second.Runners[0].Indicators.VolumeSinceSnapshot)
+	assert.Equal(t, 0.0, third.Runners[0].Indicators.VolumeSinceSnapshot)
+	assert.NotNil(t, second.Indicators)
+	assert.Nil(t, market.Snap().Indicators)
+}
+
+func TestPlainSnapKeepsVolumeBaseline(t *testing.T) {
+	// Arrange
+	changeMessage := &models.MarketChangeMessage{Pt: 1}
+	market := newMarketCache(changeMessage, &models.MarketChange{ID: "1.23", Rc: []*models.RunnerChange{{ID: 1, Trd: [][]float64{{2.0, 10}}}}})
+	market.SnapWithIndicators(IndicatorOptions{})
+	baseline := market.Volumes()
+
+	// Act
+	market.UpdateCache(changeMessage, &models.MarketChange{ID: "1.23", Rc: []*models.RunnerChange{{ID: 1, Trd: [][]float64{{2.0, 15}}}}})
+	market.Snap()
+	market.SnapVirtual()
+
+	// Assert
+	assert.Equal(t, 5.0, market.VolumeSinceSnapshot(1))
+	assert.Equal(t, 5.0, market.VolumeSince(baseline, 1))
+	assert.Equal(t, 15.0, market.VolumeSince(nil, 1))
+}
+
+func TestStreamAttachesIndicators(t *testing.T) {
+	// Arrange
+	channels := newStreamChannels()
+	marketCache := make(CachedMarkets)
+	orderCache := make(CachedOrders)
+	raceCache := make(CachedRaces)
+	handler := newEventHandler(channels, &marketCache, &orderCache, &raceCache)
+	handler.setSnapOptions(&IndicatorOptions{Levels: 1}, false)
+
+	// Act
+	handler.onData(marketChangeMessage, []byte(`{"op":"mcm","id":1,"ct":"SUB_IMAGE","pt":1,"mc":[{"id":"1.23","img":true,"rc":[{"id":1,"atb":[[2.0,30],[1.99,100]],"atl":[[2.02,10]]}]}]}`))
+	book := <-channels.MarketUpdate
+
+	// Assert
+	assert.Equal(t, 0.75, book.Runners[0].Indicators.WeightOfMoney)
+	assert.Equal(t, 1, book.Runners[0].Indicators.SpreadTicks)
+	assert.Equal(t, 50.0, book.Indicators.BackBookPercentage)
+}
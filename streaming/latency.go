@@ -0,0 +1,273 @@
+package streaming
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultStaleThreshold is the latency above which a LatencyMonitor reports the feed as stale
+const DefaultStaleThreshold = 2 * time.Second
+
+// DefaultHeartbeat is the Exchange's heartbeat interval for subscriptions which do not request one
+const DefaultHeartbeat = 5 * time.Second
+
+// LatencyWindow is the number of recent messages a LatencySummary is calculated over
+const LatencyWindow = 1000
+
+// LatencySummary describes the most recent LatencyWindow measurements of one kind
+type LatencySummary struct {
+	Count int
+	Last  time.Duration
+	Mean  time.Duration
+	Max   time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+// LatencyStats is a snapshot of a LatencyMonitor. Latency is measured from the publish time (Pt) of each change
+// message to its local receive time, processing from the receive time until the caches and handlers have been updated.
+type LatencyStats struct {
+	Markets          LatencySummary
+	Orders           LatencySummary
+	MarketProcessing LatencySummary
+	OrderProcessing  LatencySummary
+
+	// LastReceived is when the last market or order change message, including heartbeats, was read
+	LastReceived time.Time
+
+	Stale bool
+}
+
+// window holds the last LatencyWindow measurements
+type window struct {
+	samples []time.Duration
+	next    int
+}
+
+func (w *window) add(d time.Duration) {
+	if len(w.samples) < LatencyWindow {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % LatencyWindow
+}
+
+func (w *window) summary() LatencySummary {
+	if len(w.samples) == 0 {
+		return LatencySummary{}
+	}
+
+	last := w.samples[len(w.samples)-1]
+	if len(w.samples) == LatencyWindow {
+		last = w.samples[(w.next+LatencyWindow-1)%LatencyWindow]
+	}
+
+	sorted := append([]time.Duration(nil), w.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	percentile := func(p int) time.Duration {
+		return sorted[(len(sorted)-1)*p/100]
+	}
+
+	return LatencySummary{
+		Count: len(sorted),
+		Last:  last,
+		Mean:  total / time.Duration(len(sorted)),
+		Max:   sorted[len(sorted)-1],
+		P50:   percentile(50),
+		P90:   percentile(90),
+		P99:   percentile(99),
+	}
+}
+
+// LatencyMonitor measures how far behind the Exchange the market and order streams are running. The feed is stale
+// while the latency of the most recent change message, heartbeats included, is above Threshold, or once no message
+// has arrived for Threshold plus the heartbeat interval, as a connection which has stalled sends nothing to measure.
+// A Stream and a StreamPool each share a single LatencyMonitor between their connections.
+type LatencyMonitor struct {
+	// Threshold is the latency above which the feed is stale, zero disables the stale signal
+	Threshold time.Duration
+
+	lock             sync.Mutex
+	markets          window
+	orders           window
+	marketProcessing window
+	orderProcessing  window
+	lastReceived     time.Time
+	stale            bool
+	callbacks        []func(stale bool, latency time.Duration)
+
+	// heartbeat is the longest heartbeat interval confirmed by a market or order subscription
+	heartbeat time.Duration
+	// silence fires when no change message has been observed for Threshold plus heartbeat since observedAt
+	silence    *time.Timer
+	observedAt time.Time
+	onSilent   func(silence time.Duration)
+}
+
+// NewLatencyMonitor creates a LatencyMonitor reporting the feed as stale above threshold
+func NewLatencyMonitor(threshold time.Duration) *LatencyMonitor {
+	return &LatencyMonitor{Threshold: threshold}
+}
+
+// Stats returns the latency and processing time of recent messages
+func (monitor *LatencyMonitor) Stats() LatencyStats {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+
+	return LatencyStats{
+		Markets:          monitor.markets.summary(),
+		Orders:           monitor.orders.summary(),
+		MarketProcessing: monitor.marketProcessing.summary(),
+		OrderProcessing:  monitor.orderProcessing.summary(),
+		LastReceived:     monitor.lastReceived,
+		Stale:            monitor.stale,
+	}
+}
+
+// Stale reports whether the latency of the most recent change message was above Threshold, or no message has arrived
+// within Threshold plus the heartbeat interval
+func (monitor *LatencyMonitor) Stale() bool {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+	return monitor.stale
+}
+
+// OnStale registers a callback which is called with the latency whenever the feed becomes stale or recovers. Callbacks
+// are called synchronously from the read goroutine, or from a timer goroutine with the time since the last message
+// when the feed becomes stale because messages have stopped arriving.
+func (monitor *LatencyMonitor) OnStale(callback func(stale bool, latency time.Duration)) {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+	monitor.callbacks = append(monitor.callbacks, callback)
+}
+
+// latency returns the time from a publish time, in milliseconds since the epoch, to receivedAt. Clock differences
+// between the Exchange and this machine can make it negative, in which case it is reported as zero.
+func latency(publishTime int64, receivedAt time.Time) time.Duration {
+	d := receivedAt.Sub(time.UnixMilli(publishTime))
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// observe records the latency of a change message, returning whether the feed has become stale or recovered. It
+// restarts the timer which marks the feed stale if no further message arrives, calling onSilent when it does.
+func (monitor *LatencyMonitor) observe(op string, d time.Duration, receivedAt time.Time, onSilent func(silence time.Duration)) (changed bool, stale bool) {
+	monitor.lock.Lock()
+
+	switch op {
+	case marketChangeMessage:
+		monitor.markets.add(d)
+	case orderChangeMessage:
+		monitor.orders.add(d)
+	}
+	monitor.lastReceived = receivedAt
+
+	stale = monitor.Threshold > 0 && d > monitor.Threshold
+	changed = stale != monitor.stale
+	monitor.stale = stale
+	callbacks := monitor.callbacks
+
+	monitor.observedAt = time.Now()
+	monitor.onSilent = onSilent
+	if monitor.Threshold > 0 {
+		if monitor.silence == nil {
+			monitor.silence = time.AfterFunc(monitor.silenceLimit(), monitor.checkSilence)
+		} else {
+			monitor.silence.Reset(monitor.silenceLimit())
+		}
+	}
+
+	monitor.lock.Unlock()
+
+	if changed {
+		for _, callback := range callbacks {
+			callback(stale, d)
+		}
+	}
+	return changed, stale
+}
+
+// processed records the time taken to handle a change message
+func (monitor *LatencyMonitor) processed(op string, d time.Duration) {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+
+	switch op {
+	case marketChangeMessage:
+		monitor.marketProcessing.add(d)
+	case orderChangeMessage:
+		monitor.orderProcessing.add(d)
+	}
+}
+
+// silenceLimit is how long the feed may go without a message before it is stale, a heartbeat arriving up to Threshold
+// late is not yet stale
+func (monitor *LatencyMonitor) silenceLimit() time.Duration {
+	heartbeat := monitor.heartbeat
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeat
+	}
+	return monitor.Threshold + heartbeat
+}
+
+// setHeartbeat records the heartbeat interval confirmed by a subscription, keeping the longest, and moves the silence
+// timer to the new limit
+func (monitor *LatencyMonitor) setHeartbeat(heartbeat time.Duration) {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+
+	if heartbeat <= 0 || (monitor.heartbeat > 0 && heartbeat <= monitor.heartbeat) {
+		return
+	}
+	monitor.heartbeat = heartbeat
+	if monitor.silence != nil && !monitor.observedAt.IsZero() {
+		monitor.silence.Reset(monitor.silenceLimit() - time.Since(monitor.observedAt))
+	}
+}
+
+// checkSilence marks the feed stale if no change message has been observed within the silence limit
+func (monitor *LatencyMonitor) checkSilence() {
+	monitor.lock.Lock()
+
+	silence := time.Since(monitor.observedAt)
+	if monitor.Threshold <= 0 || monitor.observedAt.IsZero() || monitor.stale || silence < monitor.silenceLimit() {
+		monitor.lock.Unlock()
+		return
+	}
+	monitor.stale = true
+	callbacks := monitor.callbacks
+	onSilent := monitor.onSilent
+
+	monitor.lock.Unlock()
+
+	for _, callback := range callbacks {
+		callback(true, silence)
+	}
+	if onSilent != nil {
+		onSilent(silence)
+	}
+}
+
+// stop cancels the silence timer, for when the connections are closed deliberately
+func (monitor *LatencyMonitor) stop() {
+	if monitor == nil {
+		return
+	}
+
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+	if monitor.silence != nil {
+		monitor.silence.Stop()
+	}
+	monitor.observedAt = time.Time{}
+}
@@ -0,0 +1,146 @@
+package streaming
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/metrics"
+)
+
+func TestLatencyWindowSummary(t *testing.T) {
+	// Arrange
+	var w window
+
+	// Act
+	for i := 1; i <= 100; i++ {
+		w.add(time.Duration(i) * time.Millisecond)
+	}
+	summary := w.summary()
+
+	// Assert
+	assert.Equal(t, LatencySummary{
+		Count: 100,
+		Last:  100 * time.Millisecond,
+		Mean:  50500 * time.Microsecond,
+		Max:   100 * time.Millisecond,
+		P50:   50 * time.Millisecond,
+		P90:   90 * time.Millisecond,
+		P99:   99 * time.Millisecond,
+	}, summary)
+}
+
+func TestLatencyWindowWrapsAround(t *testing.T) {
+	// Arrange
+	var w window
+
+	// Act
+	for i := 1; i <= LatencyWindow+5; i++ {
+		w.add(time.Duration(i))
+	}
+	summary := w.summary()
+
+	// Assert
+	assert.Equal(t, LatencyWindow, summary.Count)
+	assert.Equal(t, time.Duration(LatencyWindow+5), summary.Last)
+	assert.Equal(t, time.Duration(LatencyWindow+5), summary.Max)
+}
+
+func TestLatencyNeverNegative(t *testing.T) {
+	// Arrange
+	receivedAt := time.UnixMilli(1000)
+
+	// Act / Assert
+	assert.Equal(t, 250*time.Millisecond, latency(750, receivedAt))
+	assert.Equal(t, time.Duration(0), latency(1200, receivedAt))
+}
+
+func TestLatencyMonitorStaleFeed(t *testing.T) {
+	// Arrange
+	channels := newStreamChannels()
+	marketCache := make(CachedMarkets)
+	orderCache := make(CachedOrders)
+	raceCache := make(CachedRaces)
+	handler := newEventHandler(channels, &marketCache, &orderCache, &raceCache)
+	handler.latency = NewLatencyMonitor(time.Second)
+	t.Cleanup(handler.latency.stop)
+	transitions := []bool{}
+	handler.latency.OnStale(func(stale bool, latency time.Duration) {
+		transitions = append(transitions, stale)
+	})
+	publishTime := time.UnixMilli(1700000000000)
+	messages := []struct {
+		message string
+		delay   time.Duration
+	}{
+		{`{"op":"mcm","id":1,"ct":"SUB_IMAGE","pt":%d,"mc":[{"id":"1.23","rc":[{"id":1,"ltp":2.5}]}]}`, 100 * time.Millisecond},
+		{`{"op":"mcm","id":1,"pt":%d,"mc":[{"id":"1.23","rc":[{"id":1,"ltp":2.6}]}]}`, 1500 * time.Millisecond},
+		{`{"op":"mcm","id":1,"ct":"HEARTBEAT","pt":%d}`, 3 * time.Second},
+		{`{"op":"ocm","id":2,"pt":%d,"oc":[]}`, 200 * time.Millisecond},
+	}
+
+	// Act
+	stale := []bool{}
+	for _, m := range messages {
+		message := []byte(fmt.Sprintf(m.message, publishTime.UnixMilli()))
+		op, err := getOp(message)
+		assert.NoError(t, err)
+		handler.onMessage(op, message, publishTime.Add(m.delay))
+		stale = append(stale, handler.latency.Stale())
+	}
+	stats := handler.latency.Stats()
+
+	// Assert
+	assert.Equal(t, []bool{false, true, true, false}, stale)
+	assert.Equal(t, []bool{true, false}, transitions)
+	assert.Equal(t, 3, stats.Markets.Count)
+	assert.Equal(t, 3*time.Second, stats.Markets.Max)
+	assert.Equal(t, 200*time.Millisecond, stats.Orders.Last)
+	assert.Equal(t, 3, stats.MarketProcessing.Count)
+	assert.Equal(t, publishTime.Add(200*time.Millisecond), stats.LastReceived)
+	book := <-channels.MarketUpdate
+	assert.Equal(t, publishTime.UnixMilli(), book.PublishTime)
+	assert.Equal(t, publishTime.Add(100*time.Millisecond), book.ReceivedAt)
+}
+
+func TestLatencyMonitorStaleWithoutMessages(t *testing.T) {
+	// Arrange
+	channels := newStreamChannels()
+	marketCache := make(CachedMarkets)
+	orderCache := make(CachedOrders)
+	raceCache := make(CachedRaces)
+	handler := newEventHandler(channels, &marketCache, &orderCache, &raceCache)
+	collector := metrics.NewCollector()
+	handler.metrics = collector
+	handler.latency = NewLatencyMonitor(20 * time.Millisecond)
+	t.Cleanup(handler.latency.stop)
+	silences := make(chan time.Duration, 1)
+	handler.latency.OnStale(func(stale bool, latency time.Duration) {
+		if stale {
+			silences <- latency
+		}
+	})
+	message := []byte(fmt.Sprintf(`{"op":"mcm","id":1,"ct":"SUB_IMAGE","heartbeatMs":10,"pt":%d,"mc":[]}`, time.Now().UnixMilli()))
+
+	// Act
+	handler.onMessage(marketChangeMessage, message, time.Now())
+	fresh := handler.latency.Stale()
+	var silence time.Duration
+	select {
+	case silence = <-silences:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the feed to go stale")
+	}
+	var out strings.Builder
+	_, err := collector.WriteTo(&out)
+
+	// Assert
+	assert.False(t, fresh)
+	assert.True(t, handler.latency.Stale())
+	assert.GreaterOrEqual(t, silence, 30*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "gofair_stream_stale 1")
+}
@@ -2,17 +2,20 @@ package streaming
 
 import (
 	"sort"
+	"time"
 
+	"github.com/jonachehilton/gofair/decimal"
 	"github.com/jonachehilton/gofair/streaming/models"
 )
 
 func newMarketCache(changeMessage *models.MarketChangeMessage, marketChange *models.MarketChange) *MarketCache {
 	cache := &MarketCache{
-		&changeMessage.Pt,
-		marketChange.ID,
-		&marketChange.Tv,
-		marketChange.MarketDefinition,
-		make(map[int64]RunnerCache),
+		PublishTime:      &changeMessage.Pt,
+		MarketID:         marketChange.ID,
+		TradedVolume:     &marketChange.Tv,
+		MarketDefinition: marketChange.MarketDefinition,
+		Runners:          make(map[int64]RunnerCache),
+		snapVolumes:      make(map[int64]float64),
 	}
 	for _, runnerChange := range marketChange.Rc {
 		cache.Runners[runnerChange.ID] = *newRunnerCache(runnerChange)
@@ -22,112 +25,28 @@ func newMarketCache(changeMessage *models.MarketChangeMessage, marketChange *mod
 
 func newRunnerCache(change *models.RunnerChange) *RunnerCache {
 
-	// create traded data structure
-	var traded Available
-	for _, i := range change.Trd {
-		traded.Prices = append(
-			traded.Prices,
-			PriceSize{i[0], i[1]},
-		)
-	}
-	traded.Reverse = false
-
-	// create availableToBack data structure
-	var availableToBack Available
-	for _, i := range change.Atb {
-		availableToBack.Prices = append(
-			availableToBack.Prices,
-			PriceSize{i[0], i[1]},
-		)
-	}
-	availableToBack.Reverse = true
-
-	// create availableToLay data structure
-	var availableToLay Available
-	for _, i := range change.Atl {
-		availableToLay.Prices = append(
-			availableToLay.Prices,
-			PriceSize{i[0], i[1]},
-		)
-	}
-	availableToLay.Reverse = false
-
-	// create startingPriceBack data structure
-	var startingPriceBack Available
-	for _, i := range change.Spb {
-		startingPriceBack.Prices = append(
-			startingPriceBack.Prices,
-			PriceSize{i[0], i[1]},
-		)
-	}
-	startingPriceBack.Reverse = false
-
-	// create startingPriceLay data structure
-	var startingPriceLay Available
-	for _, i := range change.Spl {
-		startingPriceLay.Prices = append(
-			startingPriceLay.Prices,
-			PriceSize{i[0], i[1]},
-		)
-	}
-	startingPriceLay.Reverse = false
-
-	// create bestAvailableToBack data structure
-	var bestAvailableToBack AvailablePosition
-	for _, i := range change.Batb {
-		bestAvailableToBack.Prices = append(
-			bestAvailableToBack.Prices,
-			PositionPriceSize{i[0], i[1], i[2]},
-		)
-	}
-	bestAvailableToBack.Reverse = false
-
-	// create bestAvailableToLay data structure
-	var bestAvailableToLay AvailablePosition
-	for _, i := range change.Batl {
-		bestAvailableToLay.Prices = append(
-			bestAvailableToLay.Prices,
-			PositionPriceSize{i[0], i[1], i[2]},
-		)
-	}
-	bestAvailableToLay.Reverse = false
-
-	// create bestDisplayAvailableToBack data structure
-	var bestDisplayAvailableToBack AvailablePosition
-	for _, i := range change.Bdatb {
-		bestDisplayAvailableToBack.Prices = append(
-			bestDisplayAvailableToBack.Prices,
-			PositionPriceSize{i[0], i[1], i[2]},
-		)
-	}
-	bestDisplayAvailableToBack.Reverse = false
-
-	// create bestDisplayAvailableToLay data structure
-	var bestDisplayAvailableToLay AvailablePosition
-	for _, i := range change.Bdatl {
-		bestDisplayAvailableToLay.Prices = append(
-			bestDisplayAvailableToLay.Prices,
-			PositionPriceSize{i[0], i[1], i[2]},
-		)
-	}
-	bestDisplayAvailableToLay.Reverse = false
+	var lastTradedPrice, tradedVolume, startingPriceNear, startingPriceFar float64
 
 	cache := &RunnerCache{
 		SelectionId:                change.ID,
-		LastTradedPrice:            &change.Ltp,
-		TradedVolume:               &change.Tv,
-		StartingPriceNear:          &change.Spn,
-		StartingPriceFar:           &change.Spf,
-		Traded:                     &traded,
-		AvailableToBack:            &availableToBack,
-		AvailableToLay:             &availableToLay,
-		StartingPriceBack:          &startingPriceBack,
-		StartingPriceLay:           &startingPriceLay,
-		BestAvailableToBack:        &bestAvailableToBack,
-		BestAvailableToLay:         &bestAvailableToLay,
-		BestDisplayAvailableToBack: &bestDisplayAvailableToBack,
-		BestDisplayAvailableToLay:  &bestDisplayAvailableToLay,
-	}
+		LastTradedPrice:            &lastTradedPrice,
+		TradedVolume:               &tradedVolume,
+		StartingPriceNear:          &startingPriceNear,
+		StartingPriceFar:           &startingPriceFar,
+		Traded:                     &Available{Reverse: false},
+		AvailableToBack:            &Available{Reverse: true},
+		AvailableToLay:             &Available{Reverse: false},
+		StartingPriceBack:          &Available{Reverse: false},
+		StartingPriceLay:           &Available{Reverse: false},
+		BestAvailableToBack:        &AvailablePosition{Reverse: false},
+		BestAvailableToLay:         &AvailablePosition{Reverse: false},
+		BestDisplayAvailableToBack: &AvailablePosition{Reverse: false},
+		BestDisplayAvailableToLay:  &AvailablePosition{Reverse: false},
+	}
+
+	// Levels are applied as updates so that any with a size of zero are dropped
+	cache.UpdateCache(change)
+
 	return cache
 }
 
@@ -141,8 +60,8 @@ type AvailableInterface interface {
 }
 
 type PriceSize struct {
-	Price float64
-	Size  float64
+	Price decimal.Price
+	Size  decimal.Money
 }
 
 // sort.Interface for []PriceSize based on price
@@ -152,6 +71,14 @@ func (a ByPrice) Len() int           { return len(a) }
 func (a ByPrice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ByPrice) Less(i, j int) bool { return a[i].Price < a[j].Price }
 
+// GetFirstItem returns the best level of a sorted ladder
+func (a ByPrice) GetFirstItem() PriceSize {
+	if len(a) > 0 {
+		return a[0]
+	}
+	return PriceSize{}
+}
+
 func (a ByPrice) GetLastItem() PriceSize {
 
 	if len(a) == 1 {
@@ -165,8 +92,8 @@ func (a ByPrice) GetLastItem() PriceSize {
 
 type PositionPriceSize struct {
 	Position float64
-	Price    float64
-	Size     float64
+	Price    decimal.Price
+	Size     decimal.Money
 }
 
 // sort.Interface for []PositionPriceSize based on position
@@ -176,6 +103,14 @@ func (a ByPosition) Len() int           { return len(a) }
 func (a ByPosition) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ByPosition) Less(i, j int) bool { return a[i].Position < a[j].Position }
 
+// GetFirstItem returns the best level of a sorted ladder
+func (a ByPosition) GetFirstItem() PositionPriceSize {
+	if len(a) > 0 {
+		return a[0]
+	}
+	return PositionPriceSize{}
+}
+
 func (a ByPosition) GetLastItem() PositionPriceSize {
 
 	if len(a) == 1 {
@@ -205,11 +140,11 @@ func (available *AvailablePosition) Sort() {
 }
 
 func (available *AvailablePosition) UpdatePrice(count int, update []float64) {
-	available.Prices[count] = PositionPriceSize{update[0], update[1], update[2]}
+	available.Prices[count] = PositionPriceSize{update[0], decimal.Price(update[1]), decimal.Money(update[2])}
 }
 
 func (available *AvailablePosition) AppendPrice(update []float64) {
-	available.Prices = append(available.Prices, PositionPriceSize{update[0], update[1], update[2]})
+	available.Prices = append(available.Prices, PositionPriceSize{update[0], decimal.Price(update[1]), decimal.Money(update[2])})
 }
 
 func (available *AvailablePosition) RemovePrice(i int) {
@@ -222,7 +157,7 @@ func (available *AvailablePosition) Update(updates [][]float64) {
 	for _, update := range updates {
 		updated := false
 		for count, trade := range available.Prices {
-			if trade.Price == update[0] {
+			if trade.Position == update[0] {
 				if update[2] == 0 {
 					available.RemovePrice(count)
 					updated = true
@@ -259,11 +194,11 @@ func (available *Available) Sort() {
 }
 
 func (available *Available) UpdatePrice(count int, update []float64) {
-	available.Prices[count] = PriceSize{update[0], update[1]}
+	available.Prices[count] = PriceSize{decimal.Price(update[0]), decimal.Money(update[1])}
 }
 
 func (available *Available) AppendPrice(update []float64) {
-	available.Prices = append(available.Prices, PriceSize{update[0], update[1]})
+	available.Prices = append(available.Prices, PriceSize{decimal.Price(update[0]), decimal.Money(update[1])})
 }
 
 func (available *Available) RemovePrice(i int) {
@@ -276,7 +211,7 @@ func (available *Available) Update(updates [][]float64) {
 	for _, update := range updates {
 		updated := false
 		for count, trade := range available.Prices {
-			if trade.Price == update[0] {
+			if trade.Price == decimal.Price(update[0]) {
 				if update[1] == 0 {
 					available.RemovePrice(count)
 					updated = true
@@ -355,11 +290,16 @@ func (cache *RunnerCache) UpdateCache(change *models.RunnerChange) {
 }
 
 type MarketCache struct {
-	PublishTime      *int64
+	PublishTime *int64
+	// ReceivedAt is when the last change to the market was read from the connection
+	ReceivedAt       time.Time
 	MarketID         string
 	TradedVolume     *float64
 	MarketDefinition *models.MarketDefinition
 	Runners          map[int64]RunnerCache
+
+	// The traded volume of each runner when the market was last snapped with indicators
+	snapVolumes map[int64]float64
 }
 
 type CachedMarkets map[string]*MarketCache
@@ -368,7 +308,7 @@ func (cache *MarketCache) UpdateCache(changeMessage *models.MarketChangeMessage,
 	*cache.PublishTime = changeMessage.Pt
 
 	if marketChange.MarketDefinition != nil {
-		*cache.MarketDefinition = *marketChange.MarketDefinition
+		cache.MarketDefinition = marketChange.MarketDefinition
 	}
 	if marketChange.Tv != 0 {
 		*cache.TradedVolume = marketChange.Tv
@@ -385,6 +325,9 @@ func (cache *MarketCache) UpdateCache(changeMessage *models.MarketChangeMessage,
 }
 
 func (cache *MarketCache) GetRunnerDefinition(selectionId int64) models.RunnerDefinition {
+	if cache.MarketDefinition == nil {
+		return models.RunnerDefinition{}
+	}
 	for i := range cache.MarketDefinition.Runners {
 		if cache.MarketDefinition.Runners[i].ID == selectionId {
 			return *cache.MarketDefinition.Runners[i]
@@ -398,10 +341,10 @@ func (cache *MarketCache) GetRunnerDefinition(selectionId int64) models.RunnerDe
 func (cache *RunnerCache) Snap(definition models.RunnerDefinition) Runner {
 
 	exchangePrices := ExchangePrices{
-		BestAvailableToBack: cache.BestAvailableToBack.Prices.GetLastItem(),
-		BestAvailableToLay:  cache.BestAvailableToLay.Prices.GetLastItem(),
-		AvailableToBack:     cache.AvailableToBack.Prices.GetLastItem(),
-		AvailableToLay:      cache.AvailableToLay.Prices.GetLastItem(),
+		BestAvailableToBack: cache.BestAvailableToBack.Prices.GetFirstItem(),
+		BestAvailableToLay:  cache.BestAvailableToLay.Prices.GetFirstItem(),
+		AvailableToBack:     cache.AvailableToBack.Prices.GetFirstItem(),
+		AvailableToLay:      cache.AvailableToLay.Prices.GetFirstItem(),
 		TradedVolume:        cache.Traded.Prices.GetLastItem(),
 	}
 	return Runner{
@@ -409,35 +352,101 @@ func (cache *RunnerCache) Snap(definition models.RunnerDefinition) Runner {
 		Handicap:         definition.Hc,
 		Status:           definition.Status,
 		AdjustmentFactor: definition.AdjustmentFactor,
-		LastPriceTraded:  *cache.LastTradedPrice,
-		TotalMatched:     *cache.TradedVolume,
+		LastPriceTraded:  decimal.Price(*cache.LastTradedPrice),
+		TotalMatched:     decimal.Money(*cache.TradedVolume),
 		RemovalDate:      definition.RemovalDate,
 		EX:               exchangePrices,
 	}
 }
 
+// snapOptions selects the optional extras calculated for each MarketBook
+type snapOptions struct {
+	indicators *IndicatorOptions
+	virtualise bool
+}
+
 func (cache *MarketCache) Snap() MarketBook {
+	return cache.snap(snapOptions{})
+}
+
+// SnapWithIndicators snaps the market with the MarketIndicators and RunnerIndicators attached
+func (cache *MarketCache) SnapWithIndicators(options IndicatorOptions) MarketBook {
+	return cache.snap(snapOptions{indicators: &options})
+}
+
+// SnapVirtual snaps the market with the best AvailableToBack and AvailableToLay taken from the virtual ladders
+func (cache *MarketCache) SnapVirtual() MarketBook {
+	return cache.snap(snapOptions{virtualise: true})
+}
+
+func (cache *MarketCache) snap(options snapOptions) MarketBook {
 	runners := []Runner{}
+	priorities := make(map[int64]int32)
+
+	var indicators map[int64]RunnerIndicators
+	var marketIndicators *MarketIndicators
+	if options.indicators != nil {
+		indicators = cache.RunnerIndicators(*options.indicators)
+		market := cache.Indicators()
+		marketIndicators = &market
+	}
+
+	var virtual map[int64]VirtualLadder
+	if options.virtualise {
+		virtual = cache.Virtualise(1)
+	}
 
 	for _, runner := range cache.Runners {
 		runnerDefinition := cache.GetRunnerDefinition(runner.SelectionId)
-		runners = append(runners, runner.Snap(runnerDefinition))
+		priorities[runner.SelectionId] = runnerDefinition.SortPriority
+		snap := runner.Snap(runnerDefinition)
+		if runnerIndicators, ok := indicators[runner.SelectionId]; ok {
+			snap.Indicators = &runnerIndicators
+		}
+		if ladder, ok := virtual[runner.SelectionId]; ok {
+			snap.EX.AvailableToBack = ladder.AvailableToBack.GetFirstItem()
+			snap.EX.AvailableToLay = ladder.AvailableToLay.GetFirstItem()
+		}
+		runners = append(runners, snap)
+	}
+
+	// Only a snap with indicators moves the VolumeSinceSnapshot baseline, so that plain snaps taken by other consumers
+	// do not reset it
+	if options.indicators != nil {
+		cache.resetVolumes()
+	}
+
+	// Order runners as the Exchange does, by sort priority, with runners missing from the definition last
+	sort.Slice(runners, func(i, j int) bool {
+		a, b := priorities[runners[i].SelectionID], priorities[runners[j].SelectionID]
+		if a != b {
+			return b == 0 || (a != 0 && a < b)
+		}
+		return runners[i].SelectionID < runners[j].SelectionID
+	})
+
+	definition := cache.MarketDefinition
+	if definition == nil {
+		definition = new(models.MarketDefinition)
 	}
+
 	return MarketBook{
 		PublishTime:           *cache.PublishTime,
+		ReceivedAt:            cache.ReceivedAt,
 		MarketID:              cache.MarketID,
-		Status:                cache.MarketDefinition.Status,
-		BetDelay:              cache.MarketDefinition.BetDelay,
-		BspReconciled:         cache.MarketDefinition.BspReconciled,
-		Complete:              cache.MarketDefinition.Complete,
-		InPlay:                cache.MarketDefinition.InPlay,
-		NumberOfWinners:       cache.MarketDefinition.NumberOfWinners,
+		Status:                definition.Status,
+		BetDelay:              definition.BetDelay,
+		BspReconciled:         definition.BspReconciled,
+		Complete:              definition.Complete,
+		InPlay:                definition.InPlay,
+		NumberOfWinners:       definition.NumberOfWinners,
 		NumberOfRunners:       len(cache.Runners),
-		NumberOfActiveRunners: cache.MarketDefinition.NumberOfActiveRunners,
-		TotalMatched:          *cache.TradedVolume,
-		CrossMatching:         cache.MarketDefinition.CrossMatching,
-		RunnersVoidable:       cache.MarketDefinition.RunnersVoidable,
-		Version:               cache.MarketDefinition.Version,
+		NumberOfActiveRunners: definition.NumberOfActiveRunners,
+		TotalMatched:          decimal.Money(*cache.TradedVolume),
+		CrossMatching:         definition.CrossMatching,
+		RunnersVoidable:       definition.RunnersVoidable,
+		Version:               definition.Version,
 		Runners:               runners,
+		Indicators:            marketIndicators,
 	}
 }
@@ -0,0 +1,125 @@
+package streaming
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//go:generate go test -run TestMarketCacheGolden -update .
+
+// Run `go generate` (or `go test -run TestMarketCacheGolden -update`) in this directory to rewrite the golden files
+// after an intended change to the market cache.
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/markets")
+
+// replayMarketStream feeds every line of a recorded stream through the event handler and returns each MarketBook
+// snapshot produced, in order
+func replayMarketStream(t *testing.T, path string) []MarketBook {
+	channels := newStreamChannels()
+	marketCache := make(CachedMarkets)
+	orderCache := make(CachedOrders)
+	raceCache := make(CachedRaces)
+	handler := newEventHandler(channels, &marketCache, &orderCache, &raceCache)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	books := []MarketBook{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, readBufferSize), readBufferSize)
+	for scanner.Scan() {
+		op, err := getOp(scanner.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.onMessage(op, scanner.Bytes(), time.Time{})
+
+		for len(channels.MarketUpdate) > 0 {
+			books = append(books, <-channels.MarketUpdate)
+		}
+	}
+
+	return books
+}
+
+func TestMarketCacheGolden(t *testing.T) {
+	streams, err := filepath.Glob("testdata/markets/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stream := range streams {
+		golden := strings.TrimSuffix(stream, ".txt") + ".golden.json"
+
+		t.Run(filepath.Base(stream), func(t *testing.T) {
+			// Arrange/Act
+			books := replayMarketStream(t, stream)
+			actual, err := json.MarshalIndent(books, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual = append(actual, '\n')
+
+			if *update {
+				if err := os.WriteFile(golden, actual, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Assert
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, string(expected), string(actual))
+		})
+	}
+}
+
+func TestAvailableUpdate(t *testing.T) {
+	// Arrange
+	testCases := []struct {
+		reverse  bool
+		updates  [][]float64
+		expected ByPrice
+	}{
+		{reverse: true, updates: [][]float64{{2.0, 10}, {2.02, 5}, {1.98, 3}}, expected: ByPrice{{2.02, 5}, {2.0, 10}, {1.98, 3}}},
+		{reverse: false, updates: [][]float64{{2.0, 10}, {2.02, 5}, {1.98, 3}}, expected: ByPrice{{1.98, 3}, {2.0, 10}, {2.02, 5}}},
+		{reverse: false, updates: [][]float64{{2.0, 10}, {2.0, 12}}, expected: ByPrice{{2.0, 12}}},
+		{reverse: false, updates: [][]float64{{2.0, 10}, {2.02, 5}, {2.0, 0}}, expected: ByPrice{{2.02, 5}}},
+		{reverse: false, updates: [][]float64{{2.0, 0}}, expected: nil},
+	}
+
+	for _, testCase := range testCases {
+		available := &Available{Reverse: testCase.reverse}
+
+		// Act
+		available.Update(testCase.updates)
+
+		// Assert
+		assert.Equal(t, testCase.expected, available.Prices)
+	}
+}
+
+func TestAvailablePositionUpdate(t *testing.T) {
+	// Arrange
+	available := &AvailablePosition{}
+	available.Update([][]float64{{0, 2.0, 10}, {1, 1.99, 20}, {2, 1.98, 30}})
+
+	// Act
+	available.Update([][]float64{{0, 1.99, 20}, {1, 1.98, 30}, {2, 0, 0}})
+
+	// Assert
+	assert.Equal(t, ByPosition{{0, 1.99, 20}, {1, 1.98, 30}}, available.Prices)
+	assert.Equal(t, PositionPriceSize{0, 1.99, 20}, available.Prices.GetFirstItem())
+}
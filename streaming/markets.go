@@ -1,6 +1,8 @@
 package streaming
 
 import (
+	"time"
+
 	"github.com/jonachehilton/gofair/streaming/models"
 )
 
@@ -9,6 +11,10 @@ type marketEventHandler struct {
 	cache      CachedMarkets
 	initialClk string
 	clk        string
+	options    snapOptions
+
+	// receivedAt points at the receive time of the message being handled
+	receivedAt *time.Time
 }
 
 func newMarketHandler(channels *StreamChannels, marketCache *CachedMarkets) *marketEventHandler {
@@ -31,14 +37,18 @@ func (handler *marketEventHandler) onChangeMessage(changeMessage models.MarketCh
 		var marketCache *MarketCache
 		var found bool
 
-		if marketCache, found = handler.cache[marketChange.ID]; found {
+		// A market change flagged as an image replaces whatever is cached for the market
+		if marketCache, found = handler.cache[marketChange.ID]; found && !marketChange.Img {
 			marketCache.UpdateCache(&changeMessage, marketChange)
 		} else {
 			marketCache = newMarketCache(&changeMessage, marketChange)
 			handler.cache[marketChange.ID] = marketCache
 		}
+		if handler.receivedAt != nil {
+			marketCache.ReceivedAt = *handler.receivedAt
+		}
 
-		handler.channels.MarketUpdate <- marketCache.Snap()
+		handler.channels.marketUpdates.send(marketCache.snap(handler.options))
 	}
 }
 
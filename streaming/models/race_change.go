@@ -0,0 +1,158 @@
+package models
+
+// The race models are written by hand, in the style of the generated models, from the race definitions in
+// streaming/schema.json. Keep them in step with the schema when it changes.
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// RaceChange race change
+//
+// swagger:model RaceChange
+type RaceChange struct {
+
+	// Race Id - the id of the race
+	ID string `json:"id,omitempty"`
+
+	// Market Id - the id of the market the race is associated with
+	Mid string `json:"mid,omitempty"`
+
+	// Race Progress Change - the latest progress of the race as a whole (or null if un-changed)
+	Rpc *RaceProgressChange `json:"rpc,omitempty"`
+
+	// Race Runner Changes - a list of changes to runners (or null if un-changed)
+	Rrc []*RaceRunnerChange `json:"rrc"`
+}
+
+// Validate validates this race change
+func (m *RaceChange) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateRpc(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateRrc(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *RaceChange) validateRpc(formats strfmt.Registry) error {
+	if swag.IsZero(m.Rpc) { // not required
+		return nil
+	}
+
+	if m.Rpc != nil {
+		if err := m.Rpc.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("rpc")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *RaceChange) validateRrc(formats strfmt.Registry) error {
+	if swag.IsZero(m.Rrc) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Rrc); i++ {
+		if swag.IsZero(m.Rrc[i]) { // not required
+			continue
+		}
+
+		if m.Rrc[i] != nil {
+			if err := m.Rrc[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("rrc" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// ContextValidate validate this race change based on the context it is used
+func (m *RaceChange) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateRpc(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateRrc(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *RaceChange) contextValidateRpc(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Rpc != nil {
+		if err := m.Rpc.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("rpc")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *RaceChange) contextValidateRrc(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Rrc); i++ {
+
+		if m.Rrc[i] != nil {
+			if err := m.Rrc[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("rrc" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *RaceChange) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *RaceChange) UnmarshalBinary(b []byte) error {
+	var res RaceChange
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,390 @@
+package models
+
+// The race models are written by hand, in the style of the generated models, from the race definitions in
+// streaming/schema.json. Keep them in step with the schema when it changes.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// RaceChangeMessage race change message
+//
+// swagger:model RaceChangeMessage
+type RaceChangeMessage struct {
+	idField int32
+
+	// Token value (non-null) should be stored and passed in a RaceSubscriptionMessage to resume subscription (in case of disconnect)
+	Clk string `json:"clk,omitempty"`
+
+	// Conflate Milliseconds - the conflation rate (may differ from that requested if subscription is delayed)
+	ConflateMs int64 `json:"conflateMs,omitempty"`
+
+	// Change Type - set to indicate the type of change - if null this is a delta)
+	// Enum: [SUB_IMAGE RESUB_DELTA HEARTBEAT]
+	Ct string `json:"ct,omitempty"`
+
+	// Heartbeat Milliseconds - the heartbeat rate (may differ from requested: bounds are 500 to 30000)
+	HeartbeatMs int64 `json:"heartbeatMs,omitempty"`
+
+	// Token value (non-null) should be stored and passed in a RaceSubscriptionMessage to resume subscription (in case of disconnect)
+	InitialClk string `json:"initialClk,omitempty"`
+
+	// RaceChanges - the modifications to races (will be null on a heartbeat)
+	Rc []*RaceChange `json:"rc"`
+
+	// Publish Time (in millis since epoch) that the changes were generated
+	Pt int64 `json:"pt,omitempty"`
+
+	// Segment Type - if the change is split into multiple segments, this denotes the beginning and end of a change, and segments in between. Will be null if data is not segmented
+	// Enum: [SEG_START SEG SEG_END]
+	SegmentType string `json:"segmentType,omitempty"`
+
+	// Stream status: set to null if the exchange stream data is up to date and 503 if the downstream services are experiencing latencies
+	Status int32 `json:"status,omitempty"`
+}
+
+// ID gets the id of this subtype
+func (m *RaceChangeMessage) ID() int32 {
+	return m.idField
+}
+
+// SetID sets the id of this subtype
+func (m *RaceChangeMessage) SetID(val int32) {
+	m.idField = val
+}
+
+// Op gets the op of this subtype
+func (m *RaceChangeMessage) Op() string {
+	return "rcm"
+}
+
+// SetOp sets the op of this subtype
+func (m *RaceChangeMessage) SetOp(val string) {
+}
+
+// UnmarshalJSON unmarshals this object with a polymorphic type from a JSON structure
+func (m *RaceChangeMessage) UnmarshalJSON(raw []byte) error {
+	var data struct {
+
+		// Token value (non-null) should be stored and passed in a RaceSubscriptionMessage to resume subscription (in case of disconnect)
+		Clk string `json:"clk,omitempty"`
+
+		// Conflate Milliseconds - the conflation rate (may differ from that requested if subscription is delayed)
+		ConflateMs int64 `json:"conflateMs,omitempty"`
+
+		// Change Type - set to indicate the type of change - if null this is a delta)
+		// Enum: [SUB_IMAGE RESUB_DELTA HEARTBEAT]
+		Ct string `json:"ct,omitempty"`
+
+		// Heartbeat Milliseconds - the heartbeat rate (may differ from requested: bounds are 500 to 30000)
+		HeartbeatMs int64 `json:"heartbeatMs,omitempty"`
+
+		// Token value (non-null) should be stored and passed in a RaceSubscriptionMessage to resume subscription (in case of disconnect)
+		InitialClk string `json:"initialClk,omitempty"`
+
+		// RaceChanges - the modifications to races (will be null on a heartbeat)
+		Rc []*RaceChange `json:"rc"`
+
+		// Publish Time (in millis since epoch) that the changes were generated
+		Pt int64 `json:"pt,omitempty"`
+
+		// Segment Type - if the change is split into multiple segments, this denotes the beginning and end of a change, and segments in between. Will be null if data is not segmented
+		// Enum: [SEG_START SEG SEG_END]
+		SegmentType string `json:"segmentType,omitempty"`
+
+		// Stream status: set to null if the exchange stream data is up to date and 503 if the downstream services are experiencing latencies
+		Status int32 `json:"status,omitempty"`
+	}
+	buf := bytes.NewBuffer(raw)
+	dec := json.NewDecoder(buf)
+	dec.UseNumber()
+
+	if err := dec.Decode(&data); err != nil {
+		return err
+	}
+
+	var base struct {
+		/* Just the base type fields. Used for unmashalling polymorphic types.*/
+
+		ID int32 `json:"id,omitempty"`
+
+		Op string `json:"op,omitempty"`
+	}
+	buf = bytes.NewBuffer(raw)
+	dec = json.NewDecoder(buf)
+	dec.UseNumber()
+
+	if err := dec.Decode(&base); err != nil {
+		return err
+	}
+
+	var result RaceChangeMessage
+
+	result.idField = base.ID
+
+	if base.Op != result.Op() {
+		/* Not the type we're looking for. */
+		return errors.New(422, "invalid op value: %q", base.Op)
+	}
+
+	result.Clk = data.Clk
+	result.ConflateMs = data.ConflateMs
+	result.Ct = data.Ct
+	result.HeartbeatMs = data.HeartbeatMs
+	result.InitialClk = data.InitialClk
+	result.Rc = data.Rc
+	result.Pt = data.Pt
+	result.SegmentType = data.SegmentType
+	result.Status = data.Status
+
+	*m = result
+
+	return nil
+}
+
+// MarshalJSON marshals this object with a polymorphic type to a JSON structure
+func (m RaceChangeMessage) MarshalJSON() ([]byte, error) {
+	var b1, b2, b3 []byte
+	var err error
+	b1, err = json.Marshal(struct {
+
+		// Token value (non-null) should be stored and passed in a RaceSubscriptionMessage to resume subscription (in case of disconnect)
+		Clk string `json:"clk,omitempty"`
+
+		// Conflate Milliseconds - the conflation rate (may differ from that requested if subscription is delayed)
+		ConflateMs int64 `json:"conflateMs,omitempty"`
+
+		// Change Type - set to indicate the type of change - if null this is a delta)
+		// Enum: [SUB_IMAGE RESUB_DELTA HEARTBEAT]
+		Ct string `json:"ct,omitempty"`
+
+		// Heartbeat Milliseconds - the heartbeat rate (may differ from requested: bounds are 500 to 30000)
+		HeartbeatMs int64 `json:"heartbeatMs,omitempty"`
+
+		// Token value (non-null) should be stored and passed in a RaceSubscriptionMessage to resume subscription (in case of disconnect)
+		InitialClk string `json:"initialClk,omitempty"`
+
+		// RaceChanges - the modifications to races (will be null on a heartbeat)
+		Rc []*RaceChange `json:"rc"`
+
+		// Publish Time (in millis since epoch) that the changes were generated
+		Pt int64 `json:"pt,omitempty"`
+
+		// Segment Type - if the change is split into multiple segments, this denotes the beginning and end of a change, and segments in between. Will be null if data is not segmented
+		// Enum: [SEG_START SEG SEG_END]
+		SegmentType string `json:"segmentType,omitempty"`
+
+		// Stream status: set to null if the exchange stream data is up to date and 503 if the downstream services are experiencing latencies
+		Status int32 `json:"status,omitempty"`
+	}{
+
+		Clk: m.Clk,
+
+		ConflateMs: m.ConflateMs,
+
+		Ct: m.Ct,
+
+		HeartbeatMs: m.HeartbeatMs,
+
+		InitialClk: m.InitialClk,
+
+		Rc: m.Rc,
+
+		Pt: m.Pt,
+
+		SegmentType: m.SegmentType,
+
+		Status: m.Status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b2, err = json.Marshal(struct {
+		ID int32 `json:"id,omitempty"`
+
+		Op string `json:"op,omitempty"`
+	}{
+
+		ID: m.ID(),
+
+		Op: m.Op(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return swag.ConcatJSON(b1, b2, b3), nil
+}
+
+// Validate validates this race change message
+func (m *RaceChangeMessage) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateCt(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateRc(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateSegmentType(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+var raceChangeMessageTypeCtPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["SUB_IMAGE","RESUB_DELTA","HEARTBEAT"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		raceChangeMessageTypeCtPropEnum = append(raceChangeMessageTypeCtPropEnum, v)
+	}
+}
+
+// property enum
+func (m *RaceChangeMessage) validateCtEnum(path, location string, value string) error {
+	if err := validate.EnumCase(path, location, value, raceChangeMessageTypeCtPropEnum, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *RaceChangeMessage) validateCt(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Ct) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validateCtEnum("ct", "body", m.Ct); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *RaceChangeMessage) validateRc(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Rc) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Rc); i++ {
+		if swag.IsZero(m.Rc[i]) { // not required
+			continue
+		}
+
+		if m.Rc[i] != nil {
+			if err := m.Rc[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("rc" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+var raceChangeMessageTypeSegmentTypePropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["SEG_START","SEG","SEG_END"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		raceChangeMessageTypeSegmentTypePropEnum = append(raceChangeMessageTypeSegmentTypePropEnum, v)
+	}
+}
+
+// property enum
+func (m *RaceChangeMessage) validateSegmentTypeEnum(path, location string, value string) error {
+	if err := validate.EnumCase(path, location, value, raceChangeMessageTypeSegmentTypePropEnum, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *RaceChangeMessage) validateSegmentType(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.SegmentType) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validateSegmentTypeEnum("segmentType", "body", m.SegmentType); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validate this race change message based on the context it is used
+func (m *RaceChangeMessage) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateRc(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *RaceChangeMessage) contextValidateRc(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Rc); i++ {
+
+		if m.Rc[i] != nil {
+			if err := m.Rc[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("rc" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *RaceChangeMessage) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *RaceChangeMessage) UnmarshalBinary(b []byte) error {
+	var res RaceChangeMessage
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,69 @@
+package models
+
+// The race models are written by hand, in the style of the generated models, from the race definitions in
+// streaming/schema.json. Keep them in step with the schema when it changes.
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// RaceProgressChange race progress change
+//
+// swagger:model RaceProgressChange
+type RaceProgressChange struct {
+
+	// Feed Time - the time (in millis since epoch) the data was recorded at the track
+	Ft int64 `json:"ft,omitempty"`
+
+	// Gate Name - the name of the last gate (sectional marker) passed by the leader e.g. 1f, 2f or Finish
+	G string `json:"g,omitempty"`
+
+	// Jumps - the obstacles in the race (jump races only)
+	J []interface{} `json:"J"`
+
+	// Order - the selection ids of the runners in their current running order
+	Ord []int64 `json:"ord"`
+
+	// Progress - the distance (in metres) remaining for the leader to the finish line
+	Prg float64 `json:"prg,omitempty"`
+
+	// Running Time - the time elapsed (in seconds) since the start of the race
+	Rt float64 `json:"rt,omitempty"`
+
+	// Speed - the speed (in metres per second) of the leader
+	Spd float64 `json:"spd,omitempty"`
+
+	// Sectional Time - the time taken (in seconds) by the leader to cover the last section
+	St float64 `json:"st,omitempty"`
+}
+
+// Validate validates this race progress change
+func (m *RaceProgressChange) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this race progress change based on context it is used
+func (m *RaceProgressChange) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *RaceProgressChange) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *RaceProgressChange) UnmarshalBinary(b []byte) error {
+	var res RaceProgressChange
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
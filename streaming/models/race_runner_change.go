@@ -0,0 +1,66 @@
+package models
+
+// The race models are written by hand, in the style of the generated models, from the race definitions in
+// streaming/schema.json. Keep them in step with the schema when it changes.
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// RaceRunnerChange race runner change
+//
+// swagger:model RaceRunnerChange
+type RaceRunnerChange struct {
+
+	// Feed Time - the time (in millis since epoch) the data was recorded at the track
+	Ft int64 `json:"ft,omitempty"`
+
+	// Selection Id - the id of the runner (selection)
+	ID int64 `json:"id,omitempty"`
+
+	// Latitude - the latitude of the runner
+	Lat float64 `json:"lat,omitempty"`
+
+	// Longitude - the longitude of the runner
+	Long float64 `json:"long,omitempty"`
+
+	// Progress - the distance (in metres) remaining for the runner to the finish line
+	Prg float64 `json:"prg,omitempty"`
+
+	// Stride Frequency - the number of strides per second the runner is taking
+	Sfq float64 `json:"sfq,omitempty"`
+
+	// Speed - the speed (in metres per second) of the runner
+	Spd float64 `json:"spd,omitempty"`
+}
+
+// Validate validates this race runner change
+func (m *RaceRunnerChange) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this race runner change based on context it is used
+func (m *RaceRunnerChange) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *RaceRunnerChange) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *RaceRunnerChange) UnmarshalBinary(b []byte) error {
+	var res RaceRunnerChange
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
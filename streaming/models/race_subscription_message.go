@@ -0,0 +1,173 @@
+package models
+
+// The race models are written by hand, in the style of the generated models, from the race definitions in
+// streaming/schema.json. Keep them in step with the schema when it changes.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// RaceSubscriptionMessage race subscription message
+//
+// swagger:model RaceSubscriptionMessage
+type RaceSubscriptionMessage struct {
+	idField int32
+
+	// Token value delta (received in RaceChangeMessage) that should be passed to resume a subscription
+	Clk string `json:"clk,omitempty"`
+
+	// Heartbeat Milliseconds - the heartbeat rate (looped back on initial image after validation: bounds are 500 to 5000)
+	HeartbeatMs int64 `json:"heartbeatMs,omitempty"`
+
+	// Token value (received in initial RaceChangeMessage) that should be passed to resume a subscription
+	InitialClk string `json:"initialClk,omitempty"`
+}
+
+// ID gets the id of this subtype
+func (m *RaceSubscriptionMessage) ID() int32 {
+	return m.idField
+}
+
+// SetID sets the id of this subtype
+func (m *RaceSubscriptionMessage) SetID(val int32) {
+	m.idField = val
+}
+
+// Op gets the op of this subtype
+func (m *RaceSubscriptionMessage) Op() string {
+	return "raceSubscription"
+}
+
+// SetOp sets the op of this subtype
+func (m *RaceSubscriptionMessage) SetOp(val string) {
+}
+
+// UnmarshalJSON unmarshals this object with a polymorphic type from a JSON structure
+func (m *RaceSubscriptionMessage) UnmarshalJSON(raw []byte) error {
+	var data struct {
+
+		// Token value delta (received in RaceChangeMessage) that should be passed to resume a subscription
+		Clk string `json:"clk,omitempty"`
+
+		// Heartbeat Milliseconds - the heartbeat rate (looped back on initial image after validation: bounds are 500 to 5000)
+		HeartbeatMs int64 `json:"heartbeatMs,omitempty"`
+
+		// Token value (received in initial RaceChangeMessage) that should be passed to resume a subscription
+		InitialClk string `json:"initialClk,omitempty"`
+	}
+	buf := bytes.NewBuffer(raw)
+	dec := json.NewDecoder(buf)
+	dec.UseNumber()
+
+	if err := dec.Decode(&data); err != nil {
+		return err
+	}
+
+	var base struct {
+		/* Just the base type fields. Used for unmashalling polymorphic types.*/
+
+		ID int32 `json:"id,omitempty"`
+
+		Op string `json:"op,omitempty"`
+	}
+	buf = bytes.NewBuffer(raw)
+	dec = json.NewDecoder(buf)
+	dec.UseNumber()
+
+	if err := dec.Decode(&base); err != nil {
+		return err
+	}
+
+	var result RaceSubscriptionMessage
+
+	result.idField = base.ID
+
+	if base.Op != result.Op() {
+		/* Not the type we're looking for. */
+		return errors.New(422, "invalid op value: %q", base.Op)
+	}
+
+	result.Clk = data.Clk
+	result.HeartbeatMs = data.HeartbeatMs
+	result.InitialClk = data.InitialClk
+
+	*m = result
+
+	return nil
+}
+
+// MarshalJSON marshals this object with a polymorphic type to a JSON structure
+func (m RaceSubscriptionMessage) MarshalJSON() ([]byte, error) {
+	var b1, b2, b3 []byte
+	var err error
+	b1, err = json.Marshal(struct {
+
+		// Token value delta (received in RaceChangeMessage) that should be passed to resume a subscription
+		Clk string `json:"clk,omitempty"`
+
+		// Heartbeat Milliseconds - the heartbeat rate (looped back on initial image after validation: bounds are 500 to 5000)
+		HeartbeatMs int64 `json:"heartbeatMs,omitempty"`
+
+		// Token value (received in initial RaceChangeMessage) that should be passed to resume a subscription
+		InitialClk string `json:"initialClk,omitempty"`
+	}{
+
+		Clk: m.Clk,
+
+		HeartbeatMs: m.HeartbeatMs,
+
+		InitialClk: m.InitialClk,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b2, err = json.Marshal(struct {
+		ID int32 `json:"id,omitempty"`
+
+		Op string `json:"op,omitempty"`
+	}{
+
+		ID: m.ID(),
+
+		Op: m.Op(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return swag.ConcatJSON(b1, b2, b3), nil
+}
+
+// Validate validates this race subscription message
+func (m *RaceSubscriptionMessage) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this race subscription message based on context it is used
+func (m *RaceSubscriptionMessage) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *RaceSubscriptionMessage) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *RaceSubscriptionMessage) UnmarshalBinary(b []byte) error {
+	var res RaceSubscriptionMessage
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
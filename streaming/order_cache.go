@@ -1,14 +1,18 @@
 package streaming
 
 import (
+	"time"
+
 	"github.com/jonachehilton/gofair/streaming/models"
 )
 
 type OrderBookCache struct {
 	MarketID        string
 	LastPublishTime int64
-	Runners         map[int64]*models.OrderRunnerChange
-	Closed          bool
+	// LastReceivedAt is when the last update to the market's orders was read from the connection
+	LastReceivedAt time.Time
+	Runners        map[int64]*models.OrderRunnerChange
+	Closed         bool
 }
 
 func newOrderBookCache() *OrderBookCache {
@@ -66,7 +70,56 @@ func (cache *OrderBookCache) updateMatchedBacks(selectionID int64, update [][]fl
 }
 
 func (cache *OrderBookCache) updateUnmatchedOrders(selectionID int64, orders []*models.Order) {
-	cache.Runners[selectionID].Uo = orders
+
+	// Deltas only contain the orders which have changed, so merge them into the cached orders by bet id
+	for _, order := range orders {
+		orderFound := false
+		for i, cachedOrder := range cache.Runners[selectionID].Uo {
+			if cachedOrder.ID == order.ID {
+				orderFound = true
+				cache.Runners[selectionID].Uo[i] = order
+				break
+			}
+		}
+
+		if !orderFound {
+			cache.Runners[selectionID].Uo = append(cache.Runners[selectionID].Uo, order)
+		}
+	}
+}
+
+func (cache *OrderBookCache) updateStrategyMatches(selectionID int64, update map[string]models.StrategyMatchChange) {
+
+	runner := cache.Runners[selectionID]
+	if len(update) > 0 && runner.Smc == nil {
+		runner.Smc = make(map[string]models.StrategyMatchChange)
+	}
+
+	for strategy, change := range update {
+		cached := runner.Smc[strategy]
+		cached.Mb = mergeMatches(cached.Mb, change.Mb)
+		cached.Ml = mergeMatches(cached.Ml, change.Ml)
+		runner.Smc[strategy] = cached
+	}
+}
+
+// mergeMatches replaces the (price, size) entries of cached which are at the same price as an update
+func mergeMatches(cached [][]float64, update [][]float64) [][]float64 {
+	for _, entry := range update {
+		priceFound := false
+		for i, cachedEntry := range cached {
+			if cachedEntry[0] == entry[0] {
+				priceFound = true
+				cached[i] = entry
+				break
+			}
+		}
+
+		if !priceFound {
+			cached = append(cached, entry)
+		}
+	}
+	return cached
 }
 
 func (cache *OrderBookCache) update(data *models.OrderMarketChange, publishTime int64) {
@@ -90,6 +143,7 @@ func (cache *OrderBookCache) update(data *models.OrderMarketChange, publishTime
 			cache.updateMatchedBacks(orderChange.ID, orderChange.Mb)
 			cache.updateMatchedLays(orderChange.ID, orderChange.Ml)
 			cache.updateUnmatchedOrders(orderChange.ID, orderChange.Uo)
+			cache.updateStrategyMatches(orderChange.ID, orderChange.Smc)
 		}
 	}
 }
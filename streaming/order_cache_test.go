@@ -70,3 +70,37 @@ func TestUpdateMatchedBacks(t *testing.T) {
 		assert.Equal(t, cache.Runners[testCase.selectionID].Mb, testCase.expectedState)
 	}
 }
+
+func TestUpdateUnmatchedOrders(t *testing.T) {
+	// Arrange
+	cache := newOrderBookCache()
+	cache.Runners[1] = &models.OrderRunnerChange{
+		Uo: []*models.Order{{ID: "1", Sr: 2, Status: "E"}, {ID: "2", Sr: 5, Status: "E"}},
+	}
+
+	// Act
+	cache.updateUnmatchedOrders(1, []*models.Order{{ID: "2", Sm: 5, Status: "EC"}, {ID: "3", Sr: 1, Status: "E"}})
+
+	// Assert
+	assert.Equal(t, []*models.Order{{ID: "1", Sr: 2, Status: "E"}, {ID: "2", Sm: 5, Status: "EC"}, {ID: "3", Sr: 1, Status: "E"}}, cache.Runners[1].Uo)
+}
+
+func TestUpdateStrategyMatches(t *testing.T) {
+	// Arrange
+	cache := newOrderBookCache()
+	cache.Runners[1] = &models.OrderRunnerChange{
+		Smc: map[string]models.StrategyMatchChange{"s1": {Mb: [][]float64{{2, 5}}}},
+	}
+
+	// Act
+	cache.updateStrategyMatches(1, map[string]models.StrategyMatchChange{
+		"s1": {Mb: [][]float64{{2, 7}, {3, 1}}},
+		"s2": {Ml: [][]float64{{4, 2}}},
+	})
+
+	// Assert
+	assert.Equal(t, map[string]models.StrategyMatchChange{
+		"s1": {Mb: [][]float64{{2, 7}, {3, 1}}},
+		"s2": {Ml: [][]float64{{4, 2}}},
+	}, cache.Runners[1].Smc)
+}
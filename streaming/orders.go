@@ -1,6 +1,8 @@
 package streaming
 
 import (
+	"time"
+
 	"github.com/jonachehilton/gofair/streaming/models"
 )
 
@@ -9,6 +11,9 @@ type orderHandler struct {
 	channels   *StreamChannels
 	initialClk string
 	clk        string
+
+	// receivedAt points at the receive time of the message being handled
+	receivedAt *time.Time
 }
 
 func newOrderHandler(channels *StreamChannels, orderCache *CachedOrders) *orderHandler {
@@ -19,11 +24,11 @@ func newOrderHandler(channels *StreamChannels, orderCache *CachedOrders) *orderH
 }
 
 func (handler *orderHandler) OnSubscribe(orderChangeMessage models.OrderChangeMessage) {
-
+	handler.onChangeMessage(orderChangeMessage, true)
 }
 
 func (handler *orderHandler) OnResubscribe(orderChangeMessage models.OrderChangeMessage) {
-
+	handler.onChangeMessage(orderChangeMessage, false)
 }
 
 func (orderHandler *orderHandler) OnHeartbeat(orderChangeMessage models.OrderChangeMessage) {
@@ -31,6 +36,12 @@ func (orderHandler *orderHandler) OnHeartbeat(orderChangeMessage models.OrderCha
 }
 
 func (handler *orderHandler) OnUpdate(orderChangeMessage models.OrderChangeMessage) {
+	handler.onChangeMessage(orderChangeMessage, false)
+}
+
+// onChangeMessage applies an order change message to the cache. Every market in an image, the SUB_IMAGE sent when a
+// subscription starts, replaces what is cached for it, so that orders placed before the subscription are included.
+func (handler *orderHandler) onChangeMessage(orderChangeMessage models.OrderChangeMessage, image bool) {
 
 	if handler.initialClk == "" {
 		handler.initialClk = orderChangeMessage.Clk
@@ -44,7 +55,7 @@ func (handler *orderHandler) OnUpdate(orderChangeMessage models.OrderChangeMessa
 		// https://docs.developer.betfair.com/display/1smk3cen4v3lu3yomq5qye0ni/Exchange+Stream+API#ExchangeStreamAPI-OrderSubscriptionMessage
 
 		orderBookCache, found := handler.cache[orderMarketChange.ID]
-		if !found || orderMarketChange.FullImage {
+		if !found || orderMarketChange.FullImage || image {
 			orderBookCache = newOrderBookCache()
 			orderBookCache.MarketID = orderMarketChange.ID
 			orderBookCache.LastPublishTime = orderChangeMessage.Pt
@@ -52,6 +63,9 @@ func (handler *orderHandler) OnUpdate(orderChangeMessage models.OrderChangeMessa
 		}
 
 		orderBookCache.update(orderMarketChange, orderChangeMessage.Pt)
-		handler.channels.OrderUpdate <- *orderBookCache.Snap()
+		if handler.receivedAt != nil {
+			orderBookCache.LastReceivedAt = *handler.receivedAt
+		}
+		handler.channels.orderUpdates.send(*orderBookCache.Snap())
 	}
 }
@@ -0,0 +1,204 @@
+package streaming
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jonachehilton/gofair/logging"
+	"github.com/jonachehilton/gofair/metrics"
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+// StreamPool spreads a market subscription across several connections to the Exchange Stream API, so that more than
+// MaxSubscriptionLimit markets can be followed at once. Updates from every connection are merged into a single set of
+// caches and channels.
+type StreamPool struct {
+	requests *requestTracker
+	certs    *tls.Certificate
+	appKey   string
+	sessions []*session
+	lock     sync.Mutex
+
+	MarketCache CachedMarkets
+	OrderCache  CachedOrders
+	RaceCache   CachedRaces
+	Channels    *StreamChannels
+
+	// TLSConfig, if set, is used when dialling the Exchange. Setting it also allows Start to connect to endpoints other
+	// than LiveEndpoint and IntegrationEndpoint, such as a local test server.
+	TLSConfig *tls.Config
+
+	// Indicators, if set before Start, attaches MarketIndicators and RunnerIndicators to every MarketBook
+	Indicators *IndicatorOptions
+
+	// Virtualise, if set before Start, takes the best prices in every MarketBook from the virtual cross-matched ladders
+	Virtualise bool
+
+	// SubscriptionTimeout is how long the Subscribe calls wait for the Exchange to confirm a subscription
+	SubscriptionTimeout time.Duration
+
+	// Metrics, if set before Start, records the messages, subscriptions, caches and channels of every connection
+	Metrics metrics.Recorder
+
+	// Latency measures how far behind the Exchange the feed is running and reports when it becomes stale
+	Latency *LatencyMonitor
+
+	// Logger, if set before Start, logs authentication, subscriptions, decode failures and reconnects, and every message
+	// payload at debug level, with session tokens redacted
+	Logger *slog.Logger
+
+	started bool
+}
+
+// NewStreamPool generates a StreamPool object which can be subsequently used to connect to an Exchange Stream endpoint
+func NewStreamPool(certs *tls.Certificate, appKey string) (*StreamPool, error) {
+
+	pool := new(StreamPool)
+	pool.certs = certs
+	pool.appKey = appKey
+	pool.requests = newRequestTracker()
+	pool.SubscriptionTimeout = DefaultSubscriptionTimeout
+	pool.Latency = NewLatencyMonitor(DefaultStaleThreshold)
+
+	pool.MarketCache = make(CachedMarkets)
+	pool.OrderCache = make(CachedOrders)
+	pool.RaceCache = make(CachedRaces)
+	pool.Channels = newStreamChannels()
+
+	return pool, nil
+}
+
+// Start opens and authenticates up to the given number of connections, stopping early once the Exchange reports that
+// no more connections are available to the account. A value of zero or less opens every available connection.
+func (pool *StreamPool) Start(endpoint string, sessionToken string, connections int) error {
+
+	if !validEndpoint(endpoint, pool.TLSConfig) {
+		return &EndpointError{}
+	}
+
+	recorder := metrics.OrNop(pool.Metrics)
+	logger := logging.OrDiscard(pool.Logger)
+	if pool.started {
+		recorder.StreamReconnect()
+		logger.Info("stream reconnecting", "endpoint", endpoint)
+	}
+	pool.started = true
+
+	for connections <= 0 || len(pool.sessions) < connections {
+
+		eventHandler := newEventHandler(pool.Channels, &pool.MarketCache, &pool.OrderCache, &pool.RaceCache)
+		eventHandler.requests = pool.requests
+		eventHandler.setSnapOptions(pool.Indicators, pool.Virtualise)
+		eventHandler.lock = &pool.lock
+		eventHandler.metrics = recorder
+		eventHandler.logger = logger
+		eventHandler.latency = pool.Latency
+
+		session, err := newSession(endpoint, pool.certs, pool.TLSConfig, pool.appKey, sessionToken, pool.Channels, eventHandler)
+		if err != nil {
+			pool.Stop()
+			return err
+		}
+
+		pool.sessions = append(pool.sessions, session)
+
+		if session.connectionsAvailable <= 0 {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Stop tears down every connection in the pool
+func (pool *StreamPool) Stop() {
+	for _, session := range pool.sessions {
+		session.stop()
+	}
+	pool.sessions = nil
+	pool.Channels.stop()
+	pool.Latency.stop()
+}
+
+// Connections returns the number of open connections in the pool
+func (pool *StreamPool) Connections() int {
+	return len(pool.sessions)
+}
+
+// SubscribeToMarkets splits the market IDs into evenly sized shards, one per connection, and subscribes to each shard
+// using the MarketDataFilter. Each connection's previous market subscription is replaced, although a connection left
+// without a shard (when there are fewer markets than connections) keeps its previous subscription. It blocks until
+// every shard has been confirmed by the Exchange.
+func (pool *StreamPool) SubscribeToMarkets(marketIDs []string, marketDataFilter *models.MarketDataFilter) ([]*Subscription, error) {
+
+	if len(pool.sessions) == 0 {
+		return nil, &NoConnectionError{}
+	}
+
+	shards, err := shardMarkets(marketIDs, len(pool.sessions))
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]*Subscription, len(shards))
+	errs := make([]error, len(shards))
+
+	var wg sync.WaitGroup
+	for i, shard := range shards {
+		wg.Add(1)
+		go func(i int, shard []string) {
+			defer wg.Done()
+			request := models.MarketSubscriptionMessage{
+				MarketFilter:     &models.MarketFilter{MarketIds: shard},
+				MarketDataFilter: marketDataFilter,
+			}
+			subscriptions[i], errs[i] = pool.sessions[i].subscribe(&request, pool.requests, pool.SubscriptionTimeout)
+		}(i, shard)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return subscriptions, err
+		}
+	}
+
+	return subscriptions, nil
+}
+
+// SubscribeToOrders subscribes to the account's orders on the first connection only, as every connection would
+// otherwise receive the same order updates.
+func (pool *StreamPool) SubscribeToOrders() (*Subscription, error) {
+
+	if len(pool.sessions) == 0 {
+		return nil, &NoConnectionError{}
+	}
+
+	request := models.OrderSubscriptionMessage{SegmentationEnabled: true}
+	return pool.sessions[0].subscribe(&request, pool.requests, pool.SubscriptionTimeout)
+}
+
+// shardMarkets splits the market IDs into at most the given number of evenly sized shards, none of which exceed the
+// MaxSubscriptionLimit
+func shardMarkets(marketIDs []string, shards int) ([][]string, error) {
+
+	if len(marketIDs) > shards*MaxSubscriptionLimit {
+		return nil, &SubscriptionLimitError{Requested: len(marketIDs), Limit: shards * MaxSubscriptionLimit}
+	}
+
+	if len(marketIDs) < shards {
+		shards = len(marketIDs)
+	}
+
+	result := make([][]string, 0, shards)
+	for i := 0; i < shards; i++ {
+		// Spread any remainder over the first shards so that no two shards differ in size by more than one
+		start := i * len(marketIDs) / shards
+		end := (i + 1) * len(marketIDs) / shards
+		result = append(result, marketIDs[start:end])
+	}
+
+	return result, nil
+}
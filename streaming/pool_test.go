@@ -0,0 +1,96 @@
+package streaming
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/betfairtest"
+)
+
+func marketIDs(count int) []string {
+	ids := make([]string, count)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("1.%d", i)
+	}
+	return ids
+}
+
+func TestShardMarkets(t *testing.T) {
+	// Arrange
+	testCases := []struct {
+		markets     int
+		connections int
+		shardSizes  []int
+	}{
+		{markets: 0, connections: 3, shardSizes: []int{}},
+		{markets: 2, connections: 3, shardSizes: []int{1, 1}},
+		{markets: 200, connections: 1, shardSizes: []int{200}},
+		{markets: 450, connections: 3, shardSizes: []int{150, 150, 150}},
+		{markets: 401, connections: 3, shardSizes: []int{133, 134, 134}},
+		{markets: 600, connections: 3, shardSizes: []int{200, 200, 200}},
+	}
+
+	for _, testCase := range testCases {
+		// Act
+		ids := marketIDs(testCase.markets)
+		shards, err := shardMarkets(ids, testCase.connections)
+
+		// Assert
+		assert.NoError(t, err)
+		sizes := []int{}
+		var merged []string
+		for _, shard := range shards {
+			sizes = append(sizes, len(shard))
+			merged = append(merged, shard...)
+		}
+		assert.Equal(t, testCase.shardSizes, sizes)
+		assert.Equal(t, len(ids), len(merged))
+		if len(ids) > 0 {
+			assert.Equal(t, ids, merged)
+		}
+	}
+}
+
+func TestShardMarketsLimit(t *testing.T) {
+	// Act
+	shards, err := shardMarkets(marketIDs(601), 3)
+
+	// Assert
+	assert.Nil(t, shards)
+	assert.Equal(t, &SubscriptionLimitError{Requested: 601, Limit: 600}, err)
+}
+
+func TestStreamPoolNotStarted(t *testing.T) {
+	// Arrange
+	pool, _ := NewStreamPool(nil, "")
+
+	// Act
+	_, err := pool.SubscribeToMarkets(marketIDs(10), nil)
+
+	// Assert
+	assert.Equal(t, 0, pool.Connections())
+	assert.IsType(t, &NoConnectionError{}, err)
+}
+
+func TestStreamPoolShardsAcrossConnections(t *testing.T) {
+	// Arrange
+	server, err := betfairtest.NewStreamServer()
+	assert.NoError(t, err)
+	defer server.Close()
+	server.MaxConnections = 3
+	pool, _ := NewStreamPool(&server.Certificate.TLS, betfairtest.AppKey)
+	pool.TLSConfig = server.ClientTLSConfig()
+
+	// Act
+	err = pool.Start(server.Addr, betfairtest.SessionToken, 0)
+	assert.NoError(t, err)
+	defer pool.Stop()
+	subscriptions, err := pool.SubscribeToMarkets(marketIDs(450), nil)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pool.Connections())
+	assert.Len(t, subscriptions, 3)
+}
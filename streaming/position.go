@@ -0,0 +1,255 @@
+package streaming
+
+import (
+	"math"
+	"sort"
+)
+
+// RunnerPosition summarises the matched bets on a runner. The calculations assume a market with a single winner.
+type RunnerPosition struct {
+	SelectionID int64
+
+	// BackStake and LayStake are the total matched stakes on each side
+	BackStake float64
+	LayStake  float64
+
+	// BackProfit is what the matched backs return if the runner wins, and LayLiability is what the matched lays cost
+	BackProfit   float64
+	LayLiability float64
+
+	// IfWin is the profit or loss on the whole market if this runner wins, net of commission when requested
+	IfWin float64
+
+	// IfLose is the profit or loss of this runner's own bets if it loses
+	IfLose float64
+
+	// WorstCase is IfWin after any unfavourable subset of the executable unmatched orders on the market is matched
+	WorstCase float64
+}
+
+// MarketPosition summarises the matched and unmatched orders on a market
+type MarketPosition struct {
+	MarketID string
+
+	// Commission is the percentage rate deducted from winnings, zero unless commission was requested
+	Commission float64
+
+	Runners []RunnerPosition
+
+	// Exposure is the largest loss across all outcomes from matched bets, zero or negative
+	Exposure float64
+
+	// PotentialExposure is the largest loss across all outcomes if unmatched orders are also matched
+	PotentialExposure float64
+}
+
+// HedgeBet is a bet placed at the current best price to equalise the profit across all outcomes
+type HedgeBet struct {
+	SelectionID int64
+	Side        OrderSide
+	Price       float64
+	Size        float64
+}
+
+// Hedge is the set of bets which green up (or red out) a market, leaving the same Profit whichever runner wins
+type Hedge struct {
+	MarketID string
+	Profit   float64
+	Bets     []HedgeBet
+}
+
+// commissionRate returns the market's base rate, or zero if there is no market or commission was not requested
+func commissionRate(market *MarketCache, withCommission bool) float64 {
+	if !withCommission || market == nil || market.MarketDefinition == nil {
+		return 0
+	}
+	return market.MarketDefinition.MarketBaseRate
+}
+
+func applyCommission(profit float64, rate float64) float64 {
+	if profit > 0 {
+		return profit * (1 - rate/100)
+	}
+	return profit
+}
+
+// selections returns every runner with orders, plus the runners in the market definition, in selection id order
+func (cache *OrderBookCache) selections(market *MarketCache) []int64 {
+	seen := make(map[int64]bool)
+	ids := []int64{}
+
+	for id := range cache.Runners {
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if market != nil && market.MarketDefinition != nil {
+		for _, runner := range market.MarketDefinition.Runners {
+			if !seen[runner.ID] {
+				seen[runner.ID] = true
+				ids = append(ids, runner.ID)
+			}
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// grossIfWin returns the profit or loss of the market for each outcome, before commission
+func (cache *OrderBookCache) grossIfWin(ids []int64) (map[int64]float64, map[int64]RunnerPosition) {
+	positions := make(map[int64]RunnerPosition)
+	totalIfLose := 0.0
+
+	for _, id := range ids {
+		position := RunnerPosition{SelectionID: id}
+		if runner := cache.Runners[id]; runner != nil {
+			for _, matched := range runner.Mb {
+				position.BackStake += matched[1]
+				position.BackProfit += matched[1] * (matched[0] - 1)
+			}
+			for _, matched := range runner.Ml {
+				position.LayStake += matched[1]
+				position.LayLiability += matched[1] * (matched[0] - 1)
+			}
+		}
+		position.IfLose = position.LayStake - position.BackStake
+		totalIfLose += position.IfLose
+		positions[id] = position
+	}
+
+	ifWin := make(map[int64]float64)
+	for _, id := range ids {
+		position := positions[id]
+		ifWin[id] = position.BackProfit - position.LayLiability + totalIfLose - position.IfLose
+	}
+
+	return ifWin, positions
+}
+
+// Position calculates the profit or loss of each outcome of the market from the matched bets, along with the exposure
+// of the unmatched orders. The MarketCache supplies the runners without orders and, if withCommission is set, the
+// MarketBaseRate. It may be nil.
+func (cache *OrderBookCache) Position(market *MarketCache, withCommission bool) MarketPosition {
+
+	rate := commissionRate(market, withCommission)
+	ids := cache.selections(market)
+	ifWin, positions := cache.grossIfWin(ids)
+
+	// An unmatched order can only hurt an outcome in which it loses, so the worst case adds every losing order
+	worstCase := make(map[int64]float64)
+	for id, profit := range ifWin {
+		worstCase[id] = profit
+	}
+	for orderSelection, runner := range cache.Runners {
+		for _, order := range runner.Uo {
+			if OrderStatus(order.Status) != OrderStatusEnum.Executable || order.Sr <= 0 {
+				continue
+			}
+			for _, id := range ids {
+				switch {
+				case OrderSide(order.Side) == OrderSideEnum.Back && id != orderSelection:
+					worstCase[id] -= order.Sr
+				case OrderSide(order.Side) == OrderSideEnum.Lay && id == orderSelection:
+					worstCase[id] -= order.Sr * (order.P - 1)
+				}
+			}
+		}
+	}
+
+	result := MarketPosition{MarketID: cache.MarketID, Commission: rate}
+	for _, id := range ids {
+		position := positions[id]
+		position.IfWin = applyCommission(ifWin[id], rate)
+		position.WorstCase = applyCommission(worstCase[id], rate)
+		result.Exposure = math.Min(result.Exposure, position.IfWin)
+		result.PotentialExposure = math.Min(result.PotentialExposure, position.WorstCase)
+		result.Runners = append(result.Runners, position)
+	}
+
+	return result
+}
+
+// GreenUp calculates the bets which, placed at the best available prices in the MarketCache, leave the same profit (or
+// loss) whichever runner wins. Both backing and laying are considered and the one leaving the larger profit is
+// returned. If withCommission is set the Profit is net of the market's MarketBaseRate. A NoPriceError is returned if a
+// runner needing a hedge has no price available.
+func (cache *OrderBookCache) GreenUp(market *MarketCache, withCommission bool) (*Hedge, error) {
+
+	if market == nil {
+		return nil, &NoPriceError{MarketID: cache.MarketID}
+	}
+
+	ids := cache.selections(market)
+	ifWin, _ := cache.grossIfWin(ids)
+
+	back, backErr := hedge(ids, ifWin, market, OrderSideEnum.Back)
+	lay, layErr := hedge(ids, ifWin, market, OrderSideEnum.Lay)
+
+	best := back
+	if backErr != nil || (layErr == nil && lay.Profit > back.Profit) {
+		best = lay
+	}
+	if backErr != nil && layErr != nil {
+		return nil, backErr
+	}
+
+	best.MarketID = cache.MarketID
+	best.Profit = applyCommission(best.Profit, commissionRate(market, withCommission))
+	return best, nil
+}
+
+// hedge equalises every outcome using bets on a single side.
+//
+// Backing runner r for S at price B adds S*B to outcome r relative to every other outcome, so with K the largest
+// current outcome each runner is backed for (K - ifWin[r]) / B and the profit is K less the total staked. Laying is
+// the mirror image, bringing every outcome down to the smallest.
+func hedge(ids []int64, ifWin map[int64]float64, market *MarketCache, side OrderSide) (*Hedge, error) {
+
+	if len(ids) == 0 {
+		return &Hedge{}, nil
+	}
+
+	target := ifWin[ids[0]]
+	for _, id := range ids {
+		if side == OrderSideEnum.Back {
+			target = math.Max(target, ifWin[id])
+		} else {
+			target = math.Min(target, ifWin[id])
+		}
+	}
+
+	result := &Hedge{Profit: target}
+	for _, id := range ids {
+		difference := math.Abs(target - ifWin[id])
+		if difference < 0.005 {
+			continue
+		}
+
+		price := bestPrice(market, id, side)
+		if price <= 1 {
+			return nil, &NoPriceError{MarketID: market.MarketID, SelectionID: id}
+		}
+
+		size := difference / price
+		result.Bets = append(result.Bets, HedgeBet{SelectionID: id, Side: side, Price: price, Size: size})
+		if side == OrderSideEnum.Back {
+			result.Profit -= size
+		} else {
+			result.Profit += size
+		}
+	}
+
+	return result, nil
+}
+
+// bestPrice returns the best price at which a bet on the given side would be matched immediately
+func bestPrice(market *MarketCache, selectionID int64, side OrderSide) float64 {
+	runner, ok := market.Runners[selectionID]
+	if !ok {
+		return 0
+	}
+	if side == OrderSideEnum.Back {
+		return runner.AvailableToBack.Prices.GetFirstItem().Price.Float64()
+	}
+	return runner.AvailableToLay.Prices.GetFirstItem().Price.Float64()
+}
@@ -0,0 +1,147 @@
+package streaming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+func newPositionTestMarket() (*OrderBookCache, *MarketCache) {
+	orders := newOrderBookCache()
+	orders.MarketID = "1.23"
+	orders.Runners[1] = &models.OrderRunnerChange{
+		ID: 1,
+		Mb: [][]float64{{3.0, 10}},
+		Uo: []*models.Order{{ID: "2", P: 3.2, S: 5, Sr: 5, Side: "B", Status: "E"}},
+	}
+	orders.Runners[2] = &models.OrderRunnerChange{
+		ID: 2,
+		Uo: []*models.Order{
+			{ID: "3", P: 3.0, S: 4, Sr: 4, Side: "L", Status: "E"},
+			{ID: "4", P: 3.0, S: 4, Sm: 4, Side: "L", Status: "EC"},
+		},
+	}
+
+	changeMessage := &models.MarketChangeMessage{Pt: 1}
+	market := newMarketCache(changeMessage, &models.MarketChange{
+		ID: "1.23",
+		MarketDefinition: &models.MarketDefinition{
+			MarketBaseRate: 5,
+			Runners:        []*models.RunnerDefinition{{ID: 1}, {ID: 2}, {ID: 3}},
+		},
+		Rc: []*models.RunnerChange{
+			{ID: 1, Atb: [][]float64{{2.5, 100}}, Atl: [][]float64{{2.52, 100}}},
+			{ID: 2, Atb: [][]float64{{1.6, 100}}, Atl: [][]float64{{1.62, 100}}},
+			{ID: 3, Atb: [][]float64{{20, 100}}, Atl: [][]float64{{22, 100}}},
+		},
+	})
+
+	return orders, market
+}
+
+func TestPosition(t *testing.T) {
+	// Arrange
+	orders, market := newPositionTestMarket()
+
+	// Act
+	position := orders.Position(market, false)
+
+	// Assert
+	assert.Equal(t, "1.23", position.MarketID)
+	assert.Equal(t, 0.0, position.Commission)
+	assert.Equal(t, []RunnerPosition{
+		{SelectionID: 1, BackStake: 10, BackProfit: 20, IfWin: 20, IfLose: -10, WorstCase: 20},
+		{SelectionID: 2, IfWin: -10, WorstCase: -23},
+		{SelectionID: 3, IfWin: -10, WorstCase: -15},
+	}, position.Runners)
+	assert.Equal(t, -10.0, position.Exposure)
+	assert.Equal(t, -23.0, position.PotentialExposure)
+}
+
+func TestPositionWithCommission(t *testing.T) {
+	// Arrange
+	orders, market := newPositionTestMarket()
+
+	// Act
+	position := orders.Position(market, true)
+
+	// Assert
+	assert.Equal(t, 5.0, position.Commission)
+	assert.Equal(t, 19.0, position.Runners[0].IfWin)
+	assert.Equal(t, -10.0, position.Runners[1].IfWin)
+}
+
+func TestPositionWithoutMarket(t *testing.T) {
+	// Arrange
+	orders, _ := newPositionTestMarket()
+
+	// Act
+	position := orders.Position(nil, true)
+
+	// Assert
+	assert.Len(t, position.Runners, 2)
+	assert.Equal(t, 0.0, position.Commission)
+}
+
+func TestGreenUp(t *testing.T) {
+	// Arrange
+	orders, market := newPositionTestMarket()
+
+	// Act
+	hedge, err := orders.GreenUp(market, false)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, hedge.Bets, 1)
+	assert.Equal(t, int64(1), hedge.Bets[0].SelectionID)
+	assert.Equal(t, OrderSideEnum.Lay, hedge.Bets[0].Side)
+	assert.Equal(t, 2.52, hedge.Bets[0].Price)
+	assert.InDelta(t, 11.9048, hedge.Bets[0].Size, 0.0001)
+	assert.InDelta(t, 1.9048, hedge.Profit, 0.0001)
+
+	// Every outcome should now show the same profit
+	lay := hedge.Bets[0]
+	assert.InDelta(t, hedge.Profit, 20-lay.Size*(lay.Price-1), 0.0001)
+	assert.InDelta(t, hedge.Profit, -10+lay.Size, 0.0001)
+}
+
+func TestGreenUpWithCommission(t *testing.T) {
+	// Arrange
+	orders, market := newPositionTestMarket()
+
+	// Act
+	hedge, err := orders.GreenUp(market, true)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.9048*0.95, hedge.Profit, 0.0001)
+}
+
+func TestRedOut(t *testing.T) {
+	// Arrange
+	orders, market := newPositionTestMarket()
+	orders.Runners[1].Mb = [][]float64{{2.0, 10}}
+
+	// Act
+	hedge, err := orders.GreenUp(market, true)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, hedge.Profit < 0)
+}
+
+func TestGreenUpNoPrice(t *testing.T) {
+	// Arrange
+	orders, market := newPositionTestMarket()
+	market.Runners[1].AvailableToLay.Clear()
+	market.Runners[2].AvailableToBack.Clear()
+
+	// Act
+	hedge, err := orders.GreenUp(market, false)
+
+	// Assert
+	assert.Nil(t, hedge)
+	assert.IsType(t, &NoPriceError{}, err)
+}
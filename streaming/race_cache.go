@@ -0,0 +1,88 @@
+package streaming
+
+import (
+	"sort"
+
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+// RaceCache holds the latest tracking data for a race. Unlike market data, every RaceProgressChange and
+// RaceRunnerChange is a complete picture at its feed time, so entries are replaced rather than merged.
+type RaceCache struct {
+	PublishTime int64
+	RaceID      string
+	MarketID    string
+	Progress    *models.RaceProgressChange
+	Runners     map[int64]*models.RaceRunnerChange
+}
+
+// CachedRaces maps MarketID's to the race being run in that market
+type CachedRaces map[string]*RaceCache
+
+func newRaceCache(raceChange *models.RaceChange) *RaceCache {
+	cache := new(RaceCache)
+	cache.RaceID = raceChange.ID
+	cache.MarketID = raceChange.Mid
+	cache.Runners = make(map[int64]*models.RaceRunnerChange)
+	return cache
+}
+
+func (cache *RaceCache) update(raceChange *models.RaceChange, publishTime int64) {
+
+	cache.PublishTime = publishTime
+
+	if raceChange.Rpc != nil {
+		cache.Progress = raceChange.Rpc
+	}
+
+	for _, runnerChange := range raceChange.Rrc {
+		cache.Runners[runnerChange.ID] = runnerChange
+	}
+}
+
+// snap functions
+
+func snapRaceProgress(change *models.RaceProgressChange) RaceProgress {
+	if change == nil {
+		return RaceProgress{}
+	}
+	return RaceProgress{
+		FeedTime:      change.Ft,
+		GateName:      change.G,
+		SectionalTime: change.St,
+		RunningTime:   change.Rt,
+		Speed:         change.Spd,
+		Progress:      change.Prg,
+		Order:         append([]int64(nil), change.Ord...),
+		Jumps:         append([]interface{}(nil), change.J...),
+	}
+}
+
+func snapRaceRunner(change *models.RaceRunnerChange) RaceRunner {
+	return RaceRunner{
+		FeedTime:        change.Ft,
+		SelectionID:     change.ID,
+		Latitude:        change.Lat,
+		Longitude:       change.Long,
+		Speed:           change.Spd,
+		Progress:        change.Prg,
+		StrideFrequency: change.Sfq,
+	}
+}
+
+func (cache *RaceCache) Snap() RaceBook {
+	runners := []RaceRunner{}
+
+	for _, runner := range cache.Runners {
+		runners = append(runners, snapRaceRunner(runner))
+	}
+	sort.Slice(runners, func(i, j int) bool { return runners[i].SelectionID < runners[j].SelectionID })
+
+	return RaceBook{
+		PublishTime: cache.PublishTime,
+		RaceID:      cache.RaceID,
+		MarketID:    cache.MarketID,
+		Progress:    snapRaceProgress(cache.Progress),
+		Runners:     runners,
+	}
+}
@@ -0,0 +1,77 @@
+package streaming
+
+import (
+	"bufio"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func replayRaceStream(t *testing.T, path string) (*StreamChannels, CachedRaces) {
+	channels := newStreamChannels()
+	marketCache := make(CachedMarkets)
+	orderCache := make(CachedOrders)
+	raceCache := make(CachedRaces)
+	handler := newEventHandler(channels, &marketCache, &orderCache, &raceCache)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		op, err := getOp(scanner.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.onData(op, scanner.Bytes())
+	}
+
+	return channels, raceCache
+}
+
+func TestRaceStreamReplay(t *testing.T) {
+	// Arrange/Act
+	channels, raceCache := replayRaceStream(t, "testdata/race_stream.txt")
+
+	// Assert
+	assert.Len(t, raceCache, 1)
+	cache := raceCache["1.140181224"]
+	assert.NotNil(t, cache)
+	assert.Equal(t, "28587288.1650", cache.RaceID)
+	assert.Equal(t, int64(1518626766000), cache.PublishTime)
+	assert.Equal(t, "2f", cache.Progress.G)
+	assert.Equal(t, []int64{5600338, 7390417, 11527189}, cache.Progress.Ord)
+	assert.Len(t, cache.Runners, 3)
+	assert.Equal(t, 1102.9, cache.Runners[7390417].Prg)
+	assert.Equal(t, 1303.5, cache.Runners[5600338].Prg)
+
+	// Only the three messages carrying race changes should produce a snapshot
+	assert.Len(t, channels.RaceUpdate, 3)
+}
+
+func TestRaceCacheSnap(t *testing.T) {
+	// Arrange
+	channels, _ := replayRaceStream(t, "testdata/race_stream.txt")
+
+	// Act
+	<-channels.RaceUpdate
+	second := <-channels.RaceUpdate
+	third := <-channels.RaceUpdate
+
+	// Assert
+	assert.Equal(t, "1f", second.Progress.GateName)
+	assert.Equal(t, 46.7, second.Progress.RunningTime)
+	assert.Len(t, second.Runners, 3)
+	assert.Equal(t, int64(5600338), second.Runners[0].SelectionID)
+	assert.Equal(t, int64(7390417), second.Runners[1].SelectionID)
+	assert.Equal(t, int64(11527189), second.Runners[2].SelectionID)
+	assert.Equal(t, 2.02, second.Runners[2].StrideFrequency)
+
+	assert.Equal(t, "2f", third.Progress.GateName)
+	assert.Equal(t, 1102.9, third.Runners[1].Progress)
+	assert.Equal(t, 51.4241113, third.Runners[1].Latitude)
+}
@@ -0,0 +1,56 @@
+package streaming
+
+import (
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+type raceEventHandler struct {
+	channels   *StreamChannels
+	cache      CachedRaces
+	initialClk string
+	clk        string
+}
+
+func newRaceHandler(channels *StreamChannels, raceCache *CachedRaces) *raceEventHandler {
+	raceStream := new(raceEventHandler)
+	raceStream.channels = channels
+	raceStream.cache = *raceCache
+	return raceStream
+}
+
+func (handler *raceEventHandler) onChangeMessage(changeMessage models.RaceChangeMessage) {
+
+	if handler.initialClk == "" {
+		handler.initialClk = changeMessage.Clk
+	}
+
+	handler.clk = changeMessage.Clk
+
+	for _, raceChange := range changeMessage.Rc {
+
+		// Race data is keyed by Market ID so that it can be joined up with the MarketCache for the same market
+		raceCache, found := handler.cache[raceChange.Mid]
+		if !found {
+			raceCache = newRaceCache(raceChange)
+			handler.cache[raceChange.Mid] = raceCache
+		}
+
+		raceCache.update(raceChange, changeMessage.Pt)
+		handler.channels.raceUpdates.send(raceCache.Snap())
+	}
+}
+
+func (handler *raceEventHandler) OnSubscribe(changeMessage models.RaceChangeMessage) {
+	handler.onChangeMessage(changeMessage)
+}
+
+func (handler *raceEventHandler) OnResubscribe(changeMessage models.RaceChangeMessage) {
+	handler.onChangeMessage(changeMessage)
+}
+
+func (handler *raceEventHandler) OnHeartbeat(changeMessage models.RaceChangeMessage) {
+}
+
+func (handler *raceEventHandler) OnUpdate(changeMessage models.RaceChangeMessage) {
+	handler.onChangeMessage(changeMessage)
+}
@@ -1,11 +1,18 @@
 package streaming
 
 import (
+	"time"
+
 	"github.com/go-openapi/strfmt"
+
+	"github.com/jonachehilton/gofair/decimal"
 )
 
 type MarketBook struct {
+	// PublishTime is when the Exchange published the last change, in milliseconds since the epoch, and ReceivedAt when
+	// that change was read from the connection
 	PublishTime           int64
+	ReceivedAt            time.Time
 	MarketID              string
 	Status                string
 	BetDelay              int32
@@ -15,11 +22,14 @@ type MarketBook struct {
 	NumberOfWinners       int32
 	NumberOfRunners       int
 	NumberOfActiveRunners int32
-	TotalMatched          float64
+	TotalMatched          decimal.Money
 	CrossMatching         bool
 	RunnersVoidable       bool
 	Version               int64
 	Runners               []Runner
+
+	// Indicators is only set when indicators have been requested
+	Indicators *MarketIndicators
 }
 
 type Runner struct {
@@ -27,10 +37,13 @@ type Runner struct {
 	Handicap         float64
 	Status           string
 	AdjustmentFactor float64
-	LastPriceTraded  float64
-	TotalMatched     float64
+	LastPriceTraded  decimal.Price
+	TotalMatched     decimal.Money
 	RemovalDate      strfmt.DateTime
 	EX               ExchangePrices
+
+	// Indicators is only set when indicators have been requested
+	Indicators *RunnerIndicators
 }
 
 type ExchangePrices struct {
@@ -44,3 +57,32 @@ type ExchangePrices struct {
 type MarketSubscriptionResponse struct {
 	SubscribedMarketIDs []string
 }
+
+type RaceBook struct {
+	PublishTime int64
+	RaceID      string
+	MarketID    string
+	Progress    RaceProgress
+	Runners     []RaceRunner
+}
+
+type RaceProgress struct {
+	FeedTime      int64
+	GateName      string
+	SectionalTime float64
+	RunningTime   float64
+	Speed         float64
+	Progress      float64
+	Order         []int64
+	Jumps         []interface{}
+}
+
+type RaceRunner struct {
+	FeedTime        int64
+	SelectionID     int64
+	Latitude        float64
+	Longitude       float64
+	Speed           float64
+	Progress        float64
+	StrideFrequency float64
+}
@@ -3,7 +3,9 @@ package streaming
 import (
 	"bufio"
 	"crypto/tls"
+	"time"
 
+	"github.com/jonachehilton/gofair/logging"
 	"github.com/jonachehilton/gofair/streaming/models"
 )
 
@@ -12,19 +14,29 @@ const (
 	failure = "FAILURE"
 )
 
+// requestMessage is implemented by every message which can be sent to the Exchange
+type requestMessage interface {
+	SetID(int32)
+	MarshalJSON() ([]byte, error)
+}
+
 type session struct {
 	conn         *tlsConnection
 	channels     *StreamChannels
 	eventHandler *eventHandler
 	scanner      *bufio.Scanner
 	stopChan     chan int
+	outgoing     chan requestMessage
+
+	// The number of further connections the account may open, as reported when this session authenticated
+	connectionsAvailable int32
 }
 
 const readBufferSize = 1024 * 1024
 
-func newSession(destination string, certs *tls.Certificate, appKey string, sessionToken string, channels *StreamChannels, marketCache *CachedMarkets, orderCache *CachedOrders) (*session, error) {
+func newSession(destination string, certs *tls.Certificate, tlsConfig *tls.Config, appKey string, sessionToken string, channels *StreamChannels, eventHandler *eventHandler) (*session, error) {
 	session := new(session)
-	TLSConnection, err := newTLSConnection(destination, certs)
+	TLSConnection, err := newTLSConnection(destination, certs, tlsConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -41,8 +53,11 @@ func newSession(destination string, certs *tls.Certificate, appKey string, sessi
 
 	// Pass a pointer to our StreamChannels struct which is used for piping data back to the main goroutine
 	session.channels = channels
-	session.eventHandler = newEventHandler(channels, marketCache, orderCache)
+	session.eventHandler = eventHandler
 	session.stopChan = make(chan int)
+	session.outgoing = make(chan requestMessage, 64)
+
+	session.eventHandler.onConnection(TLSConnection.connectionMessage)
 
 	err = session.authenticate(appKey, sessionToken)
 	if err != nil {
@@ -71,31 +86,48 @@ func (session *session) authenticate(appKey string, sessionToken string) error {
 	authenticationMessage := &models.AuthenticationMessage{AppKey: appKey, Session: sessionToken}
 	authenticationMessage.SetID(session.conn.ID)
 
+	logger := session.eventHandler.logger
+
 	b, err := authenticationMessage.MarshalJSON()
 	if err != nil {
 		return err
 	}
 
+	logging.Payload(logger, "stream message sent", b, "connectionId", session.eventHandler.connectionID)
+
 	_, err = session.write(b)
 	if err != nil {
+		logger.Error("stream authentication failed", "connectionId", session.eventHandler.connectionID, "error", err)
 		return err
 	}
 
 	buf, err := session.read()
 	if err != nil {
+		logger.Error("stream authentication failed", "connectionId", session.eventHandler.connectionID, "error", err)
 		return err
 	}
 
+	logging.Payload(logger, "stream message received", buf, "connectionId", session.eventHandler.connectionID)
+
 	statusMessage := new(models.StatusMessage)
 	err = statusMessage.UnmarshalJSON(buf)
 	if err != nil {
+		session.eventHandler.onDecodeError(status, buf, err)
 		return err
 	}
 
+	session.eventHandler.notifyStatus(*statusMessage)
+	session.connectionsAvailable = statusMessage.ConnectionsAvailable
+
 	if statusMessage.StatusCode == failure {
+		logger.Error("stream authentication failed", "connectionId", session.eventHandler.connectionID,
+			"errorCode", statusMessage.ErrorCode, "errorMessage", statusMessage.ErrorMessage)
 		return &AuthenticationError{}
 	}
 
+	logger.Info("stream authenticated", "connectionId", session.eventHandler.connectionID,
+		"connectionsAvailable", statusMessage.ConnectionsAvailable)
+
 	return nil
 }
 
@@ -120,7 +152,7 @@ func (session *session) readPump() {
 
 	if session.conn == nil {
 		err := new(NoConnectionError)
-		session.channels.Err <- err
+		session.channels.errs.send(err)
 		return
 	}
 
@@ -132,19 +164,26 @@ func (session *session) readPump() {
 
 		default:
 			buf, err := session.read()
+			receivedAt := time.Now()
 
 			if err != nil {
-				session.channels.Err <- err
+				session.eventHandler.logger.Error("stream read failed", "connectionId", session.eventHandler.connectionID, "error", err)
+				session.channels.errs.send(err)
 				return
 			}
 
+			// The scanner strips the CRLF terminating each message
+			session.eventHandler.metrics.StreamBytes(len(buf) + 2)
+			logging.Payload(session.eventHandler.logger, "stream message received", buf, "connectionId", session.eventHandler.connectionID)
+
 			op, err := getOp(buf)
 			if err != nil {
-				session.channels.Err <- err
+				session.eventHandler.onDecodeError("", buf, err)
+				session.channels.errs.send(err)
 				return
 			}
 
-			session.eventHandler.onData(op, buf)
+			session.eventHandler.onMessage(op, buf, receivedAt)
 		}
 	}
 }
@@ -156,24 +195,53 @@ func (session *session) writePump() {
 		case <-session.stopChan:
 			return
 
-		case marketSubscriptionMessage := <-session.channels.marketSubscriptionRequest:
-			b, err := marketSubscriptionMessage.MarshalJSON()
+		case message := <-session.outgoing:
+			b, err := message.MarshalJSON()
 			if err != nil {
-				session.channels.Err <- err
+				session.channels.errs.send(err)
 				return
 			}
 
-			session.write(b)
-
-		case orderSubscriptionMessage := <-session.channels.orderSubscriptionRequest:
+			logging.Payload(session.eventHandler.logger, "stream message sent", b, "connectionId", session.eventHandler.connectionID)
 
-			b, err := orderSubscriptionMessage.MarshalJSON()
+			_, err = session.write(b)
 			if err != nil {
-				session.channels.Err <- err
-				return
+				session.eventHandler.logger.Error("stream write failed", "connectionId", session.eventHandler.connectionID, "error", err)
 			}
-
-			session.write(b)
 		}
 	}
 }
+
+// subscribe sends a subscription request and blocks until the Exchange has confirmed it
+func (session *session) subscribe(request requestMessage, requests *requestTracker, timeout time.Duration) (*Subscription, error) {
+
+	id, pending := requests.track()
+	defer requests.done(id)
+
+	request.SetID(id)
+	session.outgoing <- request
+
+	subscription, err := pending.wait(id, timeout)
+	session.eventHandler.metrics.StreamSubscription(subscriptionKind(request), err)
+	if err != nil {
+		session.eventHandler.logger.Warn("stream subscription failed", "connectionId", session.eventHandler.connectionID,
+			"kind", subscriptionKind(request), "id", id, "error", err)
+	} else {
+		session.eventHandler.logger.Info("stream subscribed", "connectionId", session.eventHandler.connectionID,
+			"kind", subscriptionKind(request), "id", id, "heartbeatMs", subscription.HeartbeatMs, "conflateMs", subscription.ConflateMs)
+	}
+	return subscription, err
+}
+
+// subscriptionKind names the kind of subscription for metrics and logs
+func subscriptionKind(request requestMessage) string {
+	switch request.(type) {
+	case *models.MarketSubscriptionMessage:
+		return "markets"
+	case *models.OrderSubscriptionMessage:
+		return "orders"
+	case *models.RaceSubscriptionMessage:
+		return "races"
+	}
+	return "unknown"
+}
@@ -2,7 +2,11 @@ package streaming
 
 import (
 	"crypto/tls"
+	"log/slog"
+	"time"
 
+	"github.com/jonachehilton/gofair/logging"
+	"github.com/jonachehilton/gofair/metrics"
 	"github.com/jonachehilton/gofair/streaming/models"
 )
 
@@ -10,43 +14,130 @@ import (
 const MaxSubscriptionLimit = 200
 
 type StreamChannels struct {
-	// Outgoing Requests
-	marketSubscriptionRequest chan models.MarketSubscriptionMessage
-	orderSubscriptionRequest  chan models.OrderSubscriptionMessage
-
 	// Incoming Responses
 	Err          chan error
 	MarketUpdate chan MarketBook
 	OrderUpdate  chan OrderBookCache
+	RaceUpdate   chan RaceBook
 	Status       chan models.StatusMessage
+
+	// Delivery of Incoming Responses
+	errs          *delivery[error]
+	marketUpdates *delivery[MarketBook]
+	orderUpdates  *delivery[OrderBookCache]
+	raceUpdates   *delivery[RaceBook]
+	statuses      *delivery[models.StatusMessage]
 }
 
 func newStreamChannels() *StreamChannels {
 
 	channels := new(StreamChannels)
 
-	// Set up Outgoing Request Channels
-	channels.marketSubscriptionRequest = make(chan models.MarketSubscriptionMessage, 64)
-	channels.orderSubscriptionRequest = make(chan models.OrderSubscriptionMessage, 1)
-
 	// Set up Incoming Response Channels
 	channels.MarketUpdate = make(chan MarketBook, 64)
 	channels.OrderUpdate = make(chan OrderBookCache, 64)
-	channels.Status = make(chan models.StatusMessage)
-	channels.Err = make(chan error)
+	channels.RaceUpdate = make(chan RaceBook, 64)
+	channels.Status = make(chan models.StatusMessage, 16)
+	channels.Err = make(chan error, 16)
+
+	// Every channel blocks by default, see SetDeliveryPolicies
+	channels.errs = newDelivery(channels.Err, nil)
+	channels.marketUpdates = newDelivery(channels.MarketUpdate, func(book MarketBook) string { return book.MarketID })
+	channels.orderUpdates = newDelivery(channels.OrderUpdate, func(cache OrderBookCache) string { return cache.MarketID })
+	channels.raceUpdates = newDelivery(channels.RaceUpdate, func(book RaceBook) string { return book.MarketID })
+	channels.statuses = newDelivery(channels.Status, nil)
 
 	return channels
 }
 
+// SetDeliveryPolicies configures what happens when a consumer falls behind on each of the incoming channels, this
+// should be called before Start. A channel set to CoalesceLatest is replaced by an unbuffered one, so read the channel
+// fields after calling it.
+func (channels *StreamChannels) SetDeliveryPolicies(policies DeliveryPolicies) error {
+	marketUpdate, err := channels.marketUpdates.checkPolicy("MarketUpdate", policies.MarketUpdate)
+	if err != nil {
+		return err
+	}
+	orderUpdate, err := channels.orderUpdates.checkPolicy("OrderUpdate", policies.OrderUpdate)
+	if err != nil {
+		return err
+	}
+	raceUpdate, err := channels.raceUpdates.checkPolicy("RaceUpdate", policies.RaceUpdate)
+	if err != nil {
+		return err
+	}
+	status, err := channels.statuses.checkPolicy("Status", policies.Status)
+	if err != nil {
+		return err
+	}
+	errs, err := channels.errs.checkPolicy("Err", policies.Err)
+	if err != nil {
+		return err
+	}
+
+	channels.MarketUpdate = channels.marketUpdates.setPolicy(marketUpdate)
+	channels.OrderUpdate = channels.orderUpdates.setPolicy(orderUpdate)
+	channels.RaceUpdate = channels.raceUpdates.setPolicy(raceUpdate)
+	channels.Status = channels.statuses.setPolicy(status)
+	channels.Err = channels.errs.setPolicy(errs)
+
+	return nil
+}
+
+// stop ends the delivery of held back updates, whose consumer may have gone
+func (channels *StreamChannels) stop() {
+	channels.marketUpdates.stop()
+	channels.orderUpdates.stop()
+	channels.raceUpdates.stop()
+}
+
+// Stats returns the number of dropped and coalesced updates on each of the incoming channels.
+func (channels *StreamChannels) Stats() ChannelStats {
+	return ChannelStats{
+		MarketUpdate: channels.marketUpdates.stats(),
+		OrderUpdate:  channels.orderUpdates.stats(),
+		RaceUpdate:   channels.raceUpdates.stats(),
+		Status:       channels.statuses.stats(),
+		Err:          channels.errs.stats(),
+	}
+}
+
 type Stream struct {
-	requestUID int32
-	certs      *tls.Certificate
-	appKey     string
-	session    *session
+	requests *requestTracker
+	certs    *tls.Certificate
+	appKey   string
+	session  *session
+
+	handlers       []StreamHandler
+	replaceBuiltIn bool
 
 	MarketCache CachedMarkets
 	OrderCache  CachedOrders
+	RaceCache   CachedRaces
 	Channels    *StreamChannels
+
+	// TLSConfig, if set, is used when dialling the Exchange. Setting it also allows Start to connect to endpoints other
+	// than LiveEndpoint and IntegrationEndpoint, such as a local test server.
+	TLSConfig *tls.Config
+
+	// Indicators, if set before Start, attaches MarketIndicators and RunnerIndicators to every MarketBook
+	Indicators *IndicatorOptions
+
+	// Virtualise, if set before Start, takes the best prices in every MarketBook from the virtual cross-matched ladders
+	Virtualise bool
+
+	// SubscriptionTimeout is how long the Subscribe calls wait for the Exchange to confirm a subscription
+	SubscriptionTimeout time.Duration
+
+	// Metrics, if set before Start, records the messages, subscriptions, caches and channels of the connection
+	Metrics metrics.Recorder
+
+	// Latency measures how far behind the Exchange the feed is running and reports when it becomes stale
+	Latency *LatencyMonitor
+
+	// Logger, if set before Start, logs authentication, subscriptions, decode failures and reconnects, and every message
+	// payload at debug level, with session tokens redacted
+	Logger *slog.Logger
 }
 
 // NewStream generates a Stream object which can be subsequently used to connect to an Exchange Stream endpoint
@@ -55,9 +146,13 @@ func NewStream(certs *tls.Certificate, appKey string) (*Stream, error) {
 	stream := new(Stream)
 	stream.certs = certs
 	stream.appKey = appKey
+	stream.requests = newRequestTracker()
+	stream.SubscriptionTimeout = DefaultSubscriptionTimeout
+	stream.Latency = NewLatencyMonitor(DefaultStaleThreshold)
 
 	stream.MarketCache = make(CachedMarkets)
 	stream.OrderCache = make(CachedOrders)
+	stream.RaceCache = make(CachedRaces)
 	stream.Channels = newStreamChannels()
 
 	return stream, nil
@@ -66,11 +161,24 @@ func NewStream(certs *tls.Certificate, appKey string) (*Stream, error) {
 // Start performs the Connection and Authentication steps and initializes the read/write goroutines
 func (stream *Stream) Start(endpoint string, sessionToken string) error {
 
-	if endpoint != LiveEndpoint && endpoint != IntegrationEndpoint {
+	if !validEndpoint(endpoint, stream.TLSConfig) {
 		return &EndpointError{}
 	}
 
-	session, err := newSession(endpoint, stream.certs, stream.appKey, sessionToken, stream.Channels, &stream.MarketCache, &stream.OrderCache)
+	eventHandler := newEventHandler(stream.Channels, &stream.MarketCache, &stream.OrderCache, &stream.RaceCache)
+	eventHandler.register(stream.handlers, stream.replaceBuiltIn)
+	eventHandler.requests = stream.requests
+	eventHandler.setSnapOptions(stream.Indicators, stream.Virtualise)
+	eventHandler.metrics = metrics.OrNop(stream.Metrics)
+	eventHandler.logger = logging.OrDiscard(stream.Logger)
+	eventHandler.latency = stream.Latency
+
+	if stream.session != nil {
+		eventHandler.metrics.StreamReconnect()
+		eventHandler.logger.Info("stream reconnecting", "endpoint", endpoint)
+	}
+
+	session, err := newSession(endpoint, stream.certs, stream.TLSConfig, stream.appKey, sessionToken, stream.Channels, eventHandler)
 	if err != nil {
 		return err
 	}
@@ -80,22 +188,65 @@ func (stream *Stream) Start(endpoint string, sessionToken string) error {
 	return nil
 }
 
+// AddHandler registers a StreamHandler which receives every message alongside the built-in cache handlers. Handlers
+// must be registered before calling Start.
+func (stream *Stream) AddHandler(handler StreamHandler) {
+	stream.handlers = append(stream.handlers, handler)
+}
+
+// ReplaceHandlers registers StreamHandlers which receive every message instead of the built-in cache handlers. The
+// MarketCache, OrderCache and RaceCache are then left empty and nothing is sent on the MarketUpdate, OrderUpdate,
+// RaceUpdate or Status channels. Handlers must be registered before calling Start.
+func (stream *Stream) ReplaceHandlers(handlers ...StreamHandler) {
+	stream.handlers = append(stream.handlers, handlers...)
+	stream.replaceBuiltIn = true
+}
+
 // Stop tears down the underlying TLS session to the Streaming endpoint
 func (stream *Stream) Stop() {
 	stream.session.stop()
+	stream.Channels.stop()
+	stream.Latency.stop()
 }
 
-func (stream *Stream) SubscribeToMarkets(marketFilter *models.MarketFilter, marketDataFilter *models.MarketDataFilter) {
+// SubscribeToMarkets subscribes to the markets selected by the MarketFilter, replacing any previous market subscription
+// on this connection. It blocks until the Exchange has confirmed the subscription and sent the initial image, returning
+// a SubscriptionError if the request was rejected.
+func (stream *Stream) SubscribeToMarkets(marketFilter *models.MarketFilter, marketDataFilter *models.MarketDataFilter) (*Subscription, error) {
+
+	if marketFilter != nil && len(marketFilter.MarketIds) > MaxSubscriptionLimit {
+		return nil, &SubscriptionLimitError{Requested: len(marketFilter.MarketIds), Limit: MaxSubscriptionLimit}
+	}
+
+	if stream.session == nil {
+		return nil, &NoConnectionError{}
+	}
 
 	request := models.MarketSubscriptionMessage{MarketFilter: marketFilter, MarketDataFilter: marketDataFilter}
-	request.SetID(stream.requestUID)
-	stream.requestUID++
-	stream.Channels.marketSubscriptionRequest <- request
+	return stream.session.subscribe(&request, stream.requests, stream.SubscriptionTimeout)
 }
 
-func (stream *Stream) SubscribeToOrders() {
+// SubscribeToOrders subscribes to all of the account's orders. It blocks until the Exchange has confirmed the
+// subscription and sent the initial image, returning a SubscriptionError if the request was rejected.
+func (stream *Stream) SubscribeToOrders() (*Subscription, error) {
+
+	if stream.session == nil {
+		return nil, &NoConnectionError{}
+	}
+
 	request := models.OrderSubscriptionMessage{SegmentationEnabled: true}
-	request.SetID(stream.requestUID)
-	stream.requestUID++
-	stream.Channels.orderSubscriptionRequest <- request
+	return stream.session.subscribe(&request, stream.requests, stream.SubscriptionTimeout)
+}
+
+// SubscribeToRaces requests race status (tracking) data for every race available to the account. Updates are
+// delivered on Channels.RaceUpdate for supported horse races once they are underway. It blocks until the Exchange has
+// confirmed the subscription and sent the initial image, returning a SubscriptionError if the request was rejected.
+func (stream *Stream) SubscribeToRaces() (*Subscription, error) {
+
+	if stream.session == nil {
+		return nil, &NoConnectionError{}
+	}
+
+	request := models.RaceSubscriptionMessage{}
+	return stream.session.subscribe(&request, stream.requests, stream.SubscriptionTimeout)
 }
@@ -0,0 +1,178 @@
+package streaming
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/betfairtest"
+	"github.com/jonachehilton/gofair/decimal"
+	"github.com/jonachehilton/gofair/logging"
+	"github.com/jonachehilton/gofair/metrics"
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+func newTestStream(t *testing.T) (*Stream, *betfairtest.StreamServer) {
+	server, err := betfairtest.NewStreamServer()
+	assert.NoError(t, err)
+
+	stream, err := NewStream(&server.Certificate.TLS, betfairtest.AppKey)
+	assert.NoError(t, err)
+	stream.TLSConfig = server.ClientTLSConfig()
+	stream.SubscriptionTimeout = 5 * time.Second
+
+	return stream, server
+}
+
+func TestStreamSubscribeToMarkets(t *testing.T) {
+	// Arrange
+	stream, server := newTestStream(t)
+	defer server.Close()
+	server.Script("marketSubscription",
+		`{"op":"mcm","initialClk":"AA==","clk":"AA==","ct":"SUB_IMAGE","heartbeatMs":5000,"pt":1,"mc":[{"id":"1.23","marketDefinition":{"status":"OPEN","runners":[{"id":1,"status":"ACTIVE"}]},"rc":[{"id":1,"ltp":2.5}]}]}`,
+	)
+
+	// Act
+	err := stream.Start(server.Addr, betfairtest.SessionToken)
+	assert.NoError(t, err)
+	defer stream.Stop()
+	subscription, err := stream.SubscribeToMarkets(&models.MarketFilter{MarketIds: []string{"1.23"}}, nil)
+	assert.NoError(t, err)
+	server.Send(`{"op":"mcm","clk":"AB==","pt":2,"mc":[{"id":"1.23","rc":[{"id":1,"ltp":2.6}]}]}`)
+
+	// Assert
+	assert.Equal(t, int64(5000), subscription.HeartbeatMs)
+	assert.Equal(t, "AA==", subscription.InitialClk)
+	for _, ltp := range []decimal.Price{2.5, 2.6} {
+		select {
+		case book := <-stream.Channels.MarketUpdate:
+			assert.Equal(t, "1.23", book.MarketID)
+			assert.Equal(t, ltp, book.Runners[0].LastPriceTraded)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a market update")
+		}
+	}
+	received := server.Received()
+	assert.Equal(t, "authentication", received[0].Op)
+	assert.Equal(t, "marketSubscription", received[1].Op)
+	assert.Equal(t, subscription.ID, received[1].ID)
+}
+
+func TestStreamSubscriptionFailure(t *testing.T) {
+	// Arrange
+	stream, server := newTestStream(t)
+	defer server.Close()
+	server.FailSubscription("orderSubscription", "NO_APP_KEY", "no app key")
+
+	// Act
+	err := stream.Start(server.Addr, betfairtest.SessionToken)
+	assert.NoError(t, err)
+	defer stream.Stop()
+	subscription, err := stream.SubscribeToOrders()
+
+	// Assert
+	assert.Nil(t, subscription)
+	assert.Equal(t, &SubscriptionError{ErrorCode: StatusErrorCodeEnum.NoAppKey, ErrorMessage: "no app key"}, err)
+}
+
+func TestStreamAuthenticationFailure(t *testing.T) {
+	// Arrange
+	stream, server := newTestStream(t)
+	defer server.Close()
+
+	// Act
+	err := stream.Start(server.Addr, "expired-token")
+
+	// Assert
+	assert.IsType(t, &AuthenticationError{}, err)
+}
+
+func TestStreamRejectsUnknownEndpoint(t *testing.T) {
+	// Arrange
+	stream, server := newTestStream(t)
+	defer server.Close()
+	stream.TLSConfig = nil
+
+	// Act
+	err := stream.Start(server.Addr, betfairtest.SessionToken)
+
+	// Assert
+	assert.IsType(t, &EndpointError{}, err)
+}
+
+func TestStreamMetrics(t *testing.T) {
+	// Arrange
+	stream, server := newTestStream(t)
+	defer server.Close()
+	collector := metrics.NewCollector()
+	stream.Metrics = collector
+	server.Script("marketSubscription",
+		`{"op":"mcm","initialClk":"AA==","clk":"AA==","ct":"SUB_IMAGE","heartbeatMs":5000,"pt":1,"mc":[{"id":"1.23","marketDefinition":{"status":"OPEN","runners":[{"id":1,"status":"ACTIVE"}]},"rc":[{"id":1,"ltp":2.5}]}]}`,
+	)
+
+	// Act
+	err := stream.Start(server.Addr, betfairtest.SessionToken)
+	assert.NoError(t, err)
+	defer stream.Stop()
+	_, err = stream.SubscribeToMarkets(&models.MarketFilter{MarketIds: []string{"1.23"}}, nil)
+	assert.NoError(t, err)
+	select {
+	case <-stream.Channels.MarketUpdate:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a market update")
+	}
+	var out strings.Builder
+	_, err = collector.WriteTo(&out)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), `gofair_stream_messages_total{op="mcm",ct="SUB_IMAGE"} 1`)
+	assert.Contains(t, out.String(), `gofair_stream_subscriptions_total{kind="markets",result="success"} 1`)
+	assert.Contains(t, out.String(), `gofair_stream_cache_entries{cache="markets"} 1`)
+	assert.Contains(t, out.String(), `gofair_stream_clk_age_seconds{connection=`)
+}
+
+func TestStreamLogsDroppedMessages(t *testing.T) {
+	// Arrange
+	stream, server := newTestStream(t)
+	defer server.Close()
+	var buf safeBuffer
+	stream.Logger = logging.New(&buf, slog.LevelDebug)
+
+	// Act
+	err := stream.Start(server.Addr, betfairtest.SessionToken)
+	assert.NoError(t, err)
+	defer stream.Stop()
+	server.Send(`{"op":"mcm","pt":"corrupt"}`)
+
+	// Assert
+	assert.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), `"msg":"dropped stream message","op":"mcm"`)
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, buf.String(), `"msg":"stream authenticated"`)
+	assert.Contains(t, buf.String(), `\"pt\":\"corrupt\"`)
+	assert.NotContains(t, buf.String(), betfairtest.SessionToken)
+}
+
+// safeBuffer is a bytes.Buffer which may be written by the read goroutine while a test reads it
+type safeBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
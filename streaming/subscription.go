@@ -0,0 +1,117 @@
+package streaming
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+// DefaultSubscriptionTimeout is how long a Subscribe call waits for the Exchange to confirm the subscription
+const DefaultSubscriptionTimeout = 15 * time.Second
+
+// Subscription describes a subscription which has been accepted by the Exchange.
+type Subscription struct {
+	ID int32
+	// HeartbeatMs is the heartbeat rate confirmed by the Exchange, which may differ from the one requested
+	HeartbeatMs int64
+	// ConflateMs is the conflation rate confirmed by the Exchange, which may differ from the one requested
+	ConflateMs int64
+	// InitialClk can be passed in a later subscription request to resume this subscription
+	InitialClk string
+}
+
+type pendingRequest struct {
+	status chan models.StatusMessage
+	image  chan Subscription
+}
+
+// requestTracker hands out request IDs and links the StatusMessage and initial image replies back to the request
+type requestTracker struct {
+	uid     atomic.Int32
+	mu      sync.Mutex
+	pending map[int32]*pendingRequest
+}
+
+func newRequestTracker() *requestTracker {
+	tracker := new(requestTracker)
+	tracker.pending = make(map[int32]*pendingRequest)
+	return tracker
+}
+
+// nextID returns a request ID without waiting on the reply
+func (tracker *requestTracker) nextID() int32 {
+	return tracker.uid.Add(1)
+}
+
+// track returns a request ID along with the pendingRequest which will receive its replies
+func (tracker *requestTracker) track() (int32, *pendingRequest) {
+	id := tracker.nextID()
+	request := &pendingRequest{
+		status: make(chan models.StatusMessage, 1),
+		image:  make(chan Subscription, 1),
+	}
+
+	tracker.mu.Lock()
+	tracker.pending[id] = request
+	tracker.mu.Unlock()
+
+	return id, request
+}
+
+func (tracker *requestTracker) done(id int32) {
+	tracker.mu.Lock()
+	delete(tracker.pending, id)
+	tracker.mu.Unlock()
+}
+
+func (tracker *requestTracker) get(id int32) *pendingRequest {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	return tracker.pending[id]
+}
+
+func (tracker *requestTracker) onStatus(statusMessage models.StatusMessage) {
+	if request := tracker.get(statusMessage.ID()); request != nil {
+		select {
+		case request.status <- statusMessage:
+		default:
+		}
+	}
+}
+
+func (tracker *requestTracker) onImage(id int32, subscription Subscription) {
+	if request := tracker.get(id); request != nil {
+		select {
+		case request.image <- subscription:
+		default:
+		}
+	}
+}
+
+// wait blocks until the request has been confirmed by a StatusMessage and the initial image has arrived
+func (request *pendingRequest) wait(id int32, timeout time.Duration) (*Subscription, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	select {
+	case statusMessage := <-request.status:
+		if statusMessage.StatusCode == failure {
+			return nil, &SubscriptionError{
+				ErrorCode:    StatusErrorCode(statusMessage.ErrorCode),
+				ErrorMessage: statusMessage.ErrorMessage,
+			}
+		}
+	case <-deadline.C:
+		return nil, &SubscriptionTimeoutError{}
+	}
+
+	select {
+	case subscription := <-request.image:
+		subscription.ID = id
+		return &subscription, nil
+	case <-deadline.C:
+		return nil, &SubscriptionTimeoutError{}
+	}
+}
@@ -0,0 +1,80 @@
+package streaming
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jonachehilton/gofair/streaming/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTrackedEventHandler() (*eventHandler, *requestTracker) {
+	channels := newStreamChannels()
+	marketCache := make(CachedMarkets)
+	orderCache := make(CachedOrders)
+	raceCache := make(CachedRaces)
+	handler := newEventHandler(channels, &marketCache, &orderCache, &raceCache)
+	handler.requests = newRequestTracker()
+	return handler, handler.requests
+}
+
+func TestSubscriptionConfirmed(t *testing.T) {
+	// Arrange
+	handler, tracker := newTrackedEventHandler()
+	id, pending := tracker.track()
+
+	// Act
+	handler.onData(status, []byte(fmt.Sprintf(`{"op":"status","id":%d,"statusCode":"SUCCESS","connectionClosed":false}`, id)))
+	handler.onData(orderChangeMessage, []byte(fmt.Sprintf(`{"op":"ocm","id":%d,"initialClk":"GpOQ","clk":"AAAAAAAA","ct":"SUB_IMAGE","heartbeatMs":5000,"conflateMs":0,"pt":1}`, id)))
+	subscription, err := pending.wait(id, time.Second)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, &Subscription{ID: id, HeartbeatMs: 5000, InitialClk: "GpOQ"}, subscription)
+}
+
+func TestSubscriptionFailure(t *testing.T) {
+	// Arrange
+	handler, tracker := newTrackedEventHandler()
+	id, pending := tracker.track()
+
+	// Act
+	handler.onData(status, []byte(fmt.Sprintf(`{"op":"status","id":%d,"statusCode":"FAILURE","errorCode":"SUBSCRIPTION_LIMIT_EXCEEDED","errorMessage":"trying to subscribe to 201 markets"}`, id)))
+	subscription, err := pending.wait(id, time.Second)
+
+	// Assert
+	assert.Nil(t, subscription)
+	assert.Equal(t, &SubscriptionError{ErrorCode: StatusErrorCodeEnum.SubscriptionLimitExceeded, ErrorMessage: "trying to subscribe to 201 markets"}, err)
+}
+
+func TestSubscriptionIgnoresOtherRequests(t *testing.T) {
+	// Arrange
+	handler, tracker := newTrackedEventHandler()
+	id, pending := tracker.track()
+
+	// Act
+	handler.onData(status, []byte(fmt.Sprintf(`{"op":"status","id":%d,"statusCode":"FAILURE","errorCode":"INVALID_CLOCK"}`, id+1)))
+	subscription, err := pending.wait(id, 10*time.Millisecond)
+
+	// Assert
+	assert.Nil(t, subscription)
+	assert.IsType(t, &SubscriptionTimeoutError{}, err)
+}
+
+func TestSubscribeToMarketsLimit(t *testing.T) {
+	// Arrange
+	stream, _ := NewStream(nil, "")
+	filter := new(models.MarketFilter)
+	for i := 0; i <= MaxSubscriptionLimit; i++ {
+		filter.MarketIds = append(filter.MarketIds, fmt.Sprintf("1.%d", i))
+	}
+
+	// Act
+	_, limitErr := stream.SubscribeToMarkets(filter, nil)
+	_, connectionErr := stream.SubscribeToMarkets(&models.MarketFilter{MarketIds: []string{"1.1"}}, nil)
+
+	// Assert
+	assert.Equal(t, &SubscriptionLimitError{Requested: MaxSubscriptionLimit + 1, Limit: MaxSubscriptionLimit}, limitErr)
+	assert.IsType(t, &NoConnectionError{}, connectionErr)
+}
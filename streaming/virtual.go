@@ -0,0 +1,161 @@
+package streaming
+
+import (
+	"math"
+	"sort"
+
+	"github.com/jonachehilton/gofair/decimal"
+	"github.com/jonachehilton/gofair/price"
+)
+
+// VirtualLadder holds a runner's offers with the virtual offers generated by cross matching merged in, each sorted best
+// price first
+type VirtualLadder struct {
+	AvailableToBack ByPrice
+	AvailableToLay  ByPrice
+}
+
+// crossMatched reports whether the Exchange generates virtual offers for the market
+func (cache *MarketCache) crossMatched() bool {
+	definition := cache.MarketDefinition
+	return definition != nil && definition.CrossMatching && definition.NumberOfWinners <= 1
+}
+
+// Virtualise returns the ladders of every runner as shown with PriceProjection.Virtualise, merging in the virtual
+// offers the Exchange generates by cross matching the best offers of all other active runners. At most depth levels
+// are returned on each side, or every level if depth is zero or less. Markets which are not cross matched, including
+// those with more than one winner, are returned without virtual offers.
+func (cache *MarketCache) Virtualise(depth int) map[int64]VirtualLadder {
+
+	ladder, _ := price.ForDefinition(cache.MarketDefinition)
+
+	active := []int64{}
+	for id := range cache.Runners {
+		if cache.active(id) {
+			active = append(active, id)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i] < active[j] })
+
+	ladders := make(map[int64]VirtualLadder)
+	for id, runner := range cache.Runners {
+		back := append(ByPrice(nil), runner.AvailableToBack.Prices...)
+		lay := append(ByPrice(nil), runner.AvailableToLay.Prices...)
+
+		if ladder != nil && cache.crossMatched() && cache.active(id) {
+			others := make([]ByPrice, 0, len(active))
+			for _, other := range active {
+				if other != id {
+					others = append(others, cache.Runners[other].AvailableToLay.Prices)
+				}
+			}
+			back = mergeOffers(back, virtualOffers(others, ladder, true, depth), true)
+
+			others = others[:0]
+			for _, other := range active {
+				if other != id {
+					others = append(others, cache.Runners[other].AvailableToBack.Prices)
+				}
+			}
+			lay = mergeOffers(lay, virtualOffers(others, ladder, false, depth), false)
+		}
+
+		if depth > 0 && len(back) > depth {
+			back = back[:depth]
+		}
+		if depth > 0 && len(lay) > depth {
+			lay = lay[:depth]
+		}
+		ladders[id] = VirtualLadder{AvailableToBack: back, AvailableToLay: lay}
+	}
+
+	return ladders
+}
+
+// virtualOffers generates virtual offers from the best-first ladders of the other runners.
+//
+// A back at price p on one runner is matched by backs on every other runner, each staking p / price of the back's
+// stake, so p = 1 / (1 - sum of 1 / price) and the stake is limited by the smallest size * price. The lay side mirrors
+// this using the other runners' lay offers. Prices are rounded onto the ladder against the taker and each level
+// consumes the offers it is built from before the next is generated.
+func virtualOffers(others []ByPrice, ladder *price.Ladder, back bool, depth int) ByPrice {
+
+	if len(others) == 0 {
+		return nil
+	}
+
+	remaining := make([]ByPrice, len(others))
+	for i, offers := range others {
+		remaining[i] = append(ByPrice(nil), offers...)
+	}
+
+	var offers ByPrice
+	for depth <= 0 || len(offers) < depth {
+
+		sum := 0.0
+		capacity := math.Inf(1)
+		for _, levels := range remaining {
+			if len(levels) == 0 {
+				return offers
+			}
+			sum += 1 / levels[0].Price.Float64()
+			capacity = math.Min(capacity, levels[0].Size.Float64()*levels[0].Price.Float64())
+		}
+		if sum >= 1 {
+			return offers
+		}
+
+		var p float64
+		var err error
+		if back {
+			p, err = ladder.RoundDown(1 / (1 - sum))
+		} else {
+			p, err = ladder.RoundUp(1 / (1 - sum))
+		}
+		if err != nil {
+			return offers
+		}
+
+		size := math.Floor(capacity/p*100) / 100
+		if size < 0.01 {
+			return offers
+		}
+
+		if n := len(offers); n > 0 && offers[n-1].Price == decimal.Price(p) {
+			offers[n-1].Size = offers[n-1].Size.Add(decimal.Money(size))
+		} else {
+			offers = append(offers, PriceSize{decimal.Price(p), decimal.Money(size)})
+		}
+
+		for i, levels := range remaining {
+			levels[0].Size = levels[0].Size.Sub(decimal.Money(size * p / levels[0].Price.Float64()))
+			if levels[0].Size < 0.01 {
+				remaining[i] = levels[1:]
+			}
+		}
+	}
+
+	return offers
+}
+
+// mergeOffers combines real and virtual offers, adding together the sizes at the same price
+func mergeOffers(real ByPrice, virtual ByPrice, back bool) ByPrice {
+	sizes := make(map[decimal.Price]decimal.Money)
+	for _, offer := range real {
+		sizes[offer.Price] = sizes[offer.Price].Add(offer.Size)
+	}
+	for _, offer := range virtual {
+		sizes[offer.Price] = sizes[offer.Price].Add(offer.Size)
+	}
+
+	merged := make(ByPrice, 0, len(sizes))
+	for p, size := range sizes {
+		merged = append(merged, PriceSize{p, size.Round()})
+	}
+	if back {
+		sort.Sort(sort.Reverse(merged))
+	} else {
+		sort.Sort(merged)
+	}
+	return merged
+}
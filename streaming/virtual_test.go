@@ -0,0 +1,120 @@
+package streaming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/streaming/models"
+)
+
+func newVirtualTestMarket(crossMatching bool, numberOfWinners int32, runners ...*models.RunnerChange) *MarketCache {
+	definition := &models.MarketDefinition{CrossMatching: crossMatching, NumberOfWinners: numberOfWinners}
+	for _, runner := range runners {
+		definition.Runners = append(definition.Runners, &models.RunnerDefinition{ID: runner.ID, Status: "ACTIVE"})
+	}
+	return newMarketCache(&models.MarketChangeMessage{Pt: 1}, &models.MarketChange{ID: "1.23", MarketDefinition: definition, Rc: runners})
+}
+
+func twoRunnerChanges() []*models.RunnerChange {
+	return []*models.RunnerChange{
+		{ID: 1, Atb: [][]float64{{1.9, 10}}, Atl: [][]float64{{1.95, 20}}},
+		{ID: 2, Atb: [][]float64{{2.0, 30}}, Atl: [][]float64{{2.1, 40}}},
+	}
+}
+
+func TestVirtualiseTwoRunners(t *testing.T) {
+	// Arrange
+	market := newVirtualTestMarket(true, 1, twoRunnerChanges()...)
+
+	// Act
+	ladders := market.Virtualise(3)
+
+	// Assert
+	assert.Equal(t, VirtualLadder{
+		AvailableToBack: ByPrice{{1.9, 54.21}},
+		AvailableToLay:  ByPrice{{1.95, 20}, {2.0, 30}},
+	}, ladders[1])
+	assert.Equal(t, VirtualLadder{
+		AvailableToBack: ByPrice{{2.04, 19.11}, {2.0, 30}},
+		AvailableToLay:  ByPrice{{2.1, 40}, {2.12, 8.96}},
+	}, ladders[2])
+}
+
+func TestVirtualiseConsumesLevels(t *testing.T) {
+	// Arrange
+	market := newVirtualTestMarket(true, 1,
+		&models.RunnerChange{ID: 1},
+		&models.RunnerChange{ID: 2, Atl: [][]float64{{2.1, 10}, {2.2, 100}}},
+	)
+
+	// Act
+	ladders := market.Virtualise(0)
+
+	// Assert
+	assert.Equal(t, ByPrice{{1.9, 11.05}, {1.83, 120.21}}, ladders[1].AvailableToBack)
+}
+
+func TestVirtualiseThreeRunners(t *testing.T) {
+	// Arrange
+	market := newVirtualTestMarket(true, 1,
+		&models.RunnerChange{ID: 1},
+		&models.RunnerChange{ID: 2, Atl: [][]float64{{4.0, 10}}},
+		&models.RunnerChange{ID: 3, Atl: [][]float64{{4.0, 25}}},
+	)
+
+	// Act
+	ladders := market.Virtualise(3)
+
+	// Assert
+	assert.Equal(t, ByPrice{{2.0, 20}}, ladders[1].AvailableToBack)
+	assert.Empty(t, ladders[2].AvailableToBack)
+}
+
+func TestVirtualiseWithoutCrossMatching(t *testing.T) {
+	// Arrange
+	testCases := []struct {
+		crossMatching   bool
+		numberOfWinners int32
+	}{
+		{crossMatching: false, numberOfWinners: 1},
+		{crossMatching: true, numberOfWinners: 2},
+	}
+
+	for _, testCase := range testCases {
+		market := newVirtualTestMarket(testCase.crossMatching, testCase.numberOfWinners, twoRunnerChanges()...)
+
+		// Act
+		ladders := market.Virtualise(3)
+
+		// Assert
+		assert.Equal(t, VirtualLadder{AvailableToBack: ByPrice{{1.9, 10}}, AvailableToLay: ByPrice{{1.95, 20}}}, ladders[1])
+	}
+}
+
+func TestVirtualiseDepth(t *testing.T) {
+	// Arrange
+	market := newVirtualTestMarket(true, 1,
+		&models.RunnerChange{ID: 1, Atb: [][]float64{{1.5, 1}, {1.4, 1}, {1.3, 1}}},
+		&models.RunnerChange{ID: 2, Atl: [][]float64{{2.1, 10}}},
+	)
+
+	// Act
+	ladders := market.Virtualise(2)
+
+	// Assert
+	assert.Equal(t, ByPrice{{1.9, 11.05}, {1.5, 1}}, ladders[1].AvailableToBack)
+}
+
+func TestSnapVirtual(t *testing.T) {
+	// Arrange
+	market := newVirtualTestMarket(true, 1, twoRunnerChanges()...)
+
+	// Act
+	book := market.SnapVirtual()
+
+	// Assert
+	assert.Equal(t, PriceSize{1.9, 54.21}, book.Runners[0].EX.AvailableToBack)
+	assert.Equal(t, PriceSize{2.04, 19.11}, book.Runners[1].EX.AvailableToBack)
+	assert.Equal(t, PriceSize{1.9, 10}, market.Snap().Runners[0].EX.AvailableToBack)
+}
@@ -1,6 +1,11 @@
 package gofair
 
-import "time"
+import (
+	"time"
+
+	"github.com/jonachehilton/gofair/decimal"
+	"github.com/jonachehilton/gofair/price"
+)
 
 // EventType describes the type of event e.g. Football.
 type EventType struct {
@@ -75,17 +80,17 @@ type VenueResult struct {
 
 // PriceSize contains the Order Price (e.g. 1.51) and the Size of the Order (the amount staked).
 type PriceSize struct {
-	Price float32 `json:"price"`
-	Size  float32 `json:"size"`
+	Price decimal.Price `json:"price"`
+	Size  decimal.Money `json:"size"`
 }
 
 // StartingPrices contains the Betfair Starting Prices (BSP) for a given runner.
 type StartingPrices struct {
-	NearPrice         float32     `json:"nearPrice"`
-	FarPrice          float32     `json:"farPrice"`
-	BackStakeTaken    []PriceSize `json:"backStakeTaken"`
-	LayLiabilityTaken []PriceSize `json:"layLiabilityTaken"`
-	ActualSP          float32     `json:"actualSP"`
+	NearPrice         decimal.Price `json:"nearPrice"`
+	FarPrice          decimal.Price `json:"farPrice"`
+	BackStakeTaken    []PriceSize   `json:"backStakeTaken"`
+	LayLiabilityTaken []PriceSize   `json:"layLiabilityTaken"`
+	ActualSP          decimal.Price `json:"actualSP"`
 }
 
 // ExchangePrices contains the prices that are available on the Exchange.
@@ -101,8 +106,8 @@ type Runner struct {
 	Handicap          float32            `json:"handicap"`
 	Status            string             `json:"status"`
 	AdjustmentFactor  float32            `json:"adjustmentFactor"`
-	LastPriceTraded   float32            `json:"lastPriceTraded"`
-	TotalMatched      float32            `json:"totalMatched"`
+	LastPriceTraded   decimal.Price      `json:"lastPriceTraded"`
+	TotalMatched      decimal.Money      `json:"totalMatched"`
 	RemovalDate       time.Time          `json:"removalDate"`
 	StartingPrices    StartingPrices     `json:"sp"`
 	ExchangePrices    ExchangePrices     `json:"ex"`
@@ -127,6 +132,22 @@ type MarketCatalogueDescription struct {
 	Wallet             string    `json:"wallet"`
 	EachWayDivisor     float32   `json:"eachWayDivisor"`
 	Clarifications     string    `json:"clarifications"`
+
+	PriceLadderDescription PriceLadderDescription `json:"priceLadderDescription"`
+	LineRangeInfo          *MarketLineRangeInfo   `json:"lineRangeInfo,omitempty"`
+}
+
+// PriceLadderDescription describes the price ladder used by a market.
+type PriceLadderDescription struct {
+	Type price.LadderType `json:"type"`
+}
+
+// MarketLineRangeInfo describes the valid lines of a LINE market, between MinUnitValue and MaxUnitValue in steps of Interval.
+type MarketLineRangeInfo struct {
+	MaxUnitValue float64 `json:"maxUnitValue"`
+	MinUnitValue float64 `json:"minUnitValue"`
+	Interval     float64 `json:"interval"`
+	MarketUnit   string  `json:"marketUnit"`
 }
 
 // RunnerCatalogue contains information about a runner for a given market.
@@ -139,11 +160,11 @@ type RunnerCatalogue struct {
 
 // ExBestOffersOverrides contains options to alter the default representation of best offer prices.
 type ExBestOffersOverrides struct {
-	BestPricesDepth          int     `json:"bestPricesDepth,omitempty"`
-	RollupModel              string  `json:"rollupModel,omitempty"`
-	RollupLimit              int     `json:"rollupLimit,omitempty"`
-	RollupLiabilityThreshold float32 `json:"rollupLiabilityThreshold,omitempty"`
-	RollupLiabilityFactor    int     `json:"rollupLiabilityFactor,omitempty"`
+	BestPricesDepth          int           `json:"bestPricesDepth,omitempty"`
+	RollupModel              string        `json:"rollupModel,omitempty"`
+	RollupLimit              int           `json:"rollupLimit,omitempty"`
+	RollupLiabilityThreshold decimal.Money `json:"rollupLiabilityThreshold,omitempty"`
+	RollupLiabilityFactor    int           `json:"rollupLiabilityFactor,omitempty"`
 }
 
 // PriceProjection allows the user to specify selection criteria for returning price data.
@@ -156,30 +177,30 @@ type PriceProjection struct {
 
 // LimitOrder is a simple exchange bet for immediate execution.
 type LimitOrder struct {
-	Size            float32         `json:"size,omitempty"`
-	Price           float32         `json:"price,omitempty"`
+	Size            decimal.Money   `json:"size,omitempty"`
+	Price           decimal.Price   `json:"price,omitempty"`
 	PersistenceType PersistenceType `json:"persistenceType,omitempty"`
 	TimeInForce     TimeInForce     `json:"timeInForce,omitempty"`
-	MinFillSize     float32         `json:"minFillSize,omitempty"`
+	MinFillSize     decimal.Money   `json:"minFillSize,omitempty"`
 	BetTargetType   BetTargetType   `json:"betTargetType,omitempty"`
-	BetTargetSize   float32         `json:"betTargetSize,omitempty"`
+	BetTargetSize   decimal.Money   `json:"betTargetSize,omitempty"`
 }
 
 // LimitOnCloseOrder is to be used to place a new LIMIT_ON_CLOSE bet.
 type LimitOnCloseOrder struct {
-	Liability float32 `json:"liability,omitempty"`
-	Price     float32 `json:"price,omitempty"`
+	Liability decimal.Money `json:"liability,omitempty"`
+	Price     decimal.Price `json:"price,omitempty"`
 }
 
 // MarketCloseOrder is to be used to place a MARKET_ON_CLOSE bet.
 type MarketOnCloseOrder struct {
-	Liability float32 `json:"liability,omitempty"`
+	Liability decimal.Money `json:"liability,omitempty"`
 }
 
 // CancelInstruction is an Instruction to fully or partially cancel an order (only applies to LIMIT orders). The CancelInstruction report won't be returned for marketId level cancel instructions.
 type CancelInstruction struct {
-	BetID         string  `json:"betId"`
-	SizeReduction float32 `json:"sizeReduction,omitempty"`
+	BetID         string        `json:"betId"`
+	SizeReduction decimal.Money `json:"sizeReduction,omitempty"`
 }
 
 // CancelExecutionReport is returned by a call to cancelOrders. (https://docs.developer.betfair.com/display/1smk3cen4v3lu3yomq5qye0ni/cancelOrders)
@@ -196,10 +217,33 @@ type CancelInstructionReport struct {
 	Status        string            `json:"status"`
 	ErrorCode     string            `json:"errorCode"`
 	Instruction   CancelInstruction `json:"instruction"`
-	SizeCancelled float32           `json:"sizeCancelled"`
+	SizeCancelled decimal.Money     `json:"sizeCancelled"`
 	CancelledDate time.Time         `json:"cancelledDate"`
 }
 
+// ReplaceInstruction is an Instruction to cancel the unmatched part of an order and place it again at NewPrice.
+type ReplaceInstruction struct {
+	BetID    string        `json:"betId"`
+	NewPrice decimal.Price `json:"newPrice"`
+}
+
+// ReplaceExecutionReport is returned by a call to replaceOrders. (https://docs.developer.betfair.com/display/1smk3cen4v3lu3yomq5qye0ni/replaceOrders)
+type ReplaceExecutionReport struct {
+	CustomerRef        string                     `json:"customerRef"`
+	Status             ExecutionReportStatus      `json:"status"`
+	ErrorCode          ExecutionReportErrorCode   `json:"errorCode"`
+	MarketID           string                     `json:"marketId"`
+	InstructionReports []ReplaceInstructionReport `json:"instructionReports"`
+}
+
+// ReplaceInstructionReport is a response to a ReplaceInstruction.
+type ReplaceInstructionReport struct {
+	Status                  InstructionReportStatus  `json:"status"`
+	ErrorCode               string                   `json:"errorCode"`
+	CancelInstructionReport *CancelInstructionReport `json:"cancelInstructionReport"`
+	PlaceInstructionReport  *PlaceInstructionReport  `json:"placeInstructionReport"`
+}
+
 // PlaceInstruction contains data required to place a new order.
 type PlaceInstruction struct {
 	OrderType          OrderType           `json:"orderType,omitempty"`
@@ -220,8 +264,8 @@ type PlaceInstructionReport struct {
 	Instruction         PlaceInstruction        `json:"instruction"`
 	BetID               string                  `json:"betId"`
 	PlacedDate          time.Time               `json:"placedDate"`
-	AveragePriceMatched float32                 `json:"averagePriceMatched"`
-	SizeMatched         float32                 `json:"sizeMatched"`
+	AveragePriceMatched decimal.Price           `json:"averagePriceMatched"`
+	SizeMatched         decimal.Money           `json:"sizeMatched"`
 }
 
 // PlaceExecutionReport is returned by a call to placeOrders. (https://docs.developer.betfair.com/display/1smk3cen4v3lu3yomq5qye0ni/placeOrders)
@@ -235,23 +279,23 @@ type PlaceExecutionReport struct {
 
 // Order contains a range of information associated with placing an Order on the Exchange.
 type Order struct {
-	BetID               string    `json:"betId"`
-	OrderType           string    `json:"orderType"`
-	Status              string    `json:"status"`
-	PersistenceType     string    `json:"persistenceType"`
-	Side                string    `json:"side"`
-	Price               float32   `json:"price"`
-	Size                float32   `json:"size"`
-	BSPLiability        float32   `json:"bspLiability"`
-	PlacedDate          time.Time `json:"placedDate"`
-	AvgPriceMatched     float32   `json:"avgPriceMatched"`
-	SizeMatched         float32   `json:"sizeMatched"`
-	SizeRemaining       float32   `json:"sizeRemaining"`
-	SizeLapsed          float32   `json:"sizeLapsed"`
-	SizeCancelled       float32   `json:"sizeCancelled"`
-	SizeVoided          float32   `json:"sizeVoided"`
-	CustomerOrderRef    string    `json:"customerOrderRef"`
-	CustomerStrategyRef string    `json:"customerStrategyRef"`
+	BetID               string        `json:"betId"`
+	OrderType           string        `json:"orderType"`
+	Status              string        `json:"status"`
+	PersistenceType     string        `json:"persistenceType"`
+	Side                string        `json:"side"`
+	Price               decimal.Price `json:"price"`
+	Size                decimal.Money `json:"size"`
+	BSPLiability        decimal.Money `json:"bspLiability"`
+	PlacedDate          time.Time     `json:"placedDate"`
+	AvgPriceMatched     decimal.Price `json:"avgPriceMatched"`
+	SizeMatched         decimal.Money `json:"sizeMatched"`
+	SizeRemaining       decimal.Money `json:"sizeRemaining"`
+	SizeLapsed          decimal.Money `json:"sizeLapsed"`
+	SizeCancelled       decimal.Money `json:"sizeCancelled"`
+	SizeVoided          decimal.Money `json:"sizeVoided"`
+	CustomerOrderRef    string        `json:"customerOrderRef"`
+	CustomerStrategyRef string        `json:"customerStrategyRef"`
 }
 
 // KeyLineSelection provides a description of a markets key line selection, comprising the selectionId and handicap of the team it is applied to.
@@ -267,19 +311,19 @@ type KeyLineDescription struct {
 
 // Match contains data for an individual bet or rollup by price or avg price. Rollup depends on the requested MatchProjection.
 type Match struct {
-	BetID     string    `json:"betId"`
-	MatchID   string    `json:"matchId"`
-	Side      Side      `json:"side"`
-	Price     float32   `json:"price"`
-	Size      float32   `json:"size"`
-	MatchDate time.Time `json:"matchDate"`
+	BetID     string        `json:"betId"`
+	MatchID   string        `json:"matchId"`
+	Side      Side          `json:"side"`
+	Price     decimal.Price `json:"price"`
+	Size      decimal.Money `json:"size"`
+	MatchDate time.Time     `json:"matchDate"`
 }
 
 // MarketCatalogue holds the static data in a market.
 type MarketCatalogue struct {
 	MarketID                   string                     `json:"marketId"`
 	MarketName                 string                     `json:"marketName"`
-	TotalMatched               float32                    `json:"totalMatched"`
+	TotalMatched               decimal.Money              `json:"totalMatched"`
 	MarketStartTime            time.Time                  `json:"marketStartTime"`
 	Competition                Competition                `json:"competition"`
 	Event                      Event                      `json:"event"`
@@ -301,8 +345,8 @@ type MarketBook struct {
 	NumberOfRunners       int                `json:"numberOfRunners"`
 	NumberOfActiveRunners int                `json:"numberOfActiveRunners"`
 	LastMatchTime         time.Time          `json:"lastMatchTime"`
-	TotalMatched          float32            `json:"totalMatched"`
-	TotalAvailable        float32            `json:"totalAvailable"`
+	TotalMatched          decimal.Money      `json:"totalMatched"`
+	TotalAvailable        decimal.Money      `json:"totalAvailable"`
 	CrossMatching         bool               `json:"crossMatching"`
 	RunnersVoidable       bool               `json:"runnersVoidable"`
 	Version               int64              `json:"version"`
@@ -312,10 +356,10 @@ type MarketBook struct {
 
 // RunnerProfitAndLoss contains potential changes in winnings in the event of a particular selection winning, losing or placing.
 type RunnerProfitAndLoss struct {
-	SelectionID int     `json:"selectionId"`
-	IfWin       float32 `json:"ifWin"`
-	IfLose      float32 `json:"ifLose"`
-	IfPlace     float32 `json:"ifPlace"`
+	SelectionID int           `json:"selectionId"`
+	IfWin       decimal.Money `json:"ifWin"`
+	IfLose      decimal.Money `json:"ifLose"`
+	IfPlace     decimal.Money `json:"ifPlace"`
 }
 
 // MarketProfitAndLoss contains changes in winnings depending on the performance of selections associated with a given market.
@@ -325,27 +369,30 @@ type MarketProfitAndLoss struct {
 	ProfitAndLosses   []RunnerProfitAndLoss `json:"profitAndLosses"`
 }
 
+// TimeRangeFilter selects the markets starting From and To the given times, a zero time leaves that end of the range
+// open.
 type TimeRangeFilter struct {
-	From string `json:"from,omitempty"`
-	To   string `json:"to,omitempty"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
 }
 
 // MarketFilter is the filter to select desired markets. All markets that match the criteria in the filter are selected.
 type MarketFilter struct {
-	TextQuery          string           `json:"textQuery,omitempty"`
-	EventTypeIds       []string         `json:"eventTypeIds,omitempty"`
-	MarketCountries    []string         `json:"marketCountries,omitempty"`
-	MarketIds          []string         `json:"marketIds,omitempty"`
-	EventIds           []string         `json:"eventIds,omitempty"`
-	CompetitionIds     []string         `json:"competitionIds,omitempty"`
-	BSPOnly            bool             `json:"bspOnly,omitempty"`
-	TurnInPlayEnabled  bool             `json:"turnInPLayEnabled,omitempty"`
-	InPlayOnly         bool             `json:"inPlayOnly,omitempty"`
-	MarketBettingTypes []string         `json:"marketBettingTypes,omitempty"`
-	MarketTypeCodes    []string         `json:"marketTypeCodes,omitempty"`
-	RaceTypes          []string         `json:"raceTypes,omitempty"`
-	MarketStartTime    *TimeRangeFilter `json:"marketStartTime,omitempty"`
-	WithOrders         string           `json:"withOrders,omitempty"`
+	TextQuery          string              `json:"textQuery,omitempty"`
+	EventTypeIds       []string            `json:"eventTypeIds,omitempty"`
+	MarketCountries    []string            `json:"marketCountries,omitempty"`
+	MarketIds          []string            `json:"marketIds,omitempty"`
+	EventIds           []string            `json:"eventIds,omitempty"`
+	CompetitionIds     []string            `json:"competitionIds,omitempty"`
+	BSPOnly            bool                `json:"bspOnly,omitempty"`
+	TurnInPlayEnabled  bool                `json:"turnInPlayEnabled,omitempty"`
+	InPlayOnly         bool                `json:"inPlayOnly,omitempty"`
+	MarketBettingTypes []MarketBettingType `json:"marketBettingTypes,omitempty"`
+	MarketTypeCodes    []string            `json:"marketTypeCodes,omitempty"`
+	Venues             []string            `json:"venues,omitempty"`
+	RaceTypes          []string            `json:"raceTypes,omitempty"`
+	MarketStartTime    *TimeRangeFilter    `json:"marketStartTime,omitempty"`
+	WithOrders         []OrderStatus       `json:"withOrders,omitempty"`
 }
 
 type MarketDataFilter struct {
@@ -360,19 +407,19 @@ type CurrentOrderSummary struct {
 	SelectionID         int             `json:"selectionId"`
 	Handicap            float64         `json:"handicap"`
 	PriceSize           PriceSize       `json:"priceSize"`
-	BSPLiability        float64         `json:"bspLiability"`
+	BSPLiability        decimal.Money   `json:"bspLiability"`
 	Side                Side            `json:"side"`
 	Status              OrderStatus     `json:"status"`
 	PersistenceType     PersistenceType `json:"persistenceType"`
 	OrderType           OrderType       `json:"orderType"`
 	PlacedDate          time.Time       `json:"placedDate"`
 	MatchedDate         time.Time       `json:"matchedDate"`
-	AveragePriceMatched float64         `json:"averagePriceMatched,omitempty"`
-	SizeMatched         float64         `json:"sizeMatched,omitempty"`
-	SizeRemaining       float64         `json:"sizeRemaining,omitempty"`
-	SizeLapsed          float64         `json:"sizeLapsed,omitempty"`
-	SizeCancelled       float64         `json:"sizeCancelled,omitempty"`
-	SizeVoided          float64         `json:"sizeVoided,omitempty"`
+	AveragePriceMatched decimal.Price   `json:"averagePriceMatched,omitempty"`
+	SizeMatched         decimal.Money   `json:"sizeMatched,omitempty"`
+	SizeRemaining       decimal.Money   `json:"sizeRemaining,omitempty"`
+	SizeLapsed          decimal.Money   `json:"sizeLapsed,omitempty"`
+	SizeCancelled       decimal.Money   `json:"sizeCancelled,omitempty"`
+	SizeVoided          decimal.Money   `json:"sizeVoided,omitempty"`
 	RegulatorAuthCode   string          `json:"regulatorAuthCode,omitempty"`
 	RegulatorCode       string          `json:"regulatorCode,omitempty"`
 	CustomerOrderRef    string          `json:"customerOrderRef,omitempty"`
@@ -385,12 +432,45 @@ type CurrentOrderSummaryReport struct {
 	MoreAvailable bool                  `json:"moreAvailable"`
 }
 
+// ClearedOrderSummary contains data about a settled, voided, lapsed or cancelled order.
+type ClearedOrderSummary struct {
+	EventTypeID         string          `json:"eventTypeId"`
+	EventID             string          `json:"eventId"`
+	MarketID            string          `json:"marketId"`
+	SelectionID         int             `json:"selectionId"`
+	Handicap            float64         `json:"handicap"`
+	BetID               string          `json:"betId"`
+	PlacedDate          time.Time       `json:"placedDate"`
+	PersistenceType     PersistenceType `json:"persistenceType"`
+	OrderType           OrderType       `json:"orderType"`
+	Side                Side            `json:"side"`
+	BetOutcome          string          `json:"betOutcome"`
+	PriceRequested      decimal.Price   `json:"priceRequested"`
+	SettledDate         time.Time       `json:"settledDate"`
+	LastMatchedDate     time.Time       `json:"lastMatchedDate"`
+	BetCount            int             `json:"betCount"`
+	Commission          decimal.Money   `json:"commission"`
+	PriceMatched        decimal.Price   `json:"priceMatched"`
+	PriceReduced        bool            `json:"priceReduced"`
+	SizeSettled         decimal.Money   `json:"sizeSettled"`
+	Profit              decimal.Money   `json:"profit"`
+	SizeCancelled       decimal.Money   `json:"sizeCancelled"`
+	CustomerOrderRef    string          `json:"customerOrderRef,omitempty"`
+	CustomerStrategyRef string          `json:"customerStrategyRef,omitempty"`
+}
+
+// ClearedOrderSummaryReport is container representing search results for cleared orders.
+type ClearedOrderSummaryReport struct {
+	ClearedOrders []ClearedOrderSummary `json:"clearedOrders"`
+	MoreAvailable bool                  `json:"moreAvailable"`
+}
+
 // AccountFundsResponse contains data about the availability of funds.
 type AccountFundsResponse struct {
-	AvailableToBetBalance float64 `json:"availableToBetBalance"`
-	Exposure              float64 `json:"exposure"`
-	RetainedCommission    float64 `json:"retainedCommission"`
-	ExposureLimit         float64 `json:"exposureLimit"`
-	DiscountRate          float64 `json:"discountRate"`
-	PointsBalance         int     `json:"pointsBalance"`
+	AvailableToBetBalance decimal.Money `json:"availableToBetBalance"`
+	Exposure              decimal.Money `json:"exposure"`
+	RetainedCommission    decimal.Money `json:"retainedCommission"`
+	ExposureLimit         decimal.Money `json:"exposureLimit"`
+	DiscountRate          float64       `json:"discountRate"`
+	PointsBalance         int           `json:"pointsBalance"`
 }
@@ -0,0 +1,284 @@
+package gofair
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jonachehilton/gofair/decimal"
+	"github.com/jonachehilton/gofair/price"
+)
+
+// CurrencyRules are the Exchange's minimum bet sizes in one currency.
+// https://docs.developer.betfair.com/display/1smk3cen4v3lu3yomq5qye0ni/Additional+Information#AdditionalInformation-CurrencyParameters
+type CurrencyRules struct {
+	// MinimumStake is the smallest stake of a LIMIT order, and the smallest liability of a backing BSP order
+	MinimumStake float64
+	// MinimumBetPayout is the payout (stake times price) at which a stake below MinimumStake is accepted
+	MinimumBetPayout float64
+	// MinimumBSPLayLiability is the smallest liability of a laying BSP order
+	MinimumBSPLayLiability float64
+}
+
+// Currencies maps ISO 4217 currency codes to the Exchange's minimum bet sizes. Entries may be replaced if the Exchange
+// changes them.
+var Currencies = map[string]CurrencyRules{
+	"GBP": {MinimumStake: MinimumStakeSizeGBP, MinimumBetPayout: 10, MinimumBSPLayLiability: 10},
+	"EUR": {MinimumStake: 1, MinimumBetPayout: 10, MinimumBSPLayLiability: 10},
+	"USD": {MinimumStake: 3, MinimumBetPayout: 20, MinimumBSPLayLiability: 20},
+	"HKD": {MinimumStake: 20, MinimumBetPayout: 125, MinimumBSPLayLiability: 125},
+	"AUD": {MinimumStake: 5, MinimumBetPayout: 30, MinimumBSPLayLiability: 30},
+	"CAD": {MinimumStake: 6, MinimumBetPayout: 30, MinimumBSPLayLiability: 30},
+	"DKK": {MinimumStake: 15, MinimumBetPayout: 150, MinimumBSPLayLiability: 150},
+	"NOK": {MinimumStake: 15, MinimumBetPayout: 150, MinimumBSPLayLiability: 150},
+	"SEK": {MinimumStake: 15, MinimumBetPayout: 150, MinimumBSPLayLiability: 150},
+	"SGD": {MinimumStake: 6, MinimumBetPayout: 30, MinimumBSPLayLiability: 30},
+}
+
+// Italian exchange sizing: stakes are in whole multiples of ItalianStakeIncrement euros of at least ItalianMinimumStake,
+// and the below minimum stake rule does not apply.
+const (
+	ItalianMinimumStake   = 2.00
+	ItalianStakeIncrement = 0.50
+)
+
+// ValidationOptions describe the account and market that instructions are validated for.
+type ValidationOptions struct {
+	// Currency of the account, GBP if empty
+	Currency string
+	// Ladder of the market, price.Classic if nil
+	Ladder *price.Ladder
+	// Italian applies the Italian exchange's sizing rules
+	Italian bool
+}
+
+// FieldError is a single rule broken by a field of a PlaceInstruction.
+type FieldError struct {
+	Instruction int
+	Field       string
+	Message     string
+}
+
+func (err *FieldError) Error() string {
+	return fmt.Sprintf("instruction %d: %s %s", err.Instruction, err.Field, err.Message)
+}
+
+// ValidationError lists every FieldError found in a set of instructions.
+type ValidationError struct {
+	Fields []*FieldError
+}
+
+func (err *ValidationError) Error() string {
+	messages := make([]string, len(err.Fields))
+	for i, field := range err.Fields {
+		messages[i] = field.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (err *ValidationError) Unwrap() []error {
+	errs := make([]error, len(err.Fields))
+	for i, field := range err.Fields {
+		errs[i] = field
+	}
+	return errs
+}
+
+// instructionValidator collects the FieldErrors of one instruction
+type instructionValidator struct {
+	index   int
+	rules   CurrencyRules
+	options ValidationOptions
+	errors  []*FieldError
+}
+
+func (v *instructionValidator) fail(field string, format string, args ...interface{}) {
+	v.errors = append(v.errors, &FieldError{Instruction: v.index, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// twoDecimalPlaces reports whether an amount is a whole number of pence or cents
+func twoDecimalPlaces(amount float64) bool {
+	return math.Abs(amount*100-math.Round(amount*100)) < 1e-6
+}
+
+func (v *instructionValidator) price(field string, p decimal.Price) {
+	if _, err := v.options.Ladder.Index(p.Float64()); err != nil {
+		v.fail(field, "%s", err.Error())
+	}
+}
+
+// stake checks an amount staked, allowing one below the minimum if its payout is large enough
+func (v *instructionValidator) stake(field string, stake float64, p float64) {
+	if stake <= 0 {
+		v.fail(field, "must be greater than 0")
+		return
+	}
+	if !twoDecimalPlaces(stake) {
+		v.fail(field, "must have at most 2 decimal places")
+	}
+
+	if v.options.Italian {
+		v.italian(field, stake)
+		return
+	}
+	if stake < v.rules.MinimumStake && stake*p < v.rules.MinimumBetPayout {
+		v.fail(field, "is below the minimum stake of %.2f %s and pays out less than %.2f", v.rules.MinimumStake, v.options.Currency, v.rules.MinimumBetPayout)
+	}
+}
+
+// liability checks the liability of a BSP order
+func (v *instructionValidator) liability(field string, liability decimal.Money, side Side) {
+	amount := liability.Float64()
+	if amount <= 0 {
+		v.fail(field, "must be greater than 0")
+		return
+	}
+	if !twoDecimalPlaces(amount) {
+		v.fail(field, "must have at most 2 decimal places")
+	}
+
+	if v.options.Italian {
+		v.italian(field, amount)
+		return
+	}
+	minimum := v.rules.MinimumStake
+	if side == SideEnum.Lay {
+		minimum = v.rules.MinimumBSPLayLiability
+	}
+	if amount < minimum {
+		v.fail(field, "is below the minimum of %.2f %s", minimum, v.options.Currency)
+	}
+}
+
+func (v *instructionValidator) italian(field string, amount float64) {
+	if amount < ItalianMinimumStake {
+		v.fail(field, "is below the Italian minimum stake of %.2f EUR", ItalianMinimumStake)
+	}
+	if math.Mod(math.Round(amount*100), ItalianStakeIncrement*100) != 0 {
+		v.fail(field, "must be a multiple of %.2f EUR on the Italian exchange", ItalianStakeIncrement)
+	}
+}
+
+func (v *instructionValidator) limitOrder(instruction PlaceInstruction) {
+	order := instruction.LimitOrder
+	v.price("limitOrder.price", order.Price)
+	p := order.Price.Float64()
+
+	if order.PersistenceType == "" && order.TimeInForce == "" {
+		v.fail("limitOrder.persistenceType", "is required unless timeInForce is set")
+	}
+
+	if order.BetTargetType == "" {
+		if order.BetTargetSize != 0 {
+			v.fail("limitOrder.betTargetSize", "must not be set without betTargetType")
+		}
+		v.stake("limitOrder.size", order.Size.Float64(), p)
+	} else {
+		if order.Size != 0 {
+			v.fail("limitOrder.size", "must not be set with betTargetType")
+		}
+
+		// A bet target is accepted when the stake it implies would be
+		target := order.BetTargetSize.Float64()
+		switch order.BetTargetType {
+		case BetTargetTypeEnum.Payout:
+			if target <= 0 || p <= 0 {
+				v.fail("limitOrder.betTargetSize", "must be greater than 0")
+			} else {
+				v.stake("limitOrder.betTargetSize", math.Round(target/p*100)/100, p)
+			}
+		case BetTargetTypeEnum.BackersProfit:
+			if target <= 0 || p <= 1 {
+				v.fail("limitOrder.betTargetSize", "must be greater than 0")
+			} else {
+				v.stake("limitOrder.betTargetSize", math.Round(target/(p-1)*100)/100, p)
+			}
+		default:
+			v.fail("limitOrder.betTargetType", "must be BACKERS_PROFIT or PAYOUT")
+		}
+	}
+
+	if order.MinFillSize != 0 {
+		if !twoDecimalPlaces(order.MinFillSize.Float64()) {
+			v.fail("limitOrder.minFillSize", "must have at most 2 decimal places")
+		}
+		if order.MinFillSize > order.Size {
+			v.fail("limitOrder.minFillSize", "must not be greater than size")
+		}
+	}
+
+	if instruction.LimitOnCloseOrder != nil {
+		v.fail("limitOnCloseOrder", "must not be set for LIMIT orders")
+	}
+	if instruction.MarketOnCloseOrder != nil {
+		v.fail("marketOnCloseOrder", "must not be set for LIMIT orders")
+	}
+}
+
+func (v *instructionValidator) limitOnCloseOrder(instruction PlaceInstruction) {
+	if instruction.LimitOnCloseOrder == nil {
+		v.fail("limitOnCloseOrder", "is required for LIMIT_ON_CLOSE orders")
+	} else {
+		v.price("limitOnCloseOrder.price", instruction.LimitOnCloseOrder.Price)
+		v.liability("limitOnCloseOrder.liability", instruction.LimitOnCloseOrder.Liability, instruction.Side)
+	}
+	if instruction.MarketOnCloseOrder != nil {
+		v.fail("marketOnCloseOrder", "must not be set for LIMIT_ON_CLOSE orders")
+	}
+}
+
+func (v *instructionValidator) marketOnCloseOrder(instruction PlaceInstruction) {
+	if instruction.MarketOnCloseOrder == nil {
+		v.fail("marketOnCloseOrder", "is required for MARKET_ON_CLOSE orders")
+	} else {
+		v.liability("marketOnCloseOrder.liability", instruction.MarketOnCloseOrder.Liability, instruction.Side)
+	}
+	if instruction.LimitOnCloseOrder != nil {
+		v.fail("limitOnCloseOrder", "must not be set for MARKET_ON_CLOSE orders")
+	}
+}
+
+// ValidateInstructions checks every instruction against the Exchange's rules for the currency, ladder and exchange in
+// options, returning a *ValidationError listing each field in breach, or nil.
+func ValidateInstructions(placeInstructions []PlaceInstruction, options ValidationOptions) error {
+	if options.Currency == "" {
+		options.Currency = "GBP"
+	}
+	if options.Ladder == nil {
+		options.Ladder = price.Classic
+	}
+	rules, ok := Currencies[options.Currency]
+	if !ok && !options.Italian {
+		return fmt.Errorf("Unknown currency %s", options.Currency)
+	}
+
+	validation := &ValidationError{}
+	for i, instruction := range placeInstructions {
+		v := &instructionValidator{index: i, rules: rules, options: options}
+
+		if instruction.SelectionID <= 0 {
+			v.fail("selectionId", "is required")
+		}
+		if instruction.Side != SideEnum.Back && instruction.Side != SideEnum.Lay {
+			v.fail("side", "must be BACK or LAY")
+		}
+
+		switch instruction.OrderType {
+		case OrderTypeEnum.Limit:
+			v.limitOrder(instruction)
+		case OrderTypeEnum.LimitOnClose:
+			v.limitOnCloseOrder(instruction)
+		case OrderTypeEnum.MarketOnClose:
+			v.marketOnCloseOrder(instruction)
+		default:
+			v.fail("orderType", "must be LIMIT, LIMIT_ON_CLOSE or MARKET_ON_CLOSE")
+		}
+
+		validation.Fields = append(validation.Fields, v.errors...)
+	}
+
+	if len(validation.Fields) > 0 {
+		return validation
+	}
+	return nil
+}
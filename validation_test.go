@@ -0,0 +1,163 @@
+package gofair
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jonachehilton/gofair/decimal"
+	"github.com/jonachehilton/gofair/price"
+)
+
+func TestValidateInstructions(t *testing.T) {
+	// Arrange
+	limit := func(side Side, p decimal.Price, size decimal.Money) PlaceInstruction {
+		return PlaceInstruction{
+			OrderType:   OrderTypeEnum.Limit,
+			SelectionID: 1,
+			Side:        side,
+			LimitOrder:  LimitOrder{Price: p, Size: size, PersistenceType: PersistenceTypeEnum.Lapse},
+		}
+	}
+	target := func(targetType BetTargetType, p decimal.Price, size decimal.Money) PlaceInstruction {
+		instruction := limit(SideEnum.Back, p, 0)
+		instruction.LimitOrder.BetTargetType = targetType
+		instruction.LimitOrder.BetTargetSize = size
+		return instruction
+	}
+	testCases := []struct {
+		name        string
+		instruction PlaceInstruction
+		options     ValidationOptions
+		expected    []string
+	}{
+		{
+			name:        "valid limit",
+			instruction: limit(SideEnum.Back, 2.02, 5),
+		},
+		{
+			name:        "below minimum stake",
+			instruction: limit(SideEnum.Back, 2, 0.5),
+			expected:    []string{"instruction 0: limitOrder.size is below the minimum stake of 1.00 GBP and pays out less than 10.00"},
+		},
+		{
+			name:        "below minimum stake with large payout",
+			instruction: limit(SideEnum.Back, 25, 0.5),
+		},
+		{
+			name:        "minimum stake in currency",
+			instruction: limit(SideEnum.Lay, 2, 2),
+			options:     ValidationOptions{Currency: "USD"},
+			expected:    []string{"instruction 0: limitOrder.size is below the minimum stake of 3.00 USD and pays out less than 20.00"},
+		},
+		{
+			name:        "size precision and price",
+			instruction: limit(SideEnum.Back, 2.03, 5.125),
+			expected: []string{
+				"instruction 0: limitOrder.price Price 2.03 is not a valid CLASSIC price",
+				"instruction 0: limitOrder.size must have at most 2 decimal places",
+			},
+		},
+		{
+			name:        "finest ladder price",
+			instruction: limit(SideEnum.Back, 2.03, 5),
+			options:     ValidationOptions{Ladder: price.Finest},
+		},
+		{
+			name:        "payout target",
+			instruction: target(BetTargetTypeEnum.Payout, 4, 20),
+		},
+		{
+			name:        "payout target below minimum stake",
+			instruction: target(BetTargetTypeEnum.Payout, 4, 2),
+			expected:    []string{"instruction 0: limitOrder.betTargetSize is below the minimum stake of 1.00 GBP and pays out less than 10.00"},
+		},
+		{
+			name: "target with size",
+			instruction: func() PlaceInstruction {
+				instruction := target(BetTargetTypeEnum.BackersProfit, 3, 10)
+				instruction.LimitOrder.Size = 5
+				return instruction
+			}(),
+			expected: []string{"instruction 0: limitOrder.size must not be set with betTargetType"},
+		},
+		{
+			name:        "italian",
+			instruction: limit(SideEnum.Back, 2, 2.25),
+			options:     ValidationOptions{Currency: "EUR", Italian: true},
+			expected:    []string{"instruction 0: limitOrder.size must be a multiple of 0.50 EUR on the Italian exchange"},
+		},
+		{
+			name:        "italian minimum",
+			instruction: limit(SideEnum.Back, 30, 1),
+			options:     ValidationOptions{Currency: "EUR", Italian: true},
+			expected:    []string{"instruction 0: limitOrder.size is below the Italian minimum stake of 2.00 EUR"},
+		},
+		{
+			name:        "limit on close without sub-struct",
+			instruction: PlaceInstruction{OrderType: OrderTypeEnum.LimitOnClose, SelectionID: 1, Side: SideEnum.Back},
+			expected:    []string{"instruction 0: limitOnCloseOrder is required for LIMIT_ON_CLOSE orders"},
+		},
+		{
+			name:        "limit on close lay liability",
+			instruction: PlaceInstruction{OrderType: OrderTypeEnum.LimitOnClose, SelectionID: 1, Side: SideEnum.Lay, LimitOnCloseOrder: &LimitOnCloseOrder{Price: 10, Liability: 5}},
+			expected:    []string{"instruction 0: limitOnCloseOrder.liability is below the minimum of 10.00 GBP"},
+		},
+		{
+			name:        "market on close without sub-struct",
+			instruction: PlaceInstruction{OrderType: OrderTypeEnum.MarketOnClose, SelectionID: 1, Side: SideEnum.Back, LimitOnCloseOrder: &LimitOnCloseOrder{Price: 10, Liability: 5}},
+			expected: []string{
+				"instruction 0: marketOnCloseOrder is required for MARKET_ON_CLOSE orders",
+				"instruction 0: limitOnCloseOrder must not be set for MARKET_ON_CLOSE orders",
+			},
+		},
+		{
+			name:        "missing fields",
+			instruction: PlaceInstruction{OrderType: OrderTypeEnum.MarketOnClose, MarketOnCloseOrder: &MarketOnCloseOrder{Liability: 5}},
+			expected: []string{
+				"instruction 0: selectionId is required",
+				"instruction 0: side must be BACK or LAY",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Act
+			err := ValidateInstructions([]PlaceInstruction{testCase.instruction}, testCase.options)
+
+			// Assert
+			if testCase.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var validation *ValidationError
+			assert.ErrorAs(t, err, &validation)
+			messages := []string{}
+			for _, field := range validation.Fields {
+				messages = append(messages, field.Error())
+			}
+			assert.Equal(t, testCase.expected, messages)
+		})
+	}
+}
+
+func TestPlaceOrdersValidation(t *testing.T) {
+	// Arrange
+	client, server := newTestClient(t)
+	client.Login()
+	client.Betting.Validation = &ValidationOptions{}
+	requests := len(server.Requests())
+	instructions := []PlaceInstruction{
+		{OrderType: OrderTypeEnum.Limit, SelectionID: 1, Side: SideEnum.Back, LimitOrder: LimitOrder{Price: 2, Size: 0.5, PersistenceType: PersistenceTypeEnum.Lapse}},
+	}
+
+	// Act
+	_, err := client.Betting.PlaceOrders("1.23", instructions)
+
+	// Assert
+	var field *FieldError
+	assert.ErrorAs(t, err, &field)
+	assert.Equal(t, "limitOrder.size", field.Field)
+	assert.Len(t, server.Requests(), requests)
+}